@@ -0,0 +1,10 @@
+// Package api holds the definitions of the delivery service apis: the OpenAPI document of the
+// http api and, under delivery, the protobuf definition of the grpc one.
+package api
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 document of the http api, the router validates requests against it.
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,218 @@
+package api_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/api"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/router"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func loadSpec(t *testing.T) *openapi3.T {
+	t.Helper()
+
+	spec, err := openapi3.NewLoader().LoadFromData(api.OpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := spec.Validate(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+// jsonFields returns the members of the json encoding of t. Types marshalling themselves are
+// asked for the members of their zero value.
+func jsonFields(t reflect.Type) []string {
+	if t.Implements(reflect.TypeFor[json.Marshaler]()) {
+		data, err := json.Marshal(reflect.Zero(t).Interface())
+		if err != nil {
+			panic(err)
+		}
+		var members map[string]json.RawMessage
+		if err := json.Unmarshal(data, &members); err != nil {
+			panic(err)
+		}
+		fields := make([]string, 0, len(members))
+		for member := range members {
+			fields = append(fields, member)
+		}
+		return fields
+	}
+
+	var fields []string
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		switch {
+		case tag == "-":
+		case field.Anonymous && tag == "":
+			fields = append(fields, jsonFields(field.Type)...)
+		case !field.IsExported():
+		case tag == "":
+			fields = append(fields, field.Name)
+		default:
+			fields = append(fields, tag)
+		}
+	}
+	return fields
+}
+
+// TestSchemasMatchDomain fails when a payload of the domain gains, loses or renames a field
+// without api/openapi.json following.
+func TestSchemasMatchDomain(t *testing.T) {
+	spec := loadSpec(t)
+
+	for name, value := range map[string]any{
+		"RequestInput":            domain.RequestInput{},
+		"RequestResult":           domain.RequestResult{},
+		"UserInfo":                domain.UserInfo{},
+		"Address":                 domain.Address{},
+		"RoutingInfo":             domain.RoutingInfo{},
+		"Location":                domain.Location{},
+		"ScheduledDeliveryWindow": domain.ScheduledDeliveryWindow{},
+		"BatchRequestInput":       domain.BatchRequestInput{},
+		"BatchRequestItemResult":  domain.BatchRequestItemResult{},
+		"BatchRequestResult":      domain.BatchRequestResult{},
+		"FieldViolation":          internal_error.FieldViolation{},
+		"WebhookInput":            domain.WebhookInput{},
+		"WebhookResult":           domain.WebhookResult{},
+		"Courier":                 domain.Courier{},
+		"ProofOfDelivery":         domain.ProofOfDelivery{},
+		"Tracking":                domain.Tracking{},
+		"Slot":                    domain.Slot{},
+		"SlotsResult":             domain.SlotsResult{},
+		"Shipment":                domain.Shipment{},
+		"GetResult":               domain.GetResult{},
+		"ListResult":              domain.ListResult{},
+		"NearbyResult":            domain.NearbyResult{},
+		"CancelResult":            domain.CancelResult{},
+		"ShipmentFilter":          domain.ShipmentFilter{},
+		"ShipmentEvent":           domain.ShipmentEvent{},
+		"QuoteInput":              domain.QuoteInput{},
+		"QuoteResult":             domain.QuoteResult{},
+		"Price":                   domain.Price{},
+		"PriceBreakdown":          domain.PriceBreakdown{},
+	} {
+		schema := spec.Components.Schemas[name]
+		if schema == nil {
+			t.Errorf("%s is not documented", name)
+			continue
+		}
+
+		fields := jsonFields(reflect.TypeOf(value))
+		for _, field := range fields {
+			if _, ok := schema.Value.Properties[field]; !ok {
+				t.Errorf("%s.%s is not documented", name, field)
+			}
+		}
+		for property := range schema.Value.Properties {
+			if !slices.Contains(fields, property) {
+				t.Errorf("%s.%s is documented but not in %T", name, property, value)
+			}
+		}
+	}
+}
+
+// TestSchemasMatchClient fails when a payload of the client has a field the api does not
+// document or misses one it requires.
+func TestSchemasMatchClient(t *testing.T) {
+	spec := loadSpec(t)
+
+	for name, value := range map[string]any{
+		"RequestInput":            client.RequestInput{},
+		"UserInfo":                client.UserInfo{},
+		"Address":                 client.Address{},
+		"RoutingInfo":             client.RoutingInfo{},
+		"Location":                client.Location{},
+		"ScheduledDeliveryWindow": client.ScheduledDeliveryWindow{},
+		"WebhookInput":            client.WebhookInput{},
+		"Courier":                 client.Courier{},
+		"ProofOfDelivery":         client.ProofOfDelivery{},
+		"Tracking":                client.Tracking{},
+		"FieldViolation":          client.FieldViolation{},
+		"BatchRequestInput":       client.BatchRequestInput{},
+		"BatchRequestItemResult":  client.BatchRequestItemResult{},
+		"BatchRequestResult":      client.BatchRequestResult{},
+		"Slot":                    client.Slot{},
+		"SlotsResult":             client.SlotsResult{},
+		"Shipment":                client.Shipment{},
+		"ListResult":              client.ListResult{},
+		"NearbyResult":            client.NearbyResult{},
+		"ShipmentFilter":          client.ShipmentFilter{},
+		"ShipmentEvent":           client.ShipmentEvent{},
+		"QuoteInput":              client.QuoteInput{},
+		"QuoteResult":             client.QuoteResult{},
+		"Price":                   client.Price{},
+		"PriceBreakdown":          client.PriceBreakdown{},
+		"Error":                   client.Error{},
+	} {
+		schema := spec.Components.Schemas[name]
+		if schema == nil {
+			t.Errorf("%s is not documented", name)
+			continue
+		}
+
+		fields := jsonFields(reflect.TypeOf(value))
+		for _, field := range fields {
+			if _, ok := schema.Value.Properties[field]; !ok {
+				t.Errorf("%T.%s is not documented in %s", value, field, name)
+			}
+		}
+		for _, required := range schema.Value.Required {
+			if !slices.Contains(fields, required) {
+				t.Errorf("%T misses %s.%s", value, name, required)
+			}
+		}
+	}
+}
+
+func TestEnumsMatchDomain(t *testing.T) {
+	spec := loadSpec(t)
+
+	for _, enum := range []struct {
+		schema *openapi3.Schema
+		values []string
+	}{
+		{spec.Components.Schemas["ShipmentStatus"].Value, domain.ShipmentStatuses},
+		{
+			spec.Components.Schemas["BatchRequestItemResult"].Value.Properties["status"].Value,
+			[]string{domain.BatchItemStatusCreated, domain.BatchItemStatusDuplicate, domain.BatchItemStatusInvalid},
+		},
+		{
+			spec.Components.Schemas["BatchRequestItemResult"].Value.Properties["status"].Value,
+			[]string{client.BatchItemStatusCreated, client.BatchItemStatusDuplicate, client.BatchItemStatusInvalid},
+		},
+	} {
+		var documented []string
+		for _, value := range enum.schema.Enum {
+			documented = append(documented, value.(string))
+		}
+		if !slices.Equal(documented, enum.values) {
+			t.Errorf("documented %v, want %v", documented, enum.values)
+		}
+	}
+}
+
+// TestRoutesAreDocumented relies on the router refusing to serve undocumented routes.
+func TestRoutesAreDocumented(t *testing.T) {
+	defer func() {
+		if err := recover(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	router.NewRouter(&router.Config{}, nil, clock.NewClock(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
@@ -0,0 +1,431 @@
+// Command deliveryctl lets operators inspect and fix shipments through the use case of the
+// service, so status changes go through its state machine and reach the core and subscribers.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/config"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+)
+
+const usage = `usage: deliveryctl [-json] [-v] <command> [arguments]
+
+commands:
+  inspect <shipment_uid>                 show a shipment and the statuses it went through
+  list [-status s]... [-zone z]... [-page-size n] [-page-token t]
+                                         list shipments in uid order
+  transition <shipment_uid> <status>     force a status the state machine allows
+  redispatch <shipment_uid>              request a delivery guy again for a shipment stuck on the 3pl
+  replay-webhooks [-limit n]             send the failed core webhooks again, oldest first
+  stats                                  count shipments by status, failed dispatches and failed core webhooks
+  migrate up [-steps n] | down (-steps n | -all) | version
+                                         run the migrations of -path
+
+environment:
+  DATABASE_URL  postgres of the service, ` + config.DefaultDatabaseURL + ` by default
+  ZONES_FILE    zones file of the service when it runs with one
+`
+
+// errUsage makes main print the usage and exit with 2.
+var errUsage = errors.New("invalid usage")
+
+type command struct {
+	ctx    context.Context
+	admin  app.Admin
+	out    io.Writer
+	asJSON bool
+}
+
+func main() {
+	flags := flag.NewFlagSet("deliveryctl", flag.ContinueOnError)
+	flags.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	asJSON := flags.Bool("json", false, "print results as json")
+	verbose := flags.Bool("v", false, "log what the use case does to stderr")
+
+	if err := flags.Parse(os.Args[1:]); err != nil {
+		os.Exit(2)
+	}
+
+	if flags.NArg() == 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if *verbose {
+		logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	if err := run(ctx, flags.Arg(0), flags.Args()[1:], *asJSON, logger); err != nil {
+		if errors.Is(err, errUsage) {
+			if err != errUsage {
+				fmt.Fprintln(os.Stderr, "deliveryctl:", err)
+			}
+			flags.Usage()
+			os.Exit(2)
+		}
+
+		fmt.Fprintln(os.Stderr, "deliveryctl:", err)
+		os.Exit(1)
+	}
+}
+
+// commands run through the use case, migrate runs on the database alone.
+var commands = map[string]func(c *command, args []string) error{
+	"inspect":         (*command).inspect,
+	"list":            (*command).list,
+	"transition":      (*command).transition,
+	"redispatch":      (*command).redispatch,
+	"replay-webhooks": (*command).replayWebhooks,
+	"stats":           (*command).stats,
+}
+
+func run(ctx context.Context, name string, args []string, asJSON bool, logger *slog.Logger) error {
+	runCommand, ok := commands[name]
+	if !ok && name != "migrate" {
+		return fmt.Errorf("%w: unknown command %q", errUsage, name)
+	}
+
+	databaseURL := getenv("DATABASE_URL", config.DefaultDatabaseURL)
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if name == "migrate" {
+		return runMigrate(db, args, os.Stdout)
+	}
+
+	config := config.Default()
+	config.ZonesConfig.File = os.Getenv("ZONES_FILE")
+	// changes reach the subscribers of the running instances
+	config.EventsConfig.DatabaseURL = databaseURL
+
+	admin, err := app.NewAdmin(ctx, config, db, clock.NewClock(), logger)
+	if err != nil {
+		return err
+	}
+
+	return runCommand(&command{ctx: ctx, admin: admin, out: os.Stdout, asJSON: asJSON}, args)
+}
+
+func (c *command) inspect(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	result, err := c.admin.History(c.ctx, &domain.HistoryInput{ShipmentUID: args[0]})
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		return c.printJSON(result)
+	}
+
+	shipment := result.Shipment
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "uid\t%s\n", shipment.UID)
+	fmt.Fprintf(w, "status\t%s\n", shipment.Status)
+	fmt.Fprintf(w, "user\t%s\n", shipment.UserUID)
+	fmt.Fprintf(w, "zone\t%s\n", shipment.ZoneID)
+	fmt.Fprintf(w, "window\t%s - %s\n", formatTime(shipment.ScheduledDeliveryMinTime), formatTime(shipment.ScheduledDeliveryMaxTime))
+	fmt.Fprintf(w, "origin\t%g, %g\n", shipment.OriginPoint.Lat, shipment.OriginPoint.Long)
+	fmt.Fprintf(w, "destination\t%g, %g\n", shipment.DestinationPoint.Lat, shipment.DestinationPoint.Long)
+	fmt.Fprintf(w, "price\t%d %s\n", shipment.PriceAmount, shipment.PriceCurrency)
+	if courier := shipment.Tracking.Courier; courier != nil {
+		fmt.Fprintf(w, "courier\t%s (%s)\n", courier.Name, courier.ID)
+	}
+	if reason := shipment.Tracking.FailureReason; reason != "" {
+		fmt.Fprintf(w, "failure reason\t%s\n", reason)
+	}
+	if next := domain.Transitions(shipment.Status); len(next) > 0 {
+		fmt.Fprintf(w, "can move to\t%s\n", strings.Join(next, ", "))
+	}
+
+	fmt.Fprintln(w, "\nhistory")
+	for _, change := range result.History {
+		fmt.Fprintf(w, "%s\t%s\n", formatTime(change.At), change.Status)
+	}
+
+	return w.Flush()
+}
+
+func (c *command) list(args []string) error {
+	var input domain.ListInput
+
+	flags := newFlagSet("list")
+	flags.Var((*stringsFlag)(&input.Statuses), "status", "only list shipments in status, repeatable")
+	flags.Var((*stringsFlag)(&input.ZoneIDs), "zone", "only list shipments of zone, repeatable")
+	flags.IntVar(&input.PageSize, "page-size", domain.DefaultListPageSize, "shipments to list")
+	flags.StringVar(&input.PageToken, "page-token", "", "continue a listing from its next page token")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	result, err := c.admin.List(c.ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		return c.printJSON(result)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "UID\tSTATUS\tZONE\tWINDOW START\tWINDOW END")
+	for _, shipment := range result.Shipments {
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\n",
+			shipment.UID,
+			shipment.Status,
+			shipment.ZoneID,
+			formatTime(shipment.ScheduledDeliveryMinTime),
+			formatTime(shipment.ScheduledDeliveryMaxTime),
+		)
+	}
+	if result.NextPageToken != "" {
+		fmt.Fprintf(w, "\nnext page: -page-token %s\n", result.NextPageToken)
+	}
+
+	return w.Flush()
+}
+
+func (c *command) transition(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+
+	result, err := c.admin.Transition(c.ctx, &domain.TransitionInput{ShipmentUID: args[0], Status: args[1]})
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		return c.printJSON(result)
+	}
+
+	_, err = fmt.Fprintf(c.out, "%s is now %s\n", result.Shipment.UID, result.Shipment.Status)
+	return err
+}
+
+func (c *command) redispatch(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+
+	result, err := c.admin.Redispatch(c.ctx, &domain.RedispatchInput{ShipmentUID: args[0]})
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		return c.printJSON(result)
+	}
+
+	_, err = fmt.Fprintf(c.out, "requested a delivery guy for %s, it is %s\n", result.Shipment.UID, result.Shipment.Status)
+	return err
+}
+
+func (c *command) replayWebhooks(args []string) error {
+	var input domain.ReplayCoreWebhooksInput
+
+	flags := newFlagSet("replay-webhooks")
+	flags.IntVar(&input.Limit, "limit", domain.DefaultReplayLimit, "webhooks to replay")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 0 {
+		return errUsage
+	}
+
+	result, err := c.admin.ReplayCoreWebhooks(c.ctx, &input)
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		return c.printJSON(result)
+	}
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "replayed %d, %d failed again\n", result.Replayed, len(result.Failed))
+	if len(result.Failed) > 0 {
+		fmt.Fprintln(w, "\nUID\tSTATUS\tATTEMPTS\tFIRST FAILED\tERROR")
+		for _, webhook := range result.Failed {
+			fmt.Fprintf(
+				w,
+				"%s\t%s\t%d\t%s\t%s\n",
+				webhook.ShipmentUID,
+				webhook.Status,
+				webhook.Attempts,
+				formatTime(webhook.FirstFailedAt),
+				webhook.Error,
+			)
+		}
+	}
+
+	return w.Flush()
+}
+
+func (c *command) stats(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+
+	result, err := c.admin.Stats(c.ctx, &domain.StatsInput{})
+	if err != nil {
+		return err
+	}
+
+	if c.asJSON {
+		return c.printJSON(result)
+	}
+
+	counts := result.ShipmentsByStatus
+
+	// the backlogs of the workers: queued shipments wait on the pending worker to be promoted,
+	// pending ones on the shipping worker to be dispatched. The workers run in the service, what
+	// they leave in the database is all deliveryctl sees of them.
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(w, "pending worker backlog (queued)\t%d\t\n", counts["queued"])
+	fmt.Fprintf(w, "shipping worker backlog (pending)\t%d\t\n", counts["pending"])
+	fmt.Fprintf(w, "  of which the 3pl could not be requested for\t%d\t\n", result.FailedDispatches)
+	fmt.Fprintf(w, "waiting on the 3pl (requested, searching, not_found)\t%d\t\n", counts["requested"]+counts["searching"]+counts["not_found"])
+	fmt.Fprintf(w, "failed core webhooks\t%d\t\n", result.FailedCoreWebhooks)
+
+	fmt.Fprintln(w, "\t\t")
+	for _, status := range domain.ShipmentStatuses {
+		fmt.Fprintf(w, "%s\t%d\t\n", status, counts[status])
+	}
+
+	return w.Flush()
+}
+
+func (c *command) printJSON(result any) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(result)
+}
+
+func runMigrate(db *sql.DB, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+
+	flags := newFlagSet("migrate " + args[0])
+	path := flags.String("path", "migrations", "directory of the migrations")
+	steps := flags.Int("steps", 0, "migrations to apply or roll back, all pending ones up when zero")
+	all := flags.Bool("all", false, "roll back every migration")
+	if err := flags.Parse(args[1:]); err != nil || flags.NArg() != 0 || *steps < 0 {
+		return errUsage
+	}
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		return err
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://"+*path, "postgres", driver)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		if *steps > 0 {
+			err = m.Steps(*steps)
+		} else {
+			err = m.Up()
+		}
+	case "down":
+		// rolling back drops data: the extent is always spelled out
+		switch {
+		case *all && *steps == 0:
+			err = m.Down()
+		case !*all && *steps > 0:
+			err = m.Steps(-*steps)
+		default:
+			return errUsage
+		}
+	case "version":
+	default:
+		return errUsage
+	}
+
+	if err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return err
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		_, err = fmt.Fprintln(out, "no migration applied")
+		return err
+	case err != nil:
+		return err
+	case dirty:
+		_, err = fmt.Fprintf(out, "version %d, dirty: fix the database then force the version\n", version)
+		return err
+	}
+
+	_, err = fmt.Fprintf(out, "version %d\n", version)
+	return err
+}
+
+// stringsFlag collects the values of a repeated flag.
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	return flags
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
+
+func getenv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -1,44 +1,38 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
 	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
 )
 
 func main() {
+	ctx := context.Background()
+	delivery := client.NewClient("http://localhost:8080", nil)
+
 	for index := 0; ; index++ {
 		timeNow := time.Now().Add(-65 * time.Minute)
-		body, err := json.Marshal(map[string]any{
-			"shipment_uid": fmt.Sprintf("shipment_%d", index),
-			"user_info": map[string]any{
-				"user_uid": fmt.Sprintf("user_%d", index),
-				"address":  fmt.Sprintf("address_%d", index),
+
+		if err := delivery.Request(ctx, &client.RequestInput{
+			ShipmentUID: fmt.Sprintf("shipment_%d", index),
+			UserInfo: client.UserInfo{
+				UserUID: fmt.Sprintf("user_%d", index),
+				Address: client.Address{Street: fmt.Sprintf("%d Valiasr St", index%500+1), City: "Tehran", Country: "IR"},
 			},
-			"routing_info": map[string]any{
-				"lat":  0,
-				"long": 0,
+			RoutingInfo: client.RoutingInfo{
+				Origin:      client.Location{Lat: 35.70 + float64(index%10)*0.001, Long: 51.40},
+				Destination: client.Location{Lat: 35.72, Long: 51.41 + float64(index%10)*0.001},
 			},
-			"scheduled_delivery_window": map[string]any{
-				"start_time": timeNow,
-				"end_time":   timeNow.Add(2 * time.Hour),
+			ScheduledDeliveryWindow: client.ScheduledDeliveryWindow{
+				StartTime: timeNow,
+				EndTime:   timeNow.Add(2 * time.Hour),
 			},
-		})
-		if err != nil {
-			panic(err)
-		}
-
-		resp, err := http.Post("http://localhost:8080/request", "application/json", bytes.NewReader(body))
-		if err != nil {
+		}); err != nil {
 			panic(err)
 		}
 
-		if resp.StatusCode != http.StatusOK {
-			panic(fmt.Errorf("resp.StatusCode (%d) != 200", resp.StatusCode))
-		}
-
 		time.Sleep(200 * time.Millisecond) // more that 10K/hour
 	}
 }
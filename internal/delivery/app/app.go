@@ -4,57 +4,230 @@ import (
 	"context"
 	"database/sql"
 	"log/slog"
+	"net"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/config"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/grpcserver"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/router"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
 	_3pl "github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/3pl"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/core"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/events"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/geocoder"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/repo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/zones"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
-	"github.com/lib/pq"
 	"github.com/samber/lo"
+	"google.golang.org/grpc"
 )
 
+// grpcAddr is where the grpc api listens, next to the http one.
+const grpcAddr = ":50051"
+
 type app struct {
 	logger *slog.Logger
 	config *config.WorkerConfig
-	sqlDB  *sql.DB
 	server *http.Server
-
-	core usecase.Core
-	_3pl usecase.ThirdPartyLogistics
+	// grpcServer serves the same use case as server over grpc.
+	grpcServer *grpc.Server
+
+	core   usecase.Core
+	_3pl   usecase.ThirdPartyLogistics
+	repo   usecase.Repo
+	zones  usecase.Zones
+	events usecase.Events
+	clock  usecase.Clock
 }
 
+// New wires the service. A nil sqlDB keeps shipments and slots in memory, for local runs without postgres.
 func New(
 	ctx context.Context,
 	config *config.Config,
 	sqlDB *sql.DB,
+	clock usecase.Clock,
 	logger *slog.Logger,
-) *app {
-	core := core.NewCore(logger)
-	_3pl := _3pl.New3PL(logger)
-	repo := repo.NewRepo(sqlDB, logger)
-
-	usecase := usecase.NewUseCase(core, _3pl, repo, logger)
+) (*app, error) {
+	c, err := newComponents(ctx, config, sqlDB, clock, logger)
+	if err != nil {
+		return nil, err
+	}
 
-	router := router.NewRouter(usecase, logger)
+	router := router.NewRouter(&config.RouterConfig, c.usecase, clock, logger)
 	server := &http.Server{
 		Addr:    ":8080",
 		Handler: router,
 	}
 
 	return &app{
-		logger: logger,
-		config: &config.WorkerConfig,
-		sqlDB:  sqlDB,
-		server: server,
-		core:   core,
-		_3pl:   _3pl,
+		logger:     logger,
+		config:     &config.WorkerConfig,
+		server:     server,
+		grpcServer: grpcserver.NewServer(c.usecase, logger),
+		core:       c.core,
+		_3pl:       c._3pl,
+		repo:       c.repo,
+		clock:      clock,
+		zones:      c.zones,
+		events:     c.events,
+	}, nil
+}
+
+// Admin is the use case of the service along with the operations past its api.
+type Admin interface {
+	usecase.UseCase
+	usecase.Admin
+}
+
+// NewAdmin wires the use case the way New does, without serving it nor running the workers, for
+// operators to act on shipments through it rather than through the database.
+func NewAdmin(
+	ctx context.Context,
+	config *config.Config,
+	sqlDB *sql.DB,
+	clock usecase.Clock,
+	logger *slog.Logger,
+) (Admin, error) {
+	c, err := newComponents(ctx, config, sqlDB, clock, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.usecase, nil
+}
+
+// components are the use case and the infrastructure it is built on.
+type components struct {
+	core    usecase.Core
+	_3pl    usecase.ThirdPartyLogistics
+	repo    usecase.Repo
+	zones   usecase.Zones
+	events  usecase.Events
+	usecase Admin
+}
+
+func newComponents(
+	ctx context.Context,
+	config *config.Config,
+	sqlDB *sql.DB,
+	clock usecase.Clock,
+	logger *slog.Logger,
+) (*components, error) {
+	_3pl := _3pl.New3PL(&config.ThirdPartyLogisticsConfig, logger)
+
+	var repository usecase.Repo
+	if sqlDB != nil {
+		postgresRepo, err := repo.NewRepo(ctx, sqlDB, logger)
+		if err != nil {
+			logger.Error("failed to create repo", slog.Any("error", err))
+			return nil, err
+		}
+		repository = postgresRepo
+	} else {
+		repository = repo.NewMemoryRepo(clock, logger)
+	}
+
+	// core webhooks failing, from the use case or the workers, are kept to be replayed
+	core := usecase.NewRecordingCore(core.NewCore(logger), repository, clock, logger)
+
+	zones, err := loadZones(ctx, &config.ZonesConfig, sqlDB, logger)
+	if err != nil {
+		logger.Error("failed to load zones", slog.Any("error", err))
+		return nil, err
+	}
+
+	geocoder, err := loadGeocoder(&config.GeocoderConfig, logger)
+	if err != nil {
+		logger.Error("failed to load geocoder", slog.Any("error", err))
+		return nil, err
+	}
+
+	events, err := newEvents(ctx, &config.EventsConfig, sqlDB, logger)
+	if err != nil {
+		logger.Error("failed to create event bus", slog.Any("error", err))
+		return nil, err
 	}
+
+	return &components{
+		core:    core,
+		_3pl:    _3pl,
+		repo:    repository,
+		zones:   zones,
+		events:  events,
+		usecase: usecase.NewUseCase(&config.UseCaseConfig, core, _3pl, repository, zones, geocoder, events, clock, logger),
+	}, nil
+}
+
+// newEvents fans events out through postgres when configured, listening until ctx is done,
+// and within this instance otherwise.
+func newEvents(ctx context.Context, config *config.EventsConfig, sqlDB *sql.DB, logger *slog.Logger) (usecase.Events, error) {
+	if config.DatabaseURL == "" || sqlDB == nil {
+		logger.Info("no events database is configured: shipment events only reach this instance's subscribers")
+		return events.NewMemoryBus(logger), nil
+	}
+
+	return events.NewPostgresBus(ctx, sqlDB, config.DatabaseURL, logger)
+}
+
+// loadZones returns nil, disabling zone checks, when no zone is defined.
+func loadZones(ctx context.Context, config *config.ZonesConfig, sqlDB *sql.DB, logger *slog.Logger) (usecase.Zones, error) {
+	var (
+		loaded interface {
+			usecase.Zones
+			Len() int
+		}
+		err error
+	)
+	switch {
+	case config.File != "":
+		loaded, err = zones.LoadFile(config.File, logger)
+	case sqlDB != nil:
+		loaded, err = zones.LoadDB(ctx, sqlDB, logger)
+	default:
+		loaded = zones.NewZones(nil, logger)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded.Len() == 0 {
+		logger.Info("no zone is defined: zone checks are disabled")
+		return nil, nil
+	}
+
+	return loaded, nil
+}
+
+// loadGeocoder returns nil, disabling address checks, when no geocoder file is configured.
+func loadGeocoder(config *config.GeocoderConfig, logger *slog.Logger) (usecase.Geocoder, error) {
+	if config.File == "" {
+		logger.Info("no geocoder file is configured: address checks are disabled")
+		return nil, nil
+	}
+
+	return geocoder.LoadFile(config.File, logger)
+}
+
+// Handler returns the http handler of the service, to serve it from elsewhere than StartServer.
+func (a *app) Handler() http.Handler {
+	return a.server.Handler
+}
+
+// RunOnce runs a single cycle of the pending and shipping workers. It is meant for simulations
+// driving the clock themselves instead of the workers' tickers.
+func (a *app) RunOnce(ctx context.Context) error {
+	if err := a.runPendingWorker(ctx, a.logger.With(slog.String("worker", "pending"))); err != nil {
+		return err
+	}
+
+	return a.runShippingWorker(ctx, a.logger.With(slog.String("worker", "shipping")))
+}
+
+// GRPCServer returns the grpc server of the service, to serve it from elsewhere than StartGRPCServer.
+func (a *app) GRPCServer() *grpc.Server {
+	return a.grpcServer
 }
 
 func (a *app) StartServer() error {
@@ -73,6 +246,40 @@ func (a *app) ShutdownServer(ctx context.Context) {
 	}
 }
 
+func (a *app) StartGRPCServer() error {
+	a.logger.Info("Starting grpc server", slog.String("addr", grpcAddr))
+
+	listener, err := net.Listen("tcp", grpcAddr)
+	if err != nil {
+		a.logger.Error("Failed to listen", slog.String("addr", grpcAddr), slog.Any("error", err))
+		return err
+	}
+
+	if err := a.grpcServer.Serve(listener); err != nil && err != grpc.ErrServerStopped {
+		a.logger.Error("Failed to serve grpc", slog.String("addr", grpcAddr), slog.Any("error", err))
+		return err
+	}
+	return nil
+}
+
+// ShutdownGRPCServer waits for pending rpcs until ctx is done, then cancels them. Watch streams
+// only end when cancelled, so a deadline is needed for them not to hold the shutdown forever.
+func (a *app) ShutdownGRPCServer(ctx context.Context) {
+	a.logger.Info("Shutting down grpc server")
+
+	stopped := make(chan struct{})
+	go func() {
+		a.grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		a.grpcServer.Stop()
+	}
+}
+
 func (a *app) StartPendingWorker(ctx context.Context, interval time.Duration) error {
 	a.logger.Info("Starting pending worker")
 
@@ -105,54 +312,25 @@ func (a *app) StartPendingWorker(ctx context.Context, interval time.Duration) er
 }
 func (a *app) runPendingWorker(ctx context.Context, logger *slog.Logger) error {
 	for {
-		tx, err := a.sqlDB.Begin()
-		if err != nil {
-			logger.Error("failed to begin transaction", slog.Any("error", err))
-			return err
-		}
-
-		query := `
-	UPDATE shipments
-	SET status = 'pending'
-	WHERE uid IN (
-		SELECT uid FROM shipments
-		WHERE status = 'queued'
-		  AND scheduled_delivery_min_time <= NOW() + ($1 * INTERVAL '1 second')
-		LIMIT $2
-		FOR UPDATE SKIP LOCKED
-	)
-	RETURNING uid;
-	`
+		before := a.clock.Now().Add(time.Duration(a.config.PendingIntervalInSeconds) * time.Second)
 
-		rows, err := tx.Query(query, a.config.PendingIntervalInSeconds, a.config.PendingWorkerBatchSize)
+		shipments, err := a.repo.PromoteQueuedShipments(ctx, before, a.config.PendingWorkerBatchSize)
 		if err != nil {
-			tx.Rollback()
-			logger.Error("failed to execute batch update", slog.Any("error", err))
-			return err
-		}
-
-		var updatedCount int
-		for rows.Next() {
-			var uid string
-			if err := rows.Scan(&uid); err != nil {
-				logger.Error("error scanning uid", slog.Any("error", err))
-				continue
-			}
-			updatedCount++
-		}
-		rows.Close()
-
-		if err := tx.Commit(); err != nil {
-			logger.Error("transaction commit failed", slog.Any("error", err))
+			logger.Error("failed to promote queued shipments", slog.Any("error", err))
 			return err
 		}
 
-		if updatedCount == 0 {
+		if len(shipments) == 0 {
 			logger.Debug("No more shipments to update in this cycle.")
 			break
 		}
 
-		logger.Info("Successfully updated batch shipments to pending status", slog.Int("batch_length", updatedCount))
+		logger.Info("Successfully updated batch shipments to pending status", slog.Int("batch_length", len(shipments)))
+
+		for _, shipment := range shipments {
+			event := domain.NewShipmentEvent(&shipment, a.clock.Now())
+			a.publish(ctx, logger, &event)
+		}
 	}
 
 	return nil
@@ -191,136 +369,118 @@ func (a *app) StartShippingWorker(ctx context.Context, interval time.Duration) e
 }
 
 func (a *app) runShippingWorker(ctx context.Context, logger *slog.Logger) error {
-	for {
-		tx, err := a.sqlDB.Begin()
-		if err != nil {
-			logger.Error("failed to begin transaction", slog.Any("error", err))
-			return err
-		}
+	// shipments the 3pl could not be requested for are left to a later cycle, not claimed again in this one
+	now := a.clock.Now()
+	retryAt := now.Add(time.Duration(a.config.DispatchRetryDelayInSeconds) * time.Second)
 
-		query := `
-			SELECT uid, user_uid, user_addr, origin_point, destination_point, scheduled_delivery_min_time, scheduled_delivery_max_time, status
-			FROM shipments
-			WHERE status = 'pending'
-			LIMIT $1
-			FOR UPDATE SKIP LOCKED;
-		`
+	for {
+		var (
+			shipmentRequestUIDs []string
+			claimedShipments    = make(map[string]domain.Shipment)
+		)
+
+		claimed, err := a.repo.ClaimPendingShipments(ctx, now, retryAt, a.config.ShipmentWorkerBatchSize, func(shipments []domain.Shipment) []string {
+			for _, shipment := range shipments {
+				claimedShipments[shipment.UID] = shipment
+			}
 
-		rows, err := tx.Query(query, a.config.PendingWorkerBatchSize)
+			shipmentRequestUIDs = a.dispatchShipments(ctx, logger, shipments)
+			return shipmentRequestUIDs
+		})
 		if err != nil {
-			tx.Rollback()
-			logger.Error("failed to query", slog.Any("error", err))
+			logger.Error("failed to claim pending shipments", slog.Any("error", err))
 			return err
 		}
 
-		shipments := make([]domain.Shipment, 0, a.config.PendingWorkerBatchSize)
-		for rows.Next() {
-			var s domain.Shipment
-			err := rows.Scan(
-				&s.UID,
-				&s.UserUID,
-				&s.UserAddr,
-				&s.OriginPoint,
-				&s.DestinationPoint,
-				&s.ScheduledDeliveryMinTime,
-				&s.ScheduledDeliveryMaxTime,
-				&s.Status,
-			)
-			if err != nil {
-				logger.Error("error scanning shipment", slog.Any("error", err))
-				continue
-			}
-			shipments = append(shipments, s)
-		}
-		rows.Close()
-
-		if len(shipments) == 0 {
+		if claimed == 0 {
 			logger.Debug("No more pending shipments in this cycle.")
+			break
+		}
 
-			if err := tx.Commit(); err != nil {
-				logger.Error("transaction commit failed", slog.Any("error", err))
-				return err
-			}
+		for _, shipmentUID := range shipmentRequestUIDs {
+			shipment := claimedShipments[shipmentUID]
+			shipment.Status = "requested"
 
-			break
+			event := domain.NewShipmentEvent(&shipment, a.clock.Now())
+			a.publish(ctx, logger, &event)
 		}
 
 		var wg sync.WaitGroup
-		wg.Add(len(shipments))
-
-		shipmentRequestUIDsCh := make(chan string, len(shipments))
+		wg.Add(len(shipmentRequestUIDs))
 
-		for _, shipment := range shipments {
+		for _, shipmentUID := range shipmentRequestUIDs {
 			go func() {
 				defer wg.Done()
 
-				if _, err := a._3pl.RequestDeliveryGuy(
-					ctx,
-					&domain.ThirdPartyLogisticsRequestDeliveryGuyInput{
-						ShipmentUID: shipment.UID,
-						RoutingInfo: domain.RoutingInfo{
-							Origin:      shipment.OriginPoint,
-							Destination: shipment.DestinationPoint,
-						},
-						ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
-							StartTime: shipment.ScheduledDeliveryMinTime,
-							EndTime:   shipment.ScheduledDeliveryMaxTime,
-						},
-					},
-				); err != nil {
-					logger.Error("failed to request delivery guy", slog.Any("error", err))
-				} else {
-					shipmentRequestUIDsCh <- shipment.UID
+				if _, err := a.core.Webhook(ctx, &domain.CoreWebhookInput{ShipmentUID: shipmentUID, Status: "requested"}); err != nil {
+					logger.Error("failed to webhook", slog.Any("shipment_uid", shipmentUID), slog.Any("error", err))
 				}
 			}()
 		}
 
 		wg.Wait()
-		close(shipmentRequestUIDsCh)
-		shipmentRequestUIDs := lo.ChannelToSlice(shipmentRequestUIDsCh)
+	}
 
-		query = `
-		UPDATE shipments
-		SET status = 'requested'
-		WHERE uid = ANY($1)
-		RETURNING uid;
-		`
+	return nil
+}
 
-		rows, err = tx.Query(query, pq.Array(shipmentRequestUIDs))
-		if err != nil {
-			tx.Rollback()
-			logger.Error("failed to execute batch update", slog.Any("error", err))
-			return err
-		}
+// dispatchShipments requests a delivery guy for every shipment concurrently and returns the uids of requested ones.
+func (a *app) dispatchShipments(ctx context.Context, logger *slog.Logger, shipments []domain.Shipment) []string {
+	var wg sync.WaitGroup
+	wg.Add(len(shipments))
 
-		for rows.Next() {
-			var uid string
-			if err := rows.Scan(&uid); err != nil {
-				logger.Error("error scanning uid", slog.Any("error", err))
-				continue
-			}
-		}
-		rows.Close()
+	shipmentRequestUIDsCh := make(chan string, len(shipments))
 
-		if err := tx.Commit(); err != nil {
-			logger.Error("transaction commit failed", slog.Any("error", err))
-			return err
-		}
+	for _, shipment := range shipments {
+		go func() {
+			defer wg.Done()
 
-		wg.Add(len(shipmentRequestUIDs))
+			var provider string
+			if a.zones != nil && shipment.ZoneID != "" {
+				if zone, err := a.zones.Get(ctx, shipment.ZoneID); err != nil {
+					logger.Error("failed to fetch zone", slog.String("zone_id", shipment.ZoneID), slog.Any("error", err))
+				} else {
+					provider = zone.Preferred3PL
+				}
+			}
 
-		for _, shipmentUID := range shipmentRequestUIDs {
-			go func() {
-				defer wg.Done()
+			if _, err := a._3pl.RequestDeliveryGuy(
+				ctx,
+				&domain.ThirdPartyLogisticsRequestDeliveryGuyInput{
+					ShipmentUID: shipment.UID,
+					RoutingInfo: domain.RoutingInfo{
+						Origin:      shipment.OriginPoint,
+						Destination: shipment.DestinationPoint,
+					},
+					ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+						StartTime: shipment.ScheduledDeliveryMinTime,
+						EndTime:   shipment.ScheduledDeliveryMaxTime,
+					},
+					ZoneID:   shipment.ZoneID,
+					Provider: provider,
+				},
+			); err != nil {
+				logger.Error("failed to request delivery guy", slog.Any("error", err))
+			} else {
+				shipmentRequestUIDsCh <- shipment.UID
+			}
+		}()
+	}
 
-				if _, err := a.core.Webhook(ctx, &domain.CoreWebhookInput{ShipmentUID: shipmentUID, Status: "requested"}); err != nil {
-					logger.Error("failed to webhook", slog.Any("shipment_uid", shipmentUID), slog.Any("error", err))
-				}
-			}()
-		}
+	wg.Wait()
+	close(shipmentRequestUIDsCh)
 
-		wg.Wait()
+	return lo.ChannelToSlice(shipmentRequestUIDsCh)
+}
+
+// publish streams a status change made by the workers. Failures are only logged since the change
+// itself is already stored.
+func (a *app) publish(ctx context.Context, logger *slog.Logger, event *domain.ShipmentEvent) {
+	if a.events == nil {
+		return
 	}
 
-	return nil
+	if err := a.events.Publish(ctx, event); err != nil {
+		logger.Error("failed to publish shipment event", slog.String("shipment_uid", event.ShipmentUID), slog.Any("error", err))
+	}
 }
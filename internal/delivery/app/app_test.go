@@ -0,0 +1,125 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/config"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/repo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+type fakeCore struct{}
+
+func (fakeCore) Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error) {
+	return &domain.CoreWebhookResult{}, nil
+}
+
+type fake3PL struct {
+	mu        sync.Mutex
+	requested map[string]time.Time
+	clock     usecase.Clock
+}
+
+func (f *fake3PL) RequestDeliveryGuy(ctx context.Context, input *domain.ThirdPartyLogisticsRequestDeliveryGuyInput) (*domain.ThirdPartyLogisticsRequestDeliveryGuyResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requested[input.ShipmentUID] = f.clock.Now()
+	return &domain.ThirdPartyLogisticsRequestDeliveryGuyResult{}, nil
+}
+
+// TestWorkersThroughADay books a delivery for every hour of a day and fast-forwards through it,
+// checking every shipment is handed to the 3pl once its window is within the pending interval.
+func TestWorkersThroughADay(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	midnight := time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(midnight)
+	_3pl := &fake3PL{requested: make(map[string]time.Time), clock: fakeClock}
+	repository := repo.NewMemoryRepo(fakeClock, logger)
+
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		fakeCore{},
+		_3pl,
+		repository,
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	a := &app{
+		logger: logger,
+		config: &config.WorkerConfig{
+			PendingIntervalInSeconds:    int64(time.Hour.Seconds()),
+			PendingWorkerBatchSize:      5,
+			ShipmentWorkerBatchSize:     5,
+			DispatchRetryDelayInSeconds: int64(time.Minute.Seconds()),
+		},
+		core:  fakeCore{},
+		_3pl:  _3pl,
+		repo:  repository,
+		clock: fakeClock,
+	}
+
+	windowStarts := make(map[string]time.Time)
+	for hour := 1; hour < 24; hour++ {
+		uid := fmt.Sprintf("shipment_%02d", hour)
+		start := midnight.Add(time.Duration(hour) * time.Hour)
+		windowStarts[uid] = start
+
+		if _, err := uc.Request(ctx, &domain.RequestInput{
+			ShipmentUID: uid,
+			UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+			RoutingInfo: domain.RoutingInfo{
+				Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+				Destination: domain.Location{Lat: 35.72, Long: 51.41},
+			},
+			ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{StartTime: start, EndTime: start.Add(time.Hour)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for now := midnight; now.Before(midnight.Add(24 * time.Hour)); now = now.Add(15 * time.Minute) {
+		fakeClock.Set(now)
+
+		if err := a.runPendingWorker(ctx, logger); err != nil {
+			t.Fatal(err)
+		}
+		if err := a.runShippingWorker(ctx, logger); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for uid, start := range windowStarts {
+		requestedAt, ok := _3pl.requested[uid]
+		if !ok {
+			t.Errorf("%s was never requested", uid)
+			continue
+		}
+
+		if want := start.Add(-time.Hour); !requestedAt.Equal(want) {
+			t.Errorf("%s requested at %s, want %s", uid, requestedAt.Format(time.Kitchen), want.Format(time.Kitchen))
+		}
+
+		shipment, err := repository.GetShipment(ctx, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shipment.Status != "requested" {
+			t.Errorf("%s: status = %q, want %q", uid, shipment.Status, "requested")
+		}
+	}
+}
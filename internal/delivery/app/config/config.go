@@ -1,11 +1,44 @@
 package config
 
+import (
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/router"
+	_3pl "github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/3pl"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
 type Config struct {
-	WorkerConfig WorkerConfig
+	WorkerConfig              WorkerConfig
+	UseCaseConfig             usecase.Config
+	ThirdPartyLogisticsConfig _3pl.Config
+	ZonesConfig               ZonesConfig
+	GeocoderConfig            GeocoderConfig
+	EventsConfig              EventsConfig
+	RouterConfig              router.Config
+}
+
+type ZonesConfig struct {
+	// File is a geojson FeatureCollection zones are loaded from. When empty they are loaded from the zones table.
+	// Zone checks are disabled if no zone is defined.
+	File string
+}
+
+type GeocoderConfig struct {
+	// File is a json array of known addresses and their locations. Geocoding is disabled when empty.
+	File string
+}
+
+type EventsConfig struct {
+	// DatabaseURL is the postgres shipment events are published through with LISTEN/NOTIFY, so clients
+	// connected to any instance get them. When empty, or without postgres, events only reach the
+	// clients of the instance publishing them.
+	DatabaseURL string
 }
 
 type WorkerConfig struct {
 	PendingIntervalInSeconds int64
 	PendingWorkerBatchSize   int
 	ShipmentWorkerBatchSize  int
+	// DispatchRetryDelayInSeconds is how long a shipment the 3pl could not be requested for waits
+	// before it is dispatched again.
+	DispatchRetryDelayInSeconds int64
 }
@@ -0,0 +1,201 @@
+package grpcserver
+
+import (
+	"time"
+
+	deliveryv1 "github.com/aria3ppp/delivery-service-simulator/api/delivery/v1"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Conversions between the protobuf messages and the domain. Missing messages convert to zero
+// values so the use case validation reports them the way it does for json payloads.
+
+func toTime(ts *timestamppb.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	return ts.AsTime()
+}
+
+func toTimePtr(ts *timestamppb.Timestamp) *time.Time {
+	if ts == nil {
+		return nil
+	}
+	t := ts.AsTime()
+	return &t
+}
+
+func fromTimePtr(t *time.Time) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(*t)
+}
+
+func toLocation(location *deliveryv1.Location) domain.Location {
+	return domain.Location{Lat: location.GetLat(), Long: location.GetLong()}
+}
+
+func fromLocation(location domain.Location) *deliveryv1.Location {
+	return &deliveryv1.Location{Lat: location.Lat, Long: location.Long}
+}
+
+func toAddress(address *deliveryv1.Address) domain.Address {
+	return domain.Address{
+		Street:     address.GetStreet(),
+		City:       address.GetCity(),
+		PostalCode: address.GetPostalCode(),
+		Country:    address.GetCountry(),
+		Notes:      address.GetNotes(),
+	}
+}
+
+func fromAddress(address domain.Address) *deliveryv1.Address {
+	return &deliveryv1.Address{
+		Street:     address.Street,
+		City:       address.City,
+		PostalCode: address.PostalCode,
+		Country:    address.Country,
+		Notes:      address.Notes,
+	}
+}
+
+func toRequestInput(input *deliveryv1.RequestInput) *domain.RequestInput {
+	return &domain.RequestInput{
+		ShipmentUID: input.GetShipmentUid(),
+		UserInfo: domain.UserInfo{
+			UserUID: input.GetUserInfo().GetUserUid(),
+			Address: toAddress(input.GetUserInfo().GetAddress()),
+		},
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      toLocation(input.GetRoutingInfo().GetOrigin()),
+			Destination: toLocation(input.GetRoutingInfo().GetDestination()),
+		},
+		ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+			StartTime: toTime(input.GetScheduledDeliveryWindow().GetStartTime()),
+			EndTime:   toTime(input.GetScheduledDeliveryWindow().GetEndTime()),
+		},
+		QuoteToken: input.GetQuoteToken(),
+	}
+}
+
+func toTracking(tracking *deliveryv1.Tracking) domain.Tracking {
+	if tracking == nil {
+		return domain.Tracking{}
+	}
+
+	result := domain.Tracking{
+		PickupETA:     toTimePtr(tracking.GetPickupEta()),
+		DropoffETA:    toTimePtr(tracking.GetDropoffEta()),
+		FailureReason: tracking.GetFailureReason(),
+	}
+
+	if courier := tracking.GetCourier(); courier != nil {
+		result.Courier = &domain.Courier{
+			ID:      courier.GetId(),
+			Name:    courier.GetName(),
+			Phone:   courier.GetPhone(),
+			Vehicle: courier.GetVehicle(),
+		}
+	}
+
+	if location := tracking.GetCourierLocation(); location != nil {
+		courierLocation := toLocation(location)
+		result.CourierLocation = &courierLocation
+	}
+
+	if proof := tracking.GetProofOfDelivery(); proof != nil {
+		result.ProofOfDelivery = &domain.ProofOfDelivery{
+			DeliveredAt:   toTime(proof.GetDeliveredAt()),
+			RecipientName: proof.GetRecipientName(),
+			PhotoRef:      proof.GetPhotoRef(),
+		}
+	}
+
+	return result
+}
+
+// fromTracking returns nil for a tracking nothing was reported on.
+func fromTracking(tracking *domain.Tracking) *deliveryv1.Tracking {
+	if tracking == nil || tracking.IsZero() {
+		return nil
+	}
+
+	result := &deliveryv1.Tracking{
+		PickupEta:     fromTimePtr(tracking.PickupETA),
+		DropoffEta:    fromTimePtr(tracking.DropoffETA),
+		FailureReason: tracking.FailureReason,
+	}
+
+	if courier := tracking.Courier; courier != nil {
+		result.Courier = &deliveryv1.Courier{
+			Id:      courier.ID,
+			Name:    courier.Name,
+			Phone:   courier.Phone,
+			Vehicle: courier.Vehicle,
+		}
+	}
+
+	if tracking.CourierLocation != nil {
+		result.CourierLocation = fromLocation(*tracking.CourierLocation)
+	}
+
+	if proof := tracking.ProofOfDelivery; proof != nil {
+		result.ProofOfDelivery = &deliveryv1.ProofOfDelivery{
+			DeliveredAt:   timestamppb.New(proof.DeliveredAt),
+			RecipientName: proof.RecipientName,
+			PhotoRef:      proof.PhotoRef,
+		}
+	}
+
+	return result
+}
+
+func toWebhookInput(input *deliveryv1.WebhookInput) *domain.WebhookInput {
+	return &domain.WebhookInput{
+		Version:     int(input.GetVersion()),
+		ShipmentUID: input.GetShipmentUid(),
+		Status:      input.GetStatus(),
+		Tracking:    toTracking(input.GetTracking()),
+	}
+}
+
+func toShipmentFilter(filter *deliveryv1.ShipmentFilter) domain.ShipmentFilter {
+	return domain.ShipmentFilter{
+		ShipmentUIDs: filter.GetShipmentUids(),
+		ZoneIDs:      filter.GetZoneIds(),
+		Statuses:     filter.GetStatuses(),
+	}
+}
+
+func fromShipment(shipment *domain.Shipment) *deliveryv1.Shipment {
+	return &deliveryv1.Shipment{
+		Uid:                      shipment.UID,
+		UserUid:                  shipment.UserUID,
+		UserAddr:                 shipment.UserAddr,
+		UserAddress:              fromAddress(shipment.UserAddress),
+		OriginPoint:              fromLocation(shipment.OriginPoint),
+		DestinationPoint:         fromLocation(shipment.DestinationPoint),
+		ScheduledDeliveryMinTime: timestamppb.New(shipment.ScheduledDeliveryMinTime),
+		ScheduledDeliveryMaxTime: timestamppb.New(shipment.ScheduledDeliveryMaxTime),
+		Status:                   shipment.Status,
+		DistanceMeters:           shipment.DistanceMeters,
+		EtaSeconds:               int32(shipment.ETASeconds),
+		ZoneId:                   shipment.ZoneID,
+		PriceAmount:              shipment.PriceAmount,
+		PriceCurrency:            shipment.PriceCurrency,
+		Tracking:                 fromTracking(&shipment.Tracking),
+	}
+}
+
+func fromShipmentEvent(event *domain.ShipmentEvent) *deliveryv1.ShipmentEvent {
+	return &deliveryv1.ShipmentEvent{
+		ShipmentUid: event.ShipmentUID,
+		ZoneId:      event.ZoneID,
+		Status:      event.Status,
+		Tracking:    fromTracking(event.Tracking),
+		At:          timestamppb.New(event.At),
+	}
+}
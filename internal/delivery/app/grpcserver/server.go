@@ -0,0 +1,192 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	deliveryv1 "github.com/aria3ppp/delivery-service-simulator/api/delivery/v1"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// errorDomain qualifies the reasons of conflict errors, see errdetails.ErrorInfo.
+const errorDomain = "delivery.v1"
+
+type server struct {
+	deliveryv1.UnimplementedDeliveryServiceServer
+
+	uc     usecase.UseCase
+	logger *slog.Logger
+}
+
+var _ deliveryv1.DeliveryServiceServer = (*server)(nil)
+
+// NewServer returns a grpc server serving the delivery service on top of uc, the use case the
+// http router shares.
+func NewServer(
+	uc usecase.UseCase,
+	logger *slog.Logger,
+) *grpc.Server {
+	grpcServer := grpc.NewServer()
+	deliveryv1.RegisterDeliveryServiceServer(grpcServer, &server{
+		uc:     uc,
+		logger: logger,
+	})
+	return grpcServer
+}
+
+func (s *server) Request(ctx context.Context, input *deliveryv1.RequestInput) (*deliveryv1.RequestResult, error) {
+	logger := s.logger.With(slog.String("rpc", "Request"))
+
+	if _, err := s.uc.Request(ctx, toRequestInput(input)); err != nil {
+		logger.Error("failed to uc.Request", slog.Any("error", err))
+		return nil, toStatus(err)
+	}
+
+	return &deliveryv1.RequestResult{}, nil
+}
+
+func (s *server) Webhook(ctx context.Context, input *deliveryv1.WebhookInput) (*deliveryv1.WebhookResult, error) {
+	logger := s.logger.With(slog.String("rpc", "Webhook"))
+
+	if _, err := s.uc.Webhook(ctx, toWebhookInput(input)); err != nil {
+		logger.Error("failed to uc.Webhook", slog.Any("error", err))
+		return nil, toStatus(err)
+	}
+
+	return &deliveryv1.WebhookResult{}, nil
+}
+
+func (s *server) Get(ctx context.Context, input *deliveryv1.GetInput) (*deliveryv1.GetResult, error) {
+	logger := s.logger.With(slog.String("rpc", "Get"))
+
+	result, err := s.uc.Get(ctx, &domain.GetInput{ShipmentUID: input.GetShipmentUid()})
+	if err != nil {
+		logger.Error("failed to uc.Get", slog.Any("error", err))
+		return nil, toStatus(err)
+	}
+
+	return &deliveryv1.GetResult{Shipment: fromShipment(&result.Shipment)}, nil
+}
+
+func (s *server) List(ctx context.Context, input *deliveryv1.ListInput) (*deliveryv1.ListResult, error) {
+	logger := s.logger.With(slog.String("rpc", "List"))
+
+	result, err := s.uc.List(ctx, &domain.ListInput{
+		ShipmentFilter: toShipmentFilter(input.GetFilter()),
+		PageSize:       int(input.GetPageSize()),
+		PageToken:      input.GetPageToken(),
+	})
+	if err != nil {
+		logger.Error("failed to uc.List", slog.Any("error", err))
+		return nil, toStatus(err)
+	}
+
+	shipments := make([]*deliveryv1.Shipment, len(result.Shipments))
+	for i := range result.Shipments {
+		shipments[i] = fromShipment(&result.Shipments[i])
+	}
+
+	return &deliveryv1.ListResult{Shipments: shipments, NextPageToken: result.NextPageToken}, nil
+}
+
+func (s *server) Cancel(ctx context.Context, input *deliveryv1.CancelInput) (*deliveryv1.CancelResult, error) {
+	logger := s.logger.With(slog.String("rpc", "Cancel"))
+
+	if _, err := s.uc.Cancel(ctx, &domain.CancelInput{ShipmentUID: input.GetShipmentUid()}); err != nil {
+		logger.Error("failed to uc.Cancel", slog.Any("error", err))
+		return nil, toStatus(err)
+	}
+
+	return &deliveryv1.CancelResult{}, nil
+}
+
+// Watch streams the current state of the matching shipments, then every change until the client
+// cancels. A client falling too far behind gets RESOURCE_EXHAUSTED and should watch again.
+func (s *server) Watch(input *deliveryv1.WatchInput, stream grpc.ServerStreamingServer[deliveryv1.ShipmentEvent]) error {
+	logger := s.logger.With(slog.String("rpc", "Watch"))
+	ctx := stream.Context()
+
+	result, err := s.uc.Watch(ctx, &domain.WatchInput{ShipmentFilter: toShipmentFilter(input.GetFilter())})
+	if err != nil {
+		logger.Error("failed to uc.Watch", slog.Any("error", err))
+		return toStatus(err)
+	}
+
+	for i := range result.Current {
+		if err := stream.Send(fromShipmentEvent(&result.Current[i])); err != nil {
+			logger.Error("failed to send event", slog.Any("error", err))
+			return err
+		}
+	}
+
+	for event := range result.Events {
+		if err := stream.Send(fromShipmentEvent(&event)); err != nil {
+			logger.Error("failed to send event", slog.Any("error", err))
+			return err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return status.FromContextError(err).Err()
+	}
+
+	logger.Warn("event stream closed")
+	return status.Error(codes.ResourceExhausted, "watcher fell too far behind")
+}
+
+// toStatus maps a use case error onto a grpc status the way the http router maps it onto a status
+// code: validation errors are INVALID_ARGUMENT carrying their field violations as a BadRequest,
+// not found is NOT_FOUND, duplicates are ALREADY_EXISTS and other conflicts FAILED_PRECONDITION
+// carrying their code as an ErrorInfo reason.
+func toStatus(err error) error {
+	var validationErr internal_error.ValidationError
+	if errors.As(err, &validationErr) {
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range validationErr.Violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation.Field,
+				Description: violation.Message,
+				Reason:      violation.Code,
+			})
+		}
+		return withDetails(status.New(codes.InvalidArgument, validationErr.Error()), badRequest)
+	}
+
+	var notFoundErr internal_error.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		return status.Error(codes.NotFound, notFoundErr.Error())
+	}
+
+	var conflictErr internal_error.ConflictError
+	if errors.As(err, &conflictErr) {
+		code := codes.FailedPrecondition
+		if conflictErr.Code == internal_error.CodeDuplicate {
+			code = codes.AlreadyExists
+		}
+		return withDetails(status.New(code, conflictErr.Error()), &errdetails.ErrorInfo{Reason: conflictErr.Code, Domain: errorDomain})
+	}
+
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return status.FromContextError(err).Err()
+	}
+
+	return status.Error(codes.Internal, err.Error())
+}
+
+// withDetails attaches details to st, falling back to st alone should they fail to marshal.
+func withDetails(st *status.Status, details protoadapt.MessageV1) error {
+	detailed, err := st.WithDetails(details)
+	if err != nil {
+		return st.Err()
+	}
+	return detailed.Err()
+}
@@ -0,0 +1,110 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"testing"
+
+	deliveryv1 "github.com/aria3ppp/delivery-service-simulator/api/delivery/v1"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// stubUseCase fails every Get and Cancel with err, the other methods are not expected to be called.
+type stubUseCase struct {
+	usecase.UseCase
+	err error
+}
+
+func (s *stubUseCase) Get(ctx context.Context, input *domain.GetInput) (*domain.GetResult, error) {
+	return nil, s.err
+}
+
+func (s *stubUseCase) Cancel(ctx context.Context, input *domain.CancelInput) (*domain.CancelResult, error) {
+	return nil, s.err
+}
+
+func TestToStatus(t *testing.T) {
+	var violations internal_error.Violations
+	violations.Add("shipment_uid", internal_error.CodeRequired, "is required")
+
+	for _, tt := range []struct {
+		name string
+		err  error
+		code codes.Code
+		// reason is the reason of the error details, none when empty.
+		reason string
+	}{
+		{"validation", violations.Err(), codes.InvalidArgument, internal_error.CodeRequired},
+		{"not found", internal_error.ErrShipmentNotFound, codes.NotFound, ""},
+		{"wrapped not found", fmt.Errorf("get: %w", internal_error.ErrShipmentNotFound), codes.NotFound, ""},
+		{"duplicate", internal_error.ConflictError{Code: internal_error.CodeDuplicate}, codes.AlreadyExists, internal_error.CodeDuplicate},
+		{"conflict", internal_error.ConflictError{Code: internal_error.CodeNotCancellable}, codes.FailedPrecondition, internal_error.CodeNotCancellable},
+		{"canceled", context.Canceled, codes.Canceled, ""},
+		{"deadline exceeded", context.DeadlineExceeded, codes.DeadlineExceeded, ""},
+		{"internal", errors.New("connection refused"), codes.Internal, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			st := status.Convert(toStatus(tt.err))
+			if st.Code() != tt.code {
+				t.Errorf("code = %s, want %s", st.Code(), tt.code)
+			}
+
+			var reasons []string
+			for _, detail := range st.Details() {
+				switch detail := detail.(type) {
+				case *errdetails.BadRequest:
+					for _, violation := range detail.FieldViolations {
+						reasons = append(reasons, violation.Reason)
+					}
+				case *errdetails.ErrorInfo:
+					if detail.Domain != errorDomain {
+						t.Errorf("error domain = %q, want %q", detail.Domain, errorDomain)
+					}
+					reasons = append(reasons, detail.Reason)
+				}
+			}
+
+			switch {
+			case tt.reason == "" && len(reasons) != 0:
+				t.Errorf("reasons = %v, want none", reasons)
+			case tt.reason != "" && (len(reasons) != 1 || reasons[0] != tt.reason):
+				t.Errorf("reasons = %v, want %s", reasons, tt.reason)
+			}
+		})
+	}
+}
+
+func TestRPCErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		code codes.Code
+	}{
+		{"not found", internal_error.ErrShipmentNotFound, codes.NotFound},
+		{"not cancellable", internal_error.ConflictError{Code: internal_error.CodeNotCancellable}, codes.FailedPrecondition},
+		{"internal", errors.New("connection refused"), codes.Internal},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{
+				uc:     &stubUseCase{err: tt.err},
+				logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+			}
+
+			if _, err := s.Get(context.Background(), &deliveryv1.GetInput{ShipmentUid: "shipment"}); status.Code(err) != tt.code {
+				t.Errorf("Get: %v, want code %s", err, tt.code)
+			}
+			if _, err := s.Cancel(context.Background(), &deliveryv1.CancelInput{ShipmentUid: "shipment"}); status.Code(err) != tt.code {
+				t.Errorf("Cancel: %v, want code %s", err, tt.code)
+			}
+		})
+	}
+}
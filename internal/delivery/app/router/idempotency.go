@@ -0,0 +1,201 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+const (
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader marks responses replayed to a retry rather than handled again.
+	idempotentReplayedHeader = "Idempotent-Replayed"
+
+	// idempotencyTTL is how long responses are kept for retries to be answered with.
+	idempotencyTTL = 24 * time.Hour
+	// idempotencySweepInterval is how often expired responses are forgotten.
+	idempotencySweepInterval = time.Minute
+)
+
+// Error codes of idempotency keys.
+const (
+	// codeRequestInProgress is answered with 409 to a retry arriving before the original request
+	// was handled, it should be retried later.
+	codeRequestInProgress = "request_in_progress"
+	// codeIdempotencyKeyReused is answered with 422 to a key sent again with another payload.
+	codeIdempotencyKeyReused = "idempotency_key_reused"
+)
+
+type idempotentResponse struct {
+	// fingerprint is the hash of the request payload the key was first sent with.
+	fingerprint [sha256.Size]byte
+	done        bool
+	expiresAt   time.Time
+
+	statusCode  int
+	contentType string
+	body        []byte
+}
+
+// idempotencyStore keeps the responses of requests sent with an idempotency key. Responses are
+// kept in memory: behind a load balancer, retries are replayed by the instance that handled the
+// original request only.
+type idempotencyStore struct {
+	mu        sync.Mutex
+	responses map[string]*idempotentResponse
+	lastSweep time.Time
+	clock     usecase.Clock
+}
+
+func newIdempotencyStore(clock usecase.Clock) *idempotencyStore {
+	return &idempotencyStore{
+		responses: make(map[string]*idempotentResponse),
+		clock:     clock,
+	}
+}
+
+// begin returns the response recorded under key, or records a new one in progress and reports
+// true when there is none.
+func (s *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte) (*idempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.clock.Now()
+	if now.Sub(s.lastSweep) >= idempotencySweepInterval {
+		for key, response := range s.responses {
+			if response.done && now.After(response.expiresAt) {
+				delete(s.responses, key)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if response, ok := s.responses[key]; ok && (!response.done || now.Before(response.expiresAt)) {
+		return response, false
+	}
+
+	response := &idempotentResponse{fingerprint: fingerprint}
+	s.responses[key] = response
+	return response, true
+}
+
+// finish records the response of key. Server errors are forgotten for retries to be handled again.
+func (s *idempotencyStore) finish(key string, rec *responseRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response := s.responses[key]
+	if rec == nil || rec.statusCode == 0 || rec.statusCode >= http.StatusInternalServerError {
+		delete(s.responses, key)
+		return
+	}
+
+	response.done = true
+	response.expiresAt = s.clock.Now().Add(idempotencyTTL)
+	response.statusCode = rec.statusCode
+	response.contentType = rec.Header().Get("Content-Type")
+	response.body = rec.body.Bytes()
+}
+
+// idempotent answers requests sent again with the same Idempotency-Key header with the response
+// of the first one instead of handling them twice, so clients can safely retry.
+func (r *router) idempotent(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		key := req.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			handler(w, req)
+			return
+		}
+
+		logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path), slog.String("idempotency_key", key))
+
+		payload, err := io.ReadAll(req.Body)
+		if err != nil {
+			logger.Error("failed to read request", slog.Any("error", err))
+			w.Header().Set("Content-Type", "application/json")
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+			return
+		}
+		req.Body = io.NopCloser(bytes.NewReader(payload))
+
+		// keys are scoped to the route they are sent to, whether through its version prefix or not
+		storeKey := req.Method + " " + r.unversionedPath(req.URL.Path) + " " + key
+		fingerprint := sha256.Sum256(payload)
+
+		response, ok := r.idempotency.begin(storeKey, fingerprint)
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+
+			switch {
+			case response.fingerprint != fingerprint:
+				logger.Error("idempotency key reused with another payload")
+				writeJSON(w, http.StatusUnprocessableEntity, errorBody{
+					Error: "idempotency key was already used with another payload",
+					Code:  codeIdempotencyKeyReused,
+				})
+			case !response.done:
+				logger.Warn("request with the same idempotency key in progress")
+				w.Header().Set("Retry-After", "1")
+				writeJSON(w, http.StatusConflict, errorBody{
+					Error: "a request with this idempotency key is in progress",
+					Code:  codeRequestInProgress,
+				})
+			default:
+				w.Header().Set("Content-Type", response.contentType)
+				w.Header().Set(idempotentReplayedHeader, "true")
+				w.WriteHeader(response.statusCode)
+				if _, err := w.Write(response.body); err != nil {
+					logger.Error("failed to replay response", slog.Any("error", err))
+				}
+			}
+			return
+		}
+
+		var rec *responseRecorder
+		// a panicking handler records nothing, leaving the key free for a retry
+		defer func() { r.idempotency.finish(storeKey, rec) }()
+
+		recorder := &responseRecorder{ResponseWriter: w}
+		handler(recorder, req)
+		rec = recorder
+	}
+}
+
+// unversionedPath is path without the version prefix it may start with.
+func (r *router) unversionedPath(path string) string {
+	for _, v := range r.versions {
+		if rest, ok := strings.CutPrefix(path, "/v"+v.name+"/"); ok {
+			return "/" + rest
+		}
+	}
+	return path
+}
+
+// responseRecorder writes a response while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rec *responseRecorder) WriteHeader(statusCode int) {
+	if rec.statusCode == 0 {
+		rec.statusCode = statusCode
+	}
+	rec.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (rec *responseRecorder) Write(data []byte) (int, error) {
+	if rec.statusCode == 0 {
+		rec.statusCode = http.StatusOK
+	}
+	rec.body.Write(data)
+	return rec.ResponseWriter.Write(data)
+}
@@ -0,0 +1,163 @@
+package router
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+)
+
+// validationOptions collects every violation of a request rather than stopping at the first one.
+var validationOptions = &openapi3filter.Options{
+	MultiError:         true,
+	AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+}
+
+func loadSpec(document []byte) *openapi3.T {
+	spec, err := openapi3.NewLoader().LoadFromData(document)
+	if err != nil {
+		panic(fmt.Sprintf("router: invalid OpenAPI document: %v", err))
+	}
+	return spec
+}
+
+// validated returns handler behind the validation of the operation of pattern in spec, POST
+// handlers also behind their idempotency keys. Like http.ServeMux with conflicting patterns, it
+// panics on undocumented routes.
+func (r *router) validated(spec *openapi3.T, pattern string, handler http.HandlerFunc) http.HandlerFunc {
+	method, path, _ := strings.Cut(pattern, " ")
+
+	pathItem := spec.Paths.Value(path)
+	if pathItem == nil || pathItem.GetOperation(method) == nil {
+		panic(fmt.Sprintf("router: %s is not documented in %s %s", pattern, spec.Info.Title, spec.Info.Version))
+	}
+
+	route := &routers.Route{
+		Spec:      spec,
+		Path:      path,
+		PathItem:  pathItem,
+		Method:    method,
+		Operation: pathItem.GetOperation(method),
+	}
+
+	var pathParams []string
+	for _, parameters := range []openapi3.Parameters{pathItem.Parameters, route.Operation.Parameters} {
+		for _, parameter := range parameters {
+			if parameter.Value.In == openapi3.ParameterInPath {
+				pathParams = append(pathParams, parameter.Value.Name)
+			}
+		}
+	}
+
+	if method == http.MethodPost {
+		handler = r.idempotent(handler)
+	}
+
+	return func(w http.ResponseWriter, req *http.Request) {
+		input := &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: make(map[string]string, len(pathParams)),
+			Route:      route,
+			Options:    validationOptions,
+		}
+		for _, name := range pathParams {
+			input.PathParams[name] = req.PathValue(name)
+		}
+
+		if err := openapi3filter.ValidateRequest(req.Context(), input); err != nil {
+			logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+			w.Header().Set("Content-Type", "application/json")
+
+			violations, ok := schemaViolations(err)
+			if !ok {
+				logger.Error("failed to parse request", slog.Any("error", err))
+				writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+				return
+			}
+
+			// the violations rather than err, which dumps the schema and the payload
+			validationErr := internal_error.NewValidationError(violations...)
+			logger.Error("request does not match api/openapi.json", slog.Any("error", validationErr))
+			writeError(w, validationErr)
+			return
+		}
+
+		handler(w, req)
+	}
+}
+
+// openapi serves the OpenAPI document of a version of the api.
+func (r *router) openapi(document []byte) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(document); err != nil {
+			r.logger.Error("failed to write openapi document", slog.Any("error", err))
+		}
+	}
+}
+
+// schemaViolations maps the errors of a request validation onto field violations, the way the
+// use cases report theirs. It reports false when the request could not be parsed at all.
+func schemaViolations(err error) ([]internal_error.FieldViolation, bool) {
+	var v internal_error.Violations
+	if !collectViolations(&v, "", err) {
+		return nil, false
+	}
+	return v, true
+}
+
+func collectViolations(v *internal_error.Violations, prefix string, err error) bool {
+	switch err := err.(type) {
+	case openapi3.MultiError:
+		for _, err := range err {
+			if !collectViolations(v, prefix, err) {
+				return false
+			}
+		}
+		return true
+
+	case *openapi3filter.RequestError:
+		if err.Parameter != nil {
+			prefix = err.Parameter.Name
+		}
+		// e.g. an unsupported content type
+		if err.Err == nil {
+			return false
+		}
+		return collectViolations(v, prefix, err.Err)
+
+	case *openapi3.SchemaError:
+		field := strings.Join(append([]string{prefix}, err.JSONPointer()...), ".")
+		field = strings.TrimPrefix(field, ".")
+
+		switch err.SchemaField {
+		case "required":
+			v.Add(field, internal_error.CodeRequired, "is required")
+		case "format":
+			v.Add(field, internal_error.CodeInvalid, "must be a "+err.Schema.Format)
+		default:
+			v.Add(field, internal_error.CodeInvalid, err.Reason)
+		}
+		return true
+	}
+
+	var parseErr *openapi3filter.ParseError
+	if errors.As(err, &parseErr) {
+		return false
+	}
+
+	if errors.Is(err, openapi3filter.ErrInvalidRequired) {
+		v.Add(prefix, internal_error.CodeRequired, "is required")
+	} else {
+		v.Add(prefix, internal_error.CodeInvalid, err.Error())
+	}
+	return true
+}
@@ -2,9 +2,14 @@ package router
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"time"
 
+	"github.com/aria3ppp/delivery-service-simulator/api"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
 	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
@@ -12,27 +17,59 @@ import (
 	goccy_json "github.com/goccy/go-json"
 )
 
+// heartbeatInterval is how often idle event streams send a comment, so proxies keep them open.
+const heartbeatInterval = 15 * time.Second
+
+// Config configures the http api.
+type Config struct {
+	// Unprefixed routes default to the oldest version for the clients predating versions, they are
+	// deprecated from UnversionedDeprecation in favor of the prefixed ones or of an explicit
+	// API-Version header, and removed at UnversionedSunset. Zero leaves them undeprecated.
+	UnversionedDeprecation time.Time
+	UnversionedSunset      time.Time
+}
+
 type router struct {
+	config *Config
 	uc     usecase.UseCase
 	logger *slog.Logger
 	mux    *http.ServeMux
+	// versions of the api, oldest first.
+	versions []*apiVersion
+	// idempotency replays the responses of POST requests retried with the same Idempotency-Key.
+	idempotency *idempotencyStore
 }
 
 var _ http.Handler = (*router)(nil)
 
 func NewRouter(
+	config *Config,
 	uc usecase.UseCase,
+	clock usecase.Clock,
 	logger *slog.Logger,
 ) *router {
 	router := &router{
-		uc:     uc,
-		logger: logger,
+		config:      config,
+		uc:          uc,
+		logger:      logger,
+		idempotency: newIdempotencyStore(clock),
 	}
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("POST /request", router.request)
-	mux.HandleFunc("POST /webhook", router.webhook)
+	v1 := router.version("1", api.OpenAPI)
+	v1.handle("POST /request", router.request)
+	v1.handle("POST /requests:batch", router.requestBatch)
+	v1.handle("POST /webhook", router.webhook)
+	v1.handle("GET /slots", router.slots)
+	v1.handle("GET /shipments", router.list)
+	v1.handle("GET /shipments/{uid}", router.get)
+	v1.handle("POST /shipments/{uid}/cancel", router.cancel)
+	v1.handle("GET /shipments/{uid}/events", router.events)
+	v1.handle("GET /shipments/nearby", router.nearby)
+	v1.handle("GET /shipments/watch", router.watch)
+	v1.handle("POST /quotes", router.quote)
 
+	mux := http.NewServeMux()
+	router.mount(mux)
 	router.mux = mux
 	return router
 }
@@ -46,36 +83,41 @@ func (r *router) request(w http.ResponseWriter, req *http.Request) {
 	var requestInput domain.RequestInput
 	if err := goccy_json.NewDecoder(req.Body).Decode(&requestInput); err != nil {
 		logger.Error("failed to decode request", slog.Any("error", err))
-
-		w.WriteHeader(http.StatusBadRequest)
-		if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
-			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
-		}
-
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
 		return
 	}
 
 	response, err := r.uc.Request(req.Context(), &requestInput)
 	if err != nil {
 		logger.Error("failed to uc.RequestDelivery", slog.Any("error", err))
+		writeError(w, err)
+		return
+	}
 
-		if _, ok := err.(internal_error.ValidationError); ok {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
-		}
+	writeJSON(w, http.StatusOK, response)
+}
 
-		if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
-			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
-		}
+func (r *router) requestBatch(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer req.Body.Close()
 
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	var batchRequestInput domain.BatchRequestInput
+	if err := goccy_json.NewDecoder(req.Body).Decode(&batchRequestInput); err != nil {
+		logger.Error("failed to decode request", slog.Any("error", err))
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logger.Error("failed to encode response", slog.Any("error", err))
-		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	response, err := r.uc.RequestBatch(req.Context(), &batchRequestInput)
+	if err != nil {
+		logger.Error("failed to uc.RequestBatch", slog.Any("error", err))
+		writeError(w, err)
+		return
 	}
+
+	writeJSON(w, http.StatusOK, response)
 }
 
 func (r *router) webhook(w http.ResponseWriter, req *http.Request) {
@@ -87,38 +129,320 @@ func (r *router) webhook(w http.ResponseWriter, req *http.Request) {
 	var webhookInput domain.WebhookInput
 	if err := goccy_json.NewDecoder(req.Body).Decode(&webhookInput); err != nil {
 		logger.Error("failed to decode request", slog.Any("error", err))
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+		return
+	}
+
+	response, err := r.uc.Webhook(req.Context(), &webhookInput)
+	if err != nil {
+		logger.Error("failed to uc.Webhook", slog.Any("error", err))
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (r *router) slots(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	from, err := parseTimeQuery(req, "from")
+	if err != nil {
+		logger.Error("failed to parse query param", slog.String("param", "from"), slog.Any("error", err))
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+		return
+	}
+
+	to, err := parseTimeQuery(req, "to")
+	if err != nil {
+		logger.Error("failed to parse query param", slog.String("param", "to"), slog.Any("error", err))
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+		return
+	}
+
+	slotsInput := domain.SlotsInput{
+		ZoneID: req.URL.Query().Get("zone"),
+		From:   from,
+		To:     to,
+	}
+
+	response, err := r.uc.Slots(req.Context(), &slotsInput)
+	if err != nil {
+		logger.Error("failed to uc.Slots", slog.Any("error", err))
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (r *router) get(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-		w.WriteHeader(http.StatusBadRequest)
-		if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
-			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	response, err := r.uc.Get(req.Context(), &domain.GetInput{ShipmentUID: req.PathValue("uid")})
+	if err != nil {
+		logger.Error("failed to uc.Get", slog.Any("error", err))
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (r *router) list(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	query := req.URL.Query()
+	listInput := domain.ListInput{
+		ShipmentFilter: domain.ShipmentFilter{
+			ShipmentUIDs: query["shipment_uid"],
+			ZoneIDs:      query["zone_id"],
+			Statuses:     query["status"],
+		},
+		PageToken: query.Get("page_token"),
+	}
+
+	if pageSize := query.Get("page_size"); pageSize != "" {
+		value, err := strconv.Atoi(pageSize)
+		if err != nil {
+			logger.Error("failed to parse query param", slog.String("param", "page_size"), slog.Any("error", err))
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: "page_size must be an integer"})
+			return
 		}
+		listInput.PageSize = value
+	}
 
+	response, err := r.uc.List(req.Context(), &listInput)
+	if err != nil {
+		logger.Error("failed to uc.List", slog.Any("error", err))
+		writeError(w, err)
 		return
 	}
 
-	response, err := r.uc.Webhook(req.Context(), &webhookInput)
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (r *router) cancel(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	response, err := r.uc.Cancel(req.Context(), &domain.CancelInput{ShipmentUID: req.PathValue("uid")})
 	if err != nil {
-		logger.Error("failed to uc.Webhook", slog.Any("error", err))
+		logger.Error("failed to uc.Cancel", slog.Any("error", err))
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// events streams the changes of a shipment as server-sent events, starting with its current state.
+// The stream ends when the client falls too far behind, EventSource clients then reconnect
+// and start over from the current state.
+func (r *router) events(w http.ResponseWriter, req *http.Request) {
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logger.Error("response writer does not support flushing")
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusInternalServerError, errorBody{Error: "streaming is not supported"})
+		return
+	}
+
+	response, err := r.uc.Subscribe(req.Context(), &domain.SubscribeInput{ShipmentUID: req.PathValue("uid")})
+	if err != nil {
+		logger.Error("failed to uc.Subscribe", slog.Any("error", err))
+		w.Header().Set("Content-Type", "application/json")
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// nginx buffers proxied responses unless told otherwise
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	if err := writeEvent(w, &response.Current); err != nil {
+		logger.Error("failed to write event", slog.Any("error", err))
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-req.Context().Done():
+			return
+
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				logger.Error("failed to write heartbeat", slog.Any("error", err))
+				return
+			}
 
-		if _, ok := err.(internal_error.ValidationError); ok {
-			w.WriteHeader(http.StatusBadRequest)
-		} else {
-			w.WriteHeader(http.StatusInternalServerError)
+		case event, ok := <-response.Events:
+			if !ok {
+				logger.Warn("event stream closed")
+				return
+			}
+
+			if err := writeEvent(w, &event); err != nil {
+				logger.Error("failed to write event", slog.Any("error", err))
+				return
+			}
 		}
 
-		if err := json.NewEncoder(w).Encode(map[string]string{"error": err.Error()}); err != nil {
-			http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+		flusher.Flush()
+	}
+}
+
+func (r *router) nearby(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	var nearbyInput domain.NearbyInput
+	for _, param := range []struct {
+		name string
+		dst  *float64
+	}{
+		{"lat", &nearbyInput.Center.Lat},
+		{"long", &nearbyInput.Center.Long},
+		{"radius_meters", &nearbyInput.RadiusMeters},
+	} {
+		value, err := strconv.ParseFloat(req.URL.Query().Get(param.name), 64)
+		if err != nil {
+			logger.Error("failed to parse query param", slog.String("param", param.name), slog.Any("error", err))
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: param.name + " must be a number"})
+			return
 		}
+		*param.dst = value
+	}
 
+	if limit := req.URL.Query().Get("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil {
+			logger.Error("failed to parse query param", slog.String("param", "limit"), slog.Any("error", err))
+			writeJSON(w, http.StatusBadRequest, errorBody{Error: "limit must be an integer"})
+			return
+		}
+		nearbyInput.Limit = value
+	}
+
+	nearbyInput.Status = req.URL.Query().Get("status")
+
+	response, err := r.uc.Nearby(req.Context(), &nearbyInput)
+	if err != nil {
+		logger.Error("failed to uc.Nearby", slog.Any("error", err))
+		writeError(w, err)
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		logger.Error("failed to encode response", slog.Any("error", err))
-		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	writeJSON(w, http.StatusOK, response)
+}
+
+func (r *router) quote(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	defer req.Body.Close()
+
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	var quoteInput domain.QuoteInput
+	if err := goccy_json.NewDecoder(req.Body).Decode(&quoteInput); err != nil {
+		logger.Error("failed to decode request", slog.Any("error", err))
+		writeJSON(w, http.StatusBadRequest, errorBody{Error: err.Error()})
+		return
 	}
+
+	response, err := r.uc.Quote(req.Context(), &quoteInput)
+	if err != nil {
+		logger.Error("failed to uc.Quote", slog.Any("error", err))
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, response)
 }
 
 func (r *router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	r.mux.ServeHTTP(w, req)
 }
+
+type errorBody struct {
+	Error      string                          `json:"error"`
+	Code       string                          `json:"code,omitempty"`
+	Violations []internal_error.FieldViolation `json:"violations,omitempty"`
+}
+
+// writeError maps a use case error onto a status code and a json error body.
+// Validation errors are answered with 422 and carry their field violations
+// so clients can map them onto form fields, not found with 404 and conflicts with 409.
+func writeError(w http.ResponseWriter, err error) {
+	var validationErr internal_error.ValidationError
+	if errors.As(err, &validationErr) {
+		writeJSON(w, http.StatusUnprocessableEntity, errorBody{
+			Error:      validationErr.Error(),
+			Violations: validationErr.Violations,
+		})
+		return
+	}
+
+	var notFoundErr internal_error.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		writeJSON(w, http.StatusNotFound, errorBody{Error: notFoundErr.Error()})
+		return
+	}
+
+	var conflictErr internal_error.ConflictError
+	if errors.As(err, &conflictErr) {
+		writeJSON(w, http.StatusConflict, errorBody{Error: conflictErr.Error(), Code: conflictErr.Code})
+		return
+	}
+
+	writeJSON(w, http.StatusInternalServerError, errorBody{Error: err.Error()})
+}
+
+// parseTimeQuery parses an optional RFC3339 query param, returning the zero time when it is absent.
+func parseTimeQuery(req *http.Request, param string) (time.Time, error) {
+	value := req.URL.Query().Get(param)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", param)
+	}
+
+	return t, nil
+}
+
+// writeEvent writes event as a server-sent event of type shipment.
+func writeEvent(w http.ResponseWriter, event *domain.ShipmentEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: shipment\ndata: %s\n\n", data)
+	return err
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		http.Error(w, "Internal Server Error: "+err.Error(), http.StatusInternalServerError)
+	}
+}
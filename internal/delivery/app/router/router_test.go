@@ -0,0 +1,393 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/api"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+// stubUseCase fails every Get with err and records the input of Slots, the other methods are not
+// expected to be called.
+type stubUseCase struct {
+	usecase.UseCase
+	err error
+
+	slotsInput *domain.SlotsInput
+	cancels    int
+}
+
+func (s *stubUseCase) Slots(ctx context.Context, input *domain.SlotsInput) (*domain.SlotsResult, error) {
+	s.slotsInput = input
+	return &domain.SlotsResult{}, nil
+}
+
+func (s *stubUseCase) Cancel(ctx context.Context, input *domain.CancelInput) (*domain.CancelResult, error) {
+	s.cancels++
+	return nil, s.err
+}
+
+func (s *stubUseCase) Get(ctx context.Context, input *domain.GetInput) (*domain.GetResult, error) {
+	return nil, s.err
+}
+
+func newTestRouter(uc usecase.UseCase) *router {
+	return NewRouter(&Config{}, uc, clock.NewClock(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func serve(handler http.Handler, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func decodeErrorBody(t *testing.T, rec *httptest.ResponseRecorder) errorBody {
+	t.Helper()
+
+	var body errorBody
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decoding the error body: %v", err)
+	}
+	return body
+}
+
+func TestWriteError(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		err        error
+		statusCode int
+		code       string
+		violations int
+	}{
+		{
+			"validation",
+			internal_error.NewValidationError(internal_error.FieldViolation{Field: "uid", Code: internal_error.CodeInvalid, Message: "is invalid"}),
+			http.StatusUnprocessableEntity,
+			"",
+			1,
+		},
+		{
+			"wrapped validation",
+			fmt.Errorf("getting shipment: %w", internal_error.NewValidationError(internal_error.FieldViolation{Field: "uid"})),
+			http.StatusUnprocessableEntity,
+			"",
+			1,
+		},
+		{"not found", internal_error.ErrShipmentNotFound, http.StatusNotFound, "", 0},
+		{"conflict", internal_error.ErrShipmentExists, http.StatusConflict, internal_error.CodeDuplicate, 0},
+		{"internal", errors.New("connection refused"), http.StatusInternalServerError, "", 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(newTestRouter(&stubUseCase{err: tt.err}), http.MethodGet, "/v1/shipments/shipment", "", nil)
+
+			if rec.Code != tt.statusCode {
+				t.Errorf("status code = %d, want %d", rec.Code, tt.statusCode)
+			}
+
+			body := decodeErrorBody(t, rec)
+			if body.Code != tt.code || len(body.Violations) != tt.violations {
+				t.Errorf("body = %+v, want code %q and %d violations", body, tt.code, tt.violations)
+			}
+		})
+	}
+}
+
+// TestRequestBodyErrors checks a body that cannot be parsed is a bad request while one that is
+// parsed but invalid is unprocessable, with its violations.
+func TestRequestBodyErrors(t *testing.T) {
+	r := newTestRouter(&stubUseCase{})
+
+	rec := serve(r, http.MethodPost, "/v1/request", "{", nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed body: status code = %d, want %d", rec.Code, http.StatusBadRequest)
+	}
+	if body := decodeErrorBody(t, rec); len(body.Violations) != 0 {
+		t.Errorf("malformed body: violations = %+v, want none", body.Violations)
+	}
+
+	rec = serve(r, http.MethodPost, "/v1/request", `{"shipment_uid": "shipment"}`, nil)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid body: status code = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	body := decodeErrorBody(t, rec)
+	fields := make(map[string]string, len(body.Violations))
+	for _, violation := range body.Violations {
+		fields[violation.Field] = violation.Code
+	}
+	for _, field := range []string{"user_info", "routing_info", "scheduled_delivery_window"} {
+		if fields[field] != internal_error.CodeRequired {
+			t.Errorf("invalid body: violations = %+v, want %s %s", body.Violations, field, internal_error.CodeRequired)
+		}
+	}
+}
+
+func TestSlotsQuery(t *testing.T) {
+	uc := &stubUseCase{}
+	r := newTestRouter(uc)
+
+	rec := serve(r, http.MethodGet, "/v1/slots?zone=north&from=2026-01-05T10:00:00Z&to=2026-01-05T14:00:00%2B02:00", "", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	want := domain.SlotsInput{
+		ZoneID: "north",
+		From:   time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC),
+		To:     time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC),
+	}
+	if got := uc.slotsInput; got == nil || got.ZoneID != want.ZoneID || !got.From.Equal(want.From) || !got.To.Equal(want.To) {
+		t.Errorf("slots input = %+v, want %+v", got, want)
+	}
+
+	uc.slotsInput = nil
+	if rec := serve(r, http.MethodGet, "/v1/slots", "", nil); rec.Code != http.StatusOK || uc.slotsInput == nil || !uc.slotsInput.From.IsZero() {
+		t.Errorf("without a range: status code %d and input %+v, want the use case to pick it", rec.Code, uc.slotsInput)
+	}
+
+	uc.slotsInput = nil
+	rec = serve(r, http.MethodGet, "/v1/slots?from=tomorrow", "", nil)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Errorf("invalid from: status code = %d, want %d", rec.Code, http.StatusUnprocessableEntity)
+	}
+	if body := decodeErrorBody(t, rec); len(body.Violations) != 1 || body.Violations[0].Field != "from" {
+		t.Errorf("invalid from: violations = %+v, want one on from", body.Violations)
+	}
+	if uc.slotsInput != nil {
+		t.Error("invalid from reached the use case")
+	}
+}
+
+// The deprecation and sunset of the unprefixed routes of newVersionedTestRouter.
+var (
+	unversionedDeprecation = time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	unversionedSunset      = time.Date(2027, 4, 19, 0, 0, 0, 0, time.UTC)
+)
+
+// newVersionedTestRouter serves the shipments and slots of version 1 and a version 2 superseding
+// it, whose document only accepts lowercase shipment uids and whose get answers without the use case.
+func newVersionedTestRouter(t *testing.T, uc usecase.UseCase, deprecation, sunset time.Time) *router {
+	t.Helper()
+
+	var document map[string]any
+	if err := json.Unmarshal(api.OpenAPI, &document); err != nil {
+		t.Fatal(err)
+	}
+	document["info"].(map[string]any)["version"] = "2.0.0"
+	uid := document["components"].(map[string]any)["parameters"].(map[string]any)["ShipmentUID"].(map[string]any)
+	uid["schema"].(map[string]any)["pattern"] = "^[a-z_]+$"
+
+	documentV2, err := json.Marshal(document)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := &router{
+		config:      &Config{UnversionedDeprecation: unversionedDeprecation, UnversionedSunset: unversionedSunset},
+		uc:          uc,
+		logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		idempotency: newIdempotencyStore(clock.NewClock()),
+	}
+
+	v1 := r.version("1", api.OpenAPI)
+	v1.handle("GET /slots", r.slots)
+	v1.handle("GET /shipments/{uid}", r.get)
+
+	v2 := r.version("2", documentV2)
+	v2.handle("GET /shipments/{uid}", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		writeJSON(w, http.StatusOK, map[string]string{"uid": req.PathValue("uid")})
+	})
+	v1.deprecation, v1.sunset = deprecation, sunset
+
+	r.mux = http.NewServeMux()
+	r.mount(r.mux)
+	return r
+}
+
+func TestVersions(t *testing.T) {
+	deprecation := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2027, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	uc := &stubUseCase{err: internal_error.ErrShipmentNotFound}
+	r := newVersionedTestRouter(t, uc, deprecation, sunset)
+
+	for _, tt := range []struct {
+		name       string
+		target     string
+		version    string
+		statusCode int
+		served     string
+		// successor is the Link of a deprecated route, empty when it is current.
+		successor   string
+		deprecation time.Time
+		sunset      time.Time
+	}{
+		{
+			name:        "v1 overridden route",
+			target:      "/v1/shipments/ABC",
+			statusCode:  http.StatusNotFound,
+			served:      "1",
+			successor:   "</v2/shipments/ABC>; rel=\"successor-version\"",
+			deprecation: deprecation,
+			sunset:      sunset,
+		},
+		{
+			name:       "v2 overriding route",
+			target:     "/v2/shipments/abc",
+			statusCode: http.StatusOK,
+			served:     "2",
+		},
+		{
+			name:       "v2 validating with its own document",
+			target:     "/v2/shipments/ABC",
+			statusCode: http.StatusUnprocessableEntity,
+			served:     "2",
+		},
+		{
+			name:       "v2 inherited route",
+			target:     "/v2/slots?zone=north",
+			statusCode: http.StatusOK,
+			served:     "2",
+		},
+		{
+			name:       "v2 own document",
+			target:     "/v2/openapi.json",
+			statusCode: http.StatusOK,
+			served:     "2",
+		},
+		{
+			name:       "negotiated v2",
+			target:     "/shipments/abc",
+			version:    "2",
+			statusCode: http.StatusOK,
+			served:     "2",
+		},
+		{
+			name:        "negotiated v1",
+			target:      "/shipments/abc",
+			version:     "v1",
+			statusCode:  http.StatusNotFound,
+			served:      "1",
+			successor:   "</v2/shipments/abc>; rel=\"successor-version\"",
+			deprecation: deprecation,
+			sunset:      sunset,
+		},
+		{
+			name:        "unversioned",
+			target:      "/shipments/abc",
+			statusCode:  http.StatusNotFound,
+			served:      "1",
+			successor:   "</v1/shipments/abc>; rel=\"successor-version\"",
+			deprecation: unversionedDeprecation,
+			sunset:      unversionedSunset,
+		},
+		{
+			name:       "unsupported version",
+			target:     "/shipments/abc",
+			version:    "3",
+			statusCode: http.StatusBadRequest,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var header http.Header
+			if tt.version != "" {
+				header = http.Header{apiVersionHeader: {tt.version}}
+			}
+
+			rec := serve(r, http.MethodGet, tt.target, "", header)
+			if rec.Code != tt.statusCode {
+				t.Fatalf("status code = %d, want %d: %s", rec.Code, tt.statusCode, rec.Body)
+			}
+			if served := rec.Header().Get(apiVersionHeader); served != tt.served {
+				t.Errorf("served by version %q, want %q", served, tt.served)
+			}
+
+			if tt.successor == "" {
+				if deprecation := rec.Header().Get("Deprecation"); deprecation != "" {
+					t.Errorf("Deprecation = %q, want none", deprecation)
+				}
+				return
+			}
+
+			if got, want := rec.Header().Get("Deprecation"), fmt.Sprintf("@%d", tt.deprecation.Unix()); got != want {
+				t.Errorf("Deprecation = %q, want %q", got, want)
+			}
+			if got, want := rec.Header().Get("Sunset"), tt.sunset.Format(http.TimeFormat); got != want {
+				t.Errorf("Sunset = %q, want %q", got, want)
+			}
+			if got := rec.Header().Values("Link"); len(got) != 1 || got[0] != tt.successor {
+				t.Errorf("Link = %q, want %q", got, tt.successor)
+			}
+		})
+	}
+
+	if body := decodeErrorBody(t, serve(r, http.MethodGet, "/v2/shipments/ABC", "", nil)); len(body.Violations) != 1 || body.Violations[0].Field != "uid" {
+		t.Errorf("v2 violations = %+v, want one on uid", body.Violations)
+	}
+
+	// unprefixed routes are only deprecated once configured to
+	if rec := serve(newTestRouter(uc), http.MethodGet, "/shipments/abc", "", nil); rec.Header().Get("Deprecation") != "" {
+		t.Errorf("Deprecation = %q without a configured deprecation, want none", rec.Header().Get("Deprecation"))
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	uc := &stubUseCase{err: internal_error.ErrShipmentNotFound}
+	fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	r := NewRouter(&Config{}, uc, fakeClock, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	header := http.Header{idempotencyKeyHeader: {"cancel-abc"}}
+
+	for _, tt := range []struct {
+		name     string
+		target   string
+		advance  time.Duration
+		replayed bool
+		cancels  int
+	}{
+		{name: "first", target: "/v1/shipments/abc/cancel", cancels: 1},
+		// the unprefixed route is the same route as the prefixed one
+		{name: "unprefixed retry", target: "/shipments/abc/cancel", replayed: true, cancels: 1},
+		{name: "other shipment", target: "/v1/shipments/def/cancel", cancels: 2},
+		{name: "expired", target: "/v1/shipments/abc/cancel", advance: idempotencyTTL + time.Second, cancels: 3},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock.Advance(tt.advance)
+
+			rec := serve(r, http.MethodPost, tt.target, "", header)
+			if rec.Code != http.StatusNotFound {
+				t.Fatalf("status code = %d, want %d: %s", rec.Code, http.StatusNotFound, rec.Body)
+			}
+			if replayed := rec.Header().Get(idempotentReplayedHeader) == "true"; replayed != tt.replayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.replayed)
+			}
+			if uc.cancels != tt.cancels {
+				t.Errorf("cancelled %d times, want %d", uc.cancels, tt.cancels)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// apiVersionHeader names the version of the api a request to an unprefixed route asks for, and
+// the version a response was served by.
+const apiVersionHeader = "API-Version"
+
+// codeUnsupportedVersion is answered with 400 to an API-Version header naming no version.
+const codeUnsupportedVersion = "unsupported_version"
+
+// apiVersion is a version of the http api, mounted under /v<name>.
+type apiVersion struct {
+	name string
+	spec *openapi3.T
+	// deprecation and sunset are when the version was deprecated for its successor and when it is
+	// to be removed, zero while it is current.
+	deprecation time.Time
+	sunset      time.Time
+	successor   *apiVersion
+
+	patterns []string
+	handlers map[string]http.HandlerFunc
+}
+
+// version adds a version of the api described by the OpenAPI document. It serves the routes of
+// the version before it until handle overrides those whose payloads change with handlers adapting
+// them to the same use case.
+func (r *router) version(name string, document []byte) *apiVersion {
+	v := &apiVersion{
+		name:     name,
+		spec:     loadSpec(document),
+		handlers: make(map[string]http.HandlerFunc),
+	}
+
+	if len(r.versions) > 0 {
+		previous := r.versions[len(r.versions)-1]
+		previous.successor = v
+
+		v.patterns = slices.Clone(previous.patterns)
+		for pattern, handler := range previous.handlers {
+			v.handlers[pattern] = handler
+		}
+	}
+
+	// every version serves its own document
+	v.handle("GET /openapi.json", r.openapi(document))
+
+	r.versions = append(r.versions, v)
+	return v
+}
+
+func (v *apiVersion) handle(pattern string, handler http.HandlerFunc) {
+	if _, ok := v.handlers[pattern]; !ok {
+		v.patterns = append(v.patterns, pattern)
+	}
+	v.handlers[pattern] = handler
+}
+
+// serve marks the responses of handler with the version, and its deprecation if any.
+func (v *apiVersion) serve(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set(apiVersionHeader, v.name)
+		if !v.deprecation.IsZero() {
+			path := strings.TrimPrefix(req.URL.Path, "/v"+v.name)
+			setDeprecation(w.Header(), v.deprecation, v.sunset, "/v"+v.successor.name+path)
+		}
+		handler(w, req)
+	}
+}
+
+// mount registers the routes of every version under their prefix, and the routes of all of them
+// unprefixed, negotiating the version with the API-Version header.
+func (r *router) mount(mux *http.ServeMux) {
+	var patterns []string
+	routes := make(map[*apiVersion]map[string]http.HandlerFunc, len(r.versions))
+
+	for _, v := range r.versions {
+		routes[v] = make(map[string]http.HandlerFunc, len(v.patterns))
+
+		for _, pattern := range v.patterns {
+			handler := r.validated(v.spec, pattern, v.handlers[pattern])
+			routes[v][pattern] = handler
+
+			method, path, _ := strings.Cut(pattern, " ")
+			mux.HandleFunc(method+" /v"+v.name+path, v.serve(handler))
+
+			if !slices.Contains(patterns, pattern) {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+
+	for _, pattern := range patterns {
+		mux.HandleFunc(pattern, r.negotiate(pattern, routes))
+	}
+}
+
+// negotiate serves an unprefixed route with the version named by the API-Version header. Requests
+// without one are served by the oldest version and told to move to a prefixed route.
+func (r *router) negotiate(pattern string, routes map[*apiVersion]map[string]http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", apiVersionHeader)
+
+		name := req.Header.Get(apiVersionHeader)
+
+		v := r.versions[0]
+		if name != "" {
+			v = r.lookupVersion(strings.TrimPrefix(name, "v"))
+		}
+
+		if v == nil {
+			names := make([]string, len(r.versions))
+			for i, v := range r.versions {
+				names[i] = v.name
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			writeJSON(w, http.StatusBadRequest, errorBody{
+				Error: fmt.Sprintf("api version %q is not supported, supported versions are %s", name, strings.Join(names, ", ")),
+				Code:  codeUnsupportedVersion,
+			})
+			return
+		}
+
+		handler, ok := routes[v][pattern]
+		if !ok {
+			w.Header().Set("Content-Type", "application/json")
+			writeJSON(w, http.StatusNotFound, errorBody{Error: fmt.Sprintf("%s is not served by api version %s", pattern, v.name)})
+			return
+		}
+
+		if name == "" && !r.config.UnversionedDeprecation.IsZero() {
+			setDeprecation(w.Header(), r.config.UnversionedDeprecation, r.config.UnversionedSunset, "/v"+v.name+req.URL.Path)
+		}
+
+		v.serve(handler)(w, req)
+	}
+}
+
+func (r *router) lookupVersion(name string) *apiVersion {
+	for _, v := range r.versions {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// setDeprecation sets the Deprecation (RFC 9745) and Sunset (RFC 8594) headers of a deprecated
+// route, linking to the route replacing it.
+func setDeprecation(header http.Header, deprecation, sunset time.Time, successor string) {
+	if header.Get("Deprecation") != "" {
+		return
+	}
+
+	header.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Unix(), 10))
+	header.Set("Sunset", sunset.UTC().Format(http.TimeFormat))
+	header.Add("Link", "<"+successor+">; rel=\"successor-version\"")
+}
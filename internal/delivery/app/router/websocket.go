@@ -0,0 +1,342 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// wsWriteWait is how long a single message may take to be written.
+	wsWriteWait = 10 * time.Second
+	// wsPongWait is how long the client may stay silent, pings are answered with pongs by browsers.
+	wsPongWait     = 60 * time.Second
+	wsPingInterval = wsPongWait * 9 / 10
+
+	// wsBatchInterval is how long events are held to be sent together, only the latest state of
+	// a shipment changing several times meanwhile is sent.
+	wsBatchInterval = 250 * time.Millisecond
+	wsMaxBatchSize  = 100
+
+	wsMaxSubscriptions = 100
+	wsMaxMessageSize   = 64 << 10
+)
+
+// Client messages.
+const (
+	wsSubscribe   = "subscribe"
+	wsUnsubscribe = "unsubscribe"
+)
+
+// Server messages.
+const (
+	wsSubscribed   = "subscribed"
+	wsUnsubscribed = "unsubscribed"
+	// wsDropped tells the client a subscription fell too far behind and was ended: it should
+	// subscribe again and start over from the current state.
+	wsDropped = "dropped"
+	wsEvents  = "events"
+	wsError   = "error"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+type wsClientMessage struct {
+	Type string `json:"type"`
+	// ID names the subscription, to unsubscribe from it later.
+	ID string `json:"id"`
+	domain.ShipmentFilter
+}
+
+type wsServerMessage struct {
+	Type       string                          `json:"type"`
+	ID         string                          `json:"id,omitempty"`
+	Events     []domain.ShipmentEvent          `json:"events,omitempty"`
+	Error      string                          `json:"error,omitempty"`
+	Violations []internal_error.FieldViolation `json:"violations,omitempty"`
+
+	// sent is closed by the writer once the message is sent, if set.
+	sent chan struct{}
+}
+
+// wsConn is a websocket client watching shipments through any number of subscriptions.
+// Its reader handles subscriptions while its writer batches their events.
+type wsConn struct {
+	conn   *websocket.Conn
+	uc     usecase.UseCase
+	logger *slog.Logger
+
+	replies chan wsServerMessage
+	updates chan domain.ShipmentEvent
+
+	mu            sync.Mutex
+	subscriptions map[string]context.CancelFunc
+}
+
+// watch upgrades to a websocket over which the client subscribes to shipment uids or to zone and
+// status filters, and receives the changes of the matching shipments in batches.
+func (r *router) watch(w http.ResponseWriter, req *http.Request) {
+	logger := r.logger.With(slog.String("method", req.Method), slog.String("url", req.URL.Path))
+
+	conn, err := upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		// the upgrader has already answered
+		logger.Error("failed to upgrade to websocket", slog.Any("error", err))
+		return
+	}
+	defer conn.Close()
+
+	// hijacked connections do not end the request context, the reader does once the client is gone
+	ctx, cancel := context.WithCancel(req.Context())
+	defer cancel()
+
+	c := &wsConn{
+		conn:          conn,
+		uc:            r.uc,
+		logger:        logger,
+		replies:       make(chan wsServerMessage, 16),
+		updates:       make(chan domain.ShipmentEvent, wsMaxBatchSize),
+		subscriptions: make(map[string]context.CancelFunc),
+	}
+
+	go func() {
+		defer cancel()
+		c.read(ctx)
+	}()
+
+	c.write(ctx)
+}
+
+func (c *wsConn) read(ctx context.Context) {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(wsPongWait))
+	})
+
+	for {
+		if err := c.conn.SetReadDeadline(time.Now().Add(wsPongWait)); err != nil {
+			c.logger.Error("failed to set read deadline", slog.Any("error", err))
+			return
+		}
+
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				c.logger.Error("failed to read message", slog.Any("error", err))
+			}
+			return
+		}
+
+		var message wsClientMessage
+		if err := json.Unmarshal(data, &message); err != nil {
+			c.reply(ctx, wsServerMessage{Type: wsError, Error: err.Error()})
+			continue
+		}
+
+		switch message.Type {
+		case wsSubscribe:
+			c.subscribe(ctx, &message)
+		case wsUnsubscribe:
+			c.unsubscribe(ctx, message.ID)
+		default:
+			c.reply(ctx, wsServerMessage{Type: wsError, ID: message.ID, Error: "type must be subscribe or unsubscribe"})
+		}
+	}
+}
+
+func (c *wsConn) subscribe(ctx context.Context, message *wsClientMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch _, exists := c.subscriptions[message.ID]; {
+	case message.ID == "":
+		c.reply(ctx, wsServerMessage{Type: wsError, Error: "id is required"})
+		return
+	case exists:
+		c.reply(ctx, wsServerMessage{Type: wsError, ID: message.ID, Error: "id is already subscribed"})
+		return
+	case len(c.subscriptions) >= wsMaxSubscriptions:
+		c.reply(ctx, wsServerMessage{Type: wsError, ID: message.ID, Error: "too many subscriptions"})
+		return
+	}
+
+	subscriptionCtx, cancel := context.WithCancel(ctx)
+
+	result, err := c.uc.Watch(subscriptionCtx, &domain.WatchInput{ShipmentFilter: message.ShipmentFilter})
+	if err != nil {
+		cancel()
+		c.logger.Error("failed to uc.Watch", slog.String("id", message.ID), slog.Any("error", err))
+		c.replyError(ctx, message.ID, err)
+		return
+	}
+
+	// events and replies reach the writer apart: the subscription starts forwarding once its
+	// current state is sent so no event goes out before it
+	subscribed := make(chan struct{})
+
+	c.subscriptions[message.ID] = cancel
+	c.reply(ctx, wsServerMessage{Type: wsSubscribed, ID: message.ID, Events: result.Current, sent: subscribed})
+
+	go c.forward(ctx, subscriptionCtx, message.ID, subscribed, result.Events)
+}
+
+// forward hands the events of a subscription to the writer until it ends, once subscribed is closed.
+func (c *wsConn) forward(ctx, subscriptionCtx context.Context, id string, subscribed <-chan struct{}, events <-chan domain.ShipmentEvent) {
+	select {
+	case <-subscribed:
+	case <-subscriptionCtx.Done():
+		return
+	}
+
+	for event := range events {
+		select {
+		case c.updates <- event:
+		case <-subscriptionCtx.Done():
+			return
+		}
+	}
+
+	// the events are over although nobody ended the subscription: it fell behind
+	if subscriptionCtx.Err() != nil {
+		return
+	}
+
+	c.logger.Warn("subscription fell behind", slog.String("id", id))
+
+	c.mu.Lock()
+	if cancel, ok := c.subscriptions[id]; ok {
+		cancel()
+		delete(c.subscriptions, id)
+	}
+	c.mu.Unlock()
+
+	c.reply(ctx, wsServerMessage{Type: wsDropped, ID: id})
+}
+
+func (c *wsConn) unsubscribe(ctx context.Context, id string) {
+	c.mu.Lock()
+	cancel, ok := c.subscriptions[id]
+	delete(c.subscriptions, id)
+	c.mu.Unlock()
+
+	if !ok {
+		c.reply(ctx, wsServerMessage{Type: wsError, ID: id, Error: "id is not subscribed"})
+		return
+	}
+
+	cancel()
+	c.reply(ctx, wsServerMessage{Type: wsUnsubscribed, ID: id})
+}
+
+// reply queues message for the writer, giving up once the connection is over.
+func (c *wsConn) reply(ctx context.Context, message wsServerMessage) {
+	select {
+	case c.replies <- message:
+	case <-ctx.Done():
+	}
+}
+
+func (c *wsConn) replyError(ctx context.Context, id string, err error) {
+	message := wsServerMessage{Type: wsError, ID: id, Error: err.Error()}
+
+	var validationErr internal_error.ValidationError
+	if errors.As(err, &validationErr) {
+		message.Violations = validationErr.Violations
+	}
+
+	c.reply(ctx, message)
+}
+
+// write sends replies as they come and events in batches, pinging the client meanwhile.
+// A client reading too slowly blocks the writer, its subscriptions then fall behind and are dropped.
+func (c *wsConn) write(ctx context.Context) {
+	ping := time.NewTicker(wsPingInterval)
+	defer ping.Stop()
+
+	flush := time.NewTicker(wsBatchInterval)
+	defer flush.Stop()
+
+	var (
+		pending = make(map[string]domain.ShipmentEvent)
+		order   []string
+	)
+
+	flushPending := func() error {
+		for len(order) > 0 {
+			batch := order[:min(wsMaxBatchSize, len(order))]
+			order = order[len(batch):]
+
+			events := make([]domain.ShipmentEvent, len(batch))
+			for i, uid := range batch {
+				events[i] = pending[uid]
+				delete(pending, uid)
+			}
+
+			if err := c.send(wsServerMessage{Type: wsEvents, Events: events}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		var err error
+
+		select {
+		case <-ctx.Done():
+			c.conn.WriteControl(
+				websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+				time.Now().Add(wsWriteWait),
+			)
+			return
+
+		case message := <-c.replies:
+			err = c.send(message)
+			if err == nil && message.sent != nil {
+				close(message.sent)
+			}
+
+		case event := <-c.updates:
+			if _, ok := pending[event.ShipmentUID]; !ok {
+				order = append(order, event.ShipmentUID)
+			}
+			pending[event.ShipmentUID] = event
+
+			if len(order) >= wsMaxBatchSize {
+				err = flushPending()
+			}
+
+		case <-flush.C:
+			err = flushPending()
+
+		case <-ping.C:
+			err = c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteWait))
+		}
+
+		if err != nil {
+			c.logger.Error("failed to write message", slog.Any("error", err))
+			return
+		}
+	}
+}
+
+func (c *wsConn) send(message wsServerMessage) error {
+	if err := c.conn.SetWriteDeadline(time.Now().Add(wsWriteWait)); err != nil {
+		return err
+	}
+	return c.conn.WriteJSON(message)
+}
@@ -0,0 +1,56 @@
+package clock
+
+import (
+	"sync"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+type clock struct{}
+
+var _ usecase.Clock = (*clock)(nil)
+
+// NewClock returns the wall clock.
+func NewClock() *clock {
+	return &clock{}
+}
+
+func (c *clock) Now() time.Time {
+	return time.Now()
+}
+
+// fake is a clock that only moves when told to, so tests can fast-forward through time.
+type fake struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+var _ usecase.Clock = (*fake)(nil)
+
+func NewFake(now time.Time) *fake {
+	return &fake{now: now}
+}
+
+func (f *fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.now
+}
+
+// Advance moves the clock forward by d.
+func (f *fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+}
+
+// Set moves the clock to now.
+func (f *fake) Set(now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = now
+}
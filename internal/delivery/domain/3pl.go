@@ -4,6 +4,9 @@ type ThirdPartyLogisticsRequestDeliveryGuyInput struct {
 	ShipmentUID             string
 	RoutingInfo             RoutingInfo
 	ScheduledDeliveryWindow ScheduledDeliveryWindow
+	ZoneID                  string
+	// Provider is the preferred third party logistics provider. Empty means the default one.
+	Provider string
 }
 
 type ThirdPartyLogisticsRequestDeliveryGuyResult struct{}
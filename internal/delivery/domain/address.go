@@ -0,0 +1,108 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string `json:"country,omitempty"`
+	// Notes are delivery instructions for the courier, they are not part of the address itself.
+	Notes string `json:"notes,omitempty"`
+}
+
+func (o *Address) Validate() error {
+	var v internal_error.Violations
+
+	if strings.TrimSpace(o.Street) == "" {
+		v.Add("street", internal_error.CodeRequired, "is required")
+	}
+
+	if o.Country != "" && !isCountryCode(o.Country) {
+		v.Add("country", internal_error.CodeInvalid, "must be an ISO 3166-1 alpha-2 code")
+	}
+
+	return v.Err()
+}
+
+// String formats the address on a single line, leaving notes out.
+func (o Address) String() string {
+	locality := strings.TrimSpace(o.PostalCode + " " + o.City)
+
+	parts := make([]string, 0, 3)
+	for _, part := range []string{o.Street, locality, o.Country} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// UnmarshalJSON accepts a structured address or, for older clients, a free text one kept as the street.
+func (o *Address) UnmarshalJSON(data []byte) error {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '"' {
+		var text string
+		if err := json.Unmarshal(trimmed, &text); err != nil {
+			return err
+		}
+		*o = Address{Street: text}
+		return nil
+	}
+
+	type address Address
+	return json.Unmarshal(data, (*address)(o))
+}
+
+func (o Address) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+func (o *Address) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*o = Address{}
+		return nil
+	case []byte:
+		return json.Unmarshal(src, o)
+	case string:
+		return json.Unmarshal([]byte(src), o)
+	default:
+		return fmt.Errorf("cannot scan %T into Address", src)
+	}
+}
+
+func isCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Geocode precisions, from the most to the least precise.
+const (
+	GeocodePrecisionStreet     = "street"
+	GeocodePrecisionPostalCode = "postal_code"
+	GeocodePrecisionCity       = "city"
+)
+
+// Geocode is where an address was resolved and how precisely: coarser matches only place it
+// somewhere in its postal code or city.
+type Geocode struct {
+	Location  Location
+	Precision string
+}
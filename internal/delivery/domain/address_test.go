@@ -0,0 +1,53 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+func TestAddressUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want domain.Address
+	}{
+		{"free text", `"12 Valiasr St"`, domain.Address{Street: "12 Valiasr St"}},
+		{
+			"structured",
+			`{"street":"12 Valiasr St","city":"Tehran","postal_code":"1591","country":"IR","notes":"ring twice"}`,
+			domain.Address{Street: "12 Valiasr St", City: "Tehran", PostalCode: "1591", Country: "IR", Notes: "ring twice"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got domain.Address
+			if err := json.Unmarshal([]byte(tt.data), &got); err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddressString(t *testing.T) {
+	address := domain.Address{Street: "12 Valiasr St", City: "Tehran", PostalCode: "1591", Country: "IR", Notes: "ring twice"}
+	if got, want := address.String(), "12 Valiasr St, 1591 Tehran, IR"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestAddressValidate(t *testing.T) {
+	if err := (&domain.Address{Street: "12 Valiasr St", Country: "IR"}).Validate(); err != nil {
+		t.Errorf("valid address: %v", err)
+	}
+	if err := (&domain.Address{Country: "IR"}).Validate(); err == nil {
+		t.Error("missing street must be rejected")
+	}
+	if err := (&domain.Address{Street: "12 Valiasr St", Country: "Iran"}).Validate(); err == nil {
+		t.Error("non ISO country must be rejected")
+	}
+}
@@ -0,0 +1,154 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// shipmentTransitions are the statuses each status can move to. The webhooks of the 3pl are not
+// held to them since they report what already happened, they bound the transitions operators force.
+// requested is left out: it is entered by requesting a delivery guy, never by a status change alone.
+// The 3pl cannot be told to stop: shipments it still works on, requested to found, are not cancelled
+// until it gave up on them.
+var shipmentTransitions = map[string][]string{
+	"queued":          {"pending", "cancelled"},
+	"pending":         {"queued", "cancelled"},
+	"requested":       {"pending", "searching", "found", "not_found"},
+	"searching":       {"pending", "found", "not_found"},
+	"found":           {"searching", "not_found", "picked_up"},
+	"not_found":       {"pending", "searching", "found", "cancelled"},
+	"picked_up":       {"in_transit", "delivered", "delivery_failed"},
+	"in_transit":      {"delivered", "delivery_failed"},
+	"delivery_failed": {"in_transit", "returned"},
+	"delivered":       {},
+	"returned":        {},
+	"cancelled":       {},
+}
+
+// CanTransition reports whether a shipment in status from can be moved to status to.
+func CanTransition(from, to string) bool {
+	return slices.Contains(shipmentTransitions[from], to)
+}
+
+// Transitions returns the statuses a shipment in status can be moved to, none once it is final.
+func Transitions(status string) []string {
+	return slices.Clone(shipmentTransitions[status])
+}
+
+// ShipmentStatusChange is a status a shipment entered and when.
+type ShipmentStatusChange struct {
+	Status string    `json:"status"`
+	At     time.Time `json:"at"`
+}
+
+// FailedCoreWebhook is a core webhook that failed, kept to be replayed. Failures of the same
+// shipment and status are counted in Attempts.
+type FailedCoreWebhook struct {
+	CoreWebhookInput
+	Error         string    `json:"error"`
+	Attempts      int       `json:"attempts"`
+	FirstFailedAt time.Time `json:"first_failed_at"`
+	LastFailedAt  time.Time `json:"last_failed_at"`
+}
+
+type HistoryInput struct {
+	ShipmentUID string `json:"shipment_uid"`
+}
+
+func (o *HistoryInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.ShipmentUID == "" {
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
+	}
+
+	return v.Err()
+}
+
+// HistoryResult is a shipment and the statuses it went through, oldest first.
+type HistoryResult struct {
+	Shipment Shipment               `json:"shipment"`
+	History  []ShipmentStatusChange `json:"history"`
+}
+
+type TransitionInput struct {
+	ShipmentUID string `json:"shipment_uid"`
+	Status      string `json:"status"`
+}
+
+func (o *TransitionInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.ShipmentUID == "" {
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
+	}
+
+	switch {
+	case o.Status == "":
+		v.Add("status", internal_error.CodeRequired, "is required")
+	case !slices.Contains(ShipmentStatuses, o.Status):
+		v.Add("status", internal_error.CodeInvalid, "must be one of "+strings.Join(ShipmentStatuses, ", "))
+	}
+
+	return v.Err()
+}
+
+type TransitionResult struct {
+	Shipment Shipment `json:"shipment"`
+}
+
+type RedispatchInput struct {
+	ShipmentUID string `json:"shipment_uid"`
+}
+
+func (o *RedispatchInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.ShipmentUID == "" {
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
+	}
+
+	return v.Err()
+}
+
+type RedispatchResult struct {
+	Shipment Shipment `json:"shipment"`
+}
+
+const DefaultReplayLimit = 100
+
+type ReplayCoreWebhooksInput struct {
+	// Limit bounds the webhooks replayed, oldest failure first. DefaultReplayLimit when left out.
+	Limit int `json:"limit"`
+}
+
+func (o *ReplayCoreWebhooksInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.Limit < 0 {
+		v.Add("limit", internal_error.CodeOutOfRange, "must not be negative")
+	}
+
+	return v.Err()
+}
+
+type ReplayCoreWebhooksResult struct {
+	Replayed int `json:"replayed"`
+	// Failed are the webhooks failing again, still kept to be replayed.
+	Failed []FailedCoreWebhook `json:"failed"`
+}
+
+type StatsInput struct{}
+
+type StatsResult struct {
+	// ShipmentsByStatus counts the shipments of every status, zero for the ones without any.
+	ShipmentsByStatus map[string]int `json:"shipments_by_status"`
+	// FailedDispatches counts the pending shipments the 3pl could not be requested for, waiting
+	// for the shipping worker to try again.
+	FailedDispatches int `json:"failed_dispatches"`
+	// FailedCoreWebhooks counts the core webhooks waiting to be replayed.
+	FailedCoreWebhooks int `json:"failed_core_webhooks"`
+}
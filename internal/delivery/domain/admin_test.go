@@ -0,0 +1,38 @@
+package domain_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+func TestTransitions(t *testing.T) {
+	for _, from := range domain.ShipmentStatuses {
+		for _, to := range domain.Transitions(from) {
+			if !slices.Contains(domain.ShipmentStatuses, to) {
+				t.Errorf("%s moves to unknown status %s", from, to)
+			}
+			if to == from || to == "requested" {
+				t.Errorf("%s can be forced into %s", from, to)
+			}
+		}
+	}
+
+	for _, final := range []string{"delivered", "returned", "cancelled"} {
+		if next := domain.Transitions(final); len(next) != 0 {
+			t.Errorf("final status %s moves to %v", final, next)
+		}
+	}
+
+	// every status but the final ones and requested, entered by requesting a delivery guy, is reachable
+	for _, to := range domain.ShipmentStatuses {
+		reachable := false
+		for _, from := range domain.ShipmentStatuses {
+			reachable = reachable || domain.CanTransition(from, to)
+		}
+		if want := to != "requested"; reachable != want {
+			t.Errorf("%s reachable = %v, want %v", to, reachable, want)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package domain
 
 type CoreWebhookInput struct {
+	Version     int    `json:"version"`
 	ShipmentUID string `json:"shipment_uid"`
 	Status      string `json:"status"`
+	Tracking
 }
 
 type CoreWebhookResult struct{}
@@ -1,162 +1,314 @@
 package domain
 
 import (
-	"database/sql/driver"
-	"errors"
 	"fmt"
-	"strconv"
+	"slices"
 	"strings"
 	"time"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
 )
 
 type UserInfo struct {
-	UserUID string `json:"user_uid"`
-	Address string `json:"address"`
+	UserUID string  `json:"user_uid"`
+	Address Address `json:"address"`
 }
 
 func (o *UserInfo) Validate() error {
+	var v internal_error.Violations
+
 	if o.UserUID == "" {
-		return errors.New(".user_uid is required")
+		v.Add("user_uid", internal_error.CodeRequired, "is required")
 	}
 
-	if o.Address == "" {
-		return errors.New(".address is required")
-	}
+	v.Merge("address", o.Address.Validate())
 
-	return nil
+	return v.Err()
 }
 
-type Location struct {
-	Lat  float64 `json:"lat"`
-	Long float64 `json:"long"`
+type RoutingInfo struct {
+	Origin      Location `json:"origin"`
+	Destination Location `json:"destination"`
 }
 
-func (o *Location) Validate() error {
-	if o.Lat < -90 || o.Lat > 90 {
-		return errors.New(".lat must be between -90 and 90")
-	}
+func (o *RoutingInfo) Validate() error {
+	var v internal_error.Violations
 
-	if o.Long < -180 || o.Long > 180 {
-		return errors.New(".long must be between -180 and 180")
-	}
+	v.Merge("origin", o.Origin.Validate())
+	v.Merge("destination", o.Destination.Validate())
 
-	return nil
+	return v.Err()
 }
 
-func (l *Location) Scan(value interface{}) error {
-	var data []byte
-	switch v := value.(type) {
-	case []byte:
-		data = v
-	case string:
-		data = []byte(v)
-	default:
-		return fmt.Errorf("unsupported type: %T", value)
-	}
+type ScheduledDeliveryWindow struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+// Validate only checks the window is well formed.
+// Business rules (durations, operating hours, lead time...) are enforced by WindowPolicy.
+func (o *ScheduledDeliveryWindow) Validate() error {
+	var v internal_error.Violations
 
-	str := strings.Trim(string(data), "()")
-	parts := strings.Split(str, ",")
-	if len(parts) != 2 {
-		return fmt.Errorf("invalid point format: %s", str)
+	if o.StartTime.IsZero() {
+		v.Add("start_time", internal_error.CodeRequired, "is required")
 	}
 
-	x, err := strconv.ParseFloat(parts[0], 64)
-	if err != nil {
-		return err
+	if o.EndTime.IsZero() {
+		v.Add("end_time", internal_error.CodeRequired, "is required")
 	}
 
-	y, err := strconv.ParseFloat(parts[1], 64)
-	if err != nil {
-		return err
+	if !o.EndTime.After(o.StartTime) {
+		v.Add("end_time", internal_error.CodeInvalid, "must be after start_time")
 	}
 
-	l.Long = x
-	l.Lat = y
-	return nil
+	return v.Err()
 }
 
-func (l Location) Value() (driver.Value, error) {
-	return fmt.Sprintf("(%f,%f)", l.Long, l.Lat), nil
+func (o *ScheduledDeliveryWindow) Duration() time.Duration {
+	return o.EndTime.Sub(o.StartTime)
 }
 
-type RoutingInfo struct {
-	Origin      Location `json:"origin"`
-	Destination Location `json:"destination"`
+// Hours splits the window into the hour long buckets slot capacity is held in: the whole hours of
+// loc it overlaps, from the one it starts in to the one it ends in. Overlapping windows share the
+// buckets of the hours they have in common, whether or not they start on the hour.
+func (o *ScheduledDeliveryWindow) Hours(loc *time.Location) []ScheduledDeliveryWindow {
+	var hours []ScheduledDeliveryWindow
+	for start := truncateToHour(o.StartTime.In(loc)); start.Before(o.EndTime); start = start.Add(time.Hour) {
+		hours = append(hours, ScheduledDeliveryWindow{StartTime: start, EndTime: start.Add(time.Hour)})
+	}
+	return hours
 }
 
-func (o *RoutingInfo) Validate() error {
-	if err := o.Origin.Validate(); err != nil {
-		return fmt.Errorf(".origin%s", err)
-	}
+type RequestInput struct {
+	ShipmentUID             string                  `json:"shipment_uid"`
+	UserInfo                UserInfo                `json:"user_info"`
+	RoutingInfo             RoutingInfo             `json:"routing_info"`
+	ScheduledDeliveryWindow ScheduledDeliveryWindow `json:"scheduled_delivery_window"`
+	// QuoteToken books the shipment with the price of a previously issued quote.
+	QuoteToken string `json:"quote_token,omitempty"`
+}
+
+func (o *RequestInput) Validate() error {
+	var v internal_error.Violations
 
-	if err := o.Destination.Validate(); err != nil {
-		return fmt.Errorf(".destination%s", err)
+	if o.ShipmentUID == "" {
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
 	}
 
-	return nil
+	v.Merge("user_info", o.UserInfo.Validate())
+	v.Merge("routing_info", o.RoutingInfo.Validate())
+	v.Merge("scheduled_delivery_window", o.ScheduledDeliveryWindow.Validate())
+
+	return v.Err()
 }
 
-type ScheduledDeliveryWindow struct {
+type RequestResult struct{}
+
+const (
+	BatchItemStatusCreated   = "created"
+	BatchItemStatusDuplicate = "duplicate"
+	BatchItemStatusInvalid   = "invalid"
+)
+
+type BatchRequestInput struct {
+	Items []RequestInput `json:"items"`
+}
+
+type BatchRequestItemResult struct {
+	ShipmentUID string                          `json:"shipment_uid"`
+	Status      string                          `json:"status"`
+	Reason      string                          `json:"reason,omitempty"`
+	Violations  []internal_error.FieldViolation `json:"violations,omitempty"`
+}
+
+type BatchRequestResult struct {
+	Results []BatchRequestItemResult `json:"results"`
+}
+
+type SlotsInput struct {
+	ZoneID string
+	From   time.Time
+	To     time.Time
+}
+
+// Slot is a bookable window. Capacity and Remaining are omitted when slots are unlimited.
+type Slot struct {
 	StartTime time.Time `json:"start_time"`
 	EndTime   time.Time `json:"end_time"`
+	Capacity  *int      `json:"capacity,omitempty"`
+	Remaining *int      `json:"remaining,omitempty"`
 }
 
-func (o *ScheduledDeliveryWindow) Validate() error {
-	if o.EndTime.Before(time.Now()) {
-		return errors.New(".end_time expired")
+type SlotsResult struct {
+	Slots []Slot `json:"slots"`
+}
+
+const (
+	MaxNearbyRadiusMeters = 100_000
+	MaxNearbyLimit        = 1000
+)
+
+type NearbyInput struct {
+	Center       Location
+	RadiusMeters float64
+	Status       string
+	Limit        int
+}
+
+func (o *NearbyInput) Validate() error {
+	var v internal_error.Violations
+
+	v.Merge("", o.Center.Validate())
+
+	if o.RadiusMeters <= 0 || o.RadiusMeters > MaxNearbyRadiusMeters {
+		v.Add("radius_meters", internal_error.CodeOutOfRange, fmt.Sprintf("must be greater than 0 and at most %d", MaxNearbyRadiusMeters))
 	}
 
-	if o.EndTime.Sub(o.StartTime) != 2*time.Hour {
-		return errors.New(" must be 2 hours")
+	if o.Limit < 0 || o.Limit > MaxNearbyLimit {
+		v.Add("limit", internal_error.CodeOutOfRange, fmt.Sprintf("must be between 0 and %d", MaxNearbyLimit))
 	}
 
-	return nil
+	return v.Err()
 }
 
-type RequestInput struct {
-	ShipmentUID             string                  `json:"shipment_uid"`
-	UserInfo                UserInfo                `json:"user_info"`
-	RoutingInfo             RoutingInfo             `json:"routing_info"`
-	ScheduledDeliveryWindow ScheduledDeliveryWindow `json:"scheduled_delivery_window"`
+type NearbyResult struct {
+	Shipments []Shipment `json:"shipments"`
 }
 
-func (o *RequestInput) Validate() error {
+type GetInput struct {
+	ShipmentUID string `json:"shipment_uid"`
+}
+
+func (o *GetInput) Validate() error {
+	var v internal_error.Violations
+
 	if o.ShipmentUID == "" {
-		return errors.New("shipment_uid is required")
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
 	}
 
-	if err := o.UserInfo.Validate(); err != nil {
-		return fmt.Errorf("user_info%s", err)
-	}
+	return v.Err()
+}
+
+type GetResult struct {
+	Shipment Shipment `json:"shipment"`
+}
+
+const (
+	DefaultListPageSize = 50
+	MaxListPageSize     = 500
+)
+
+type ListInput struct {
+	ShipmentFilter
+	// PageSize is DefaultListPageSize when left out.
+	PageSize int `json:"page_size"`
+	// PageToken continues a listing from the NextPageToken of its previous page.
+	PageToken string `json:"page_token"`
+}
 
-	if err := o.RoutingInfo.Validate(); err != nil {
-		return fmt.Errorf("routing_info%s", err)
+func (o *ListInput) Validate() error {
+	var v internal_error.Violations
+
+	v.Merge("", o.ShipmentFilter.Validate())
+
+	if o.PageSize < 0 || o.PageSize > MaxListPageSize {
+		v.Add("page_size", internal_error.CodeOutOfRange, fmt.Sprintf("must be between 0 and %d", MaxListPageSize))
 	}
 
-	if err := o.ScheduledDeliveryWindow.Validate(); err != nil {
-		return fmt.Errorf("scheduled_delivery_window%s", err)
+	return v.Err()
+}
+
+// ListResult is a page of shipments in uid order.
+type ListResult struct {
+	Shipments []Shipment `json:"shipments"`
+	// NextPageToken is empty on the last page.
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+type CancelInput struct {
+	ShipmentUID string `json:"shipment_uid"`
+}
+
+func (o *CancelInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.ShipmentUID == "" {
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
 	}
 
-	return nil
+	return v.Err()
 }
 
-type RequestResult struct{}
+type CancelResult struct{}
+
+// WebhookStatuses are the statuses a 3pl reports, in lifecycle order. Failed deliveries are
+// brought back to the origin: delivery_failed then returned.
+var WebhookStatuses = []string{"searching", "found", "not_found", "picked_up", "in_transit", "delivered", "delivery_failed", "returned"}
+
+// ShipmentStatuses are every status a shipment goes through, in lifecycle order.
+var ShipmentStatuses = slices.Concat([]string{"queued", "pending", "requested"}, WebhookStatuses, []string{"cancelled"})
+
+// webhookFromStatuses are the statuses a shipment can be in when the 3pl reports each status. A
+// report may skip statuses whose webhooks were lost but never goes back: shipments already past the
+// reported status, or in a final one, got it late or twice.
+var webhookFromStatuses = map[string][]string{
+	"searching":       {"requested", "not_found"},
+	"found":           {"requested", "searching", "not_found"},
+	"not_found":       {"requested", "searching"},
+	"picked_up":       {"requested", "searching", "found", "not_found"},
+	"in_transit":      {"requested", "searching", "found", "not_found", "picked_up"},
+	"delivered":       {"requested", "searching", "found", "not_found", "picked_up", "in_transit"},
+	"delivery_failed": {"requested", "searching", "found", "not_found", "picked_up", "in_transit"},
+	"returned":        {"requested", "searching", "found", "not_found", "picked_up", "in_transit", "delivery_failed"},
+}
+
+// WebhookFromStatuses returns the statuses a webhook reporting status moves a shipment on from.
+// Status itself is left out: reported again, it only carries tracking changes.
+func WebhookFromStatuses(status string) []string {
+	return slices.Clone(webhookFromStatuses[status])
+}
 
 type WebhookInput struct {
+	// Version of the payload, 1 when left out. Tracking is only read from version 2 on.
+	Version     int    `json:"version"`
 	ShipmentUID string `json:"shipment_uid"`
 	Status      string `json:"status"`
+	Tracking
 }
 
 func (o *WebhookInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.Version < 0 || o.Version > WebhookVersion {
+		v.Add("version", internal_error.CodeOutOfRange, fmt.Sprintf("must be between 1 and %d", WebhookVersion))
+	}
+
 	if o.ShipmentUID == "" {
-		return errors.New("shipment_uid is required")
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
 	}
 
-	if o.Status == "" {
-		return errors.New("status is required")
+	switch {
+	case o.Status == "":
+		v.Add("status", internal_error.CodeRequired, "is required")
+	case o.Status == "shipped" && o.Version <= 1:
+	case !slices.Contains(WebhookStatuses, o.Status):
+		v.Add("status", internal_error.CodeInvalid, "must be one of "+strings.Join(WebhookStatuses, ", "))
 	}
 
-	return nil
+	if o.Version >= 2 {
+		o.Tracking.validate(&v, o.Status)
+	}
+
+	return v.Err()
+}
+
+// DeliveryStatus returns the status to record: version 1 providers report delivered shipments as shipped.
+func (o *WebhookInput) DeliveryStatus() string {
+	if o.Status == "shipped" {
+		return "delivered"
+	}
+	return o.Status
 }
 
 type WebhookResult struct{}
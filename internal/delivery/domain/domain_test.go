@@ -0,0 +1,43 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+func TestNearbyInputValidate(t *testing.T) {
+	center := domain.Location{Lat: 35.7, Long: 51.4}
+
+	for _, tt := range []struct {
+		name  string
+		input domain.NearbyInput
+		// field is the invalid field, none when empty
+		field string
+	}{
+		{"valid", domain.NearbyInput{Center: center, RadiusMeters: 500}, ""},
+		{"max radius", domain.NearbyInput{Center: center, RadiusMeters: domain.MaxNearbyRadiusMeters}, ""},
+		{"zero radius", domain.NearbyInput{Center: center}, "radius_meters"},
+		{"negative radius", domain.NearbyInput{Center: center, RadiusMeters: -1}, "radius_meters"},
+		{"radius too large", domain.NearbyInput{Center: center, RadiusMeters: domain.MaxNearbyRadiusMeters + 1}, "radius_meters"},
+		{"limit too large", domain.NearbyInput{Center: center, RadiusMeters: 500, Limit: domain.MaxNearbyLimit + 1}, "limit"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validate: %v, want no error", err)
+				}
+				return
+			}
+
+			var validationErr internal_error.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Violations[0].Field != tt.field {
+				t.Errorf("validate: %v, want a violation of %q", err, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// ShipmentEvent is the state of a shipment after it changed, streamed to the clients tracking it:
+// a new status or the tracking the 3pl reported along, e.g. a courier location update.
+type ShipmentEvent struct {
+	ShipmentUID string `json:"shipment_uid"`
+	// ZoneID is left out for shipments outside of any zone.
+	ZoneID   string    `json:"zone_id,omitempty"`
+	Status   string    `json:"status"`
+	Tracking *Tracking `json:"tracking,omitempty"`
+	// At is when the shipment was in this state.
+	At time.Time `json:"at"`
+}
+
+// NewShipmentEvent returns the event of shipment as it is at at.
+func NewShipmentEvent(shipment *Shipment, at time.Time) ShipmentEvent {
+	event := ShipmentEvent{
+		ShipmentUID: shipment.UID,
+		ZoneID:      shipment.ZoneID,
+		Status:      shipment.Status,
+		At:          at,
+	}
+
+	if !shipment.Tracking.IsZero() {
+		tracking := shipment.Tracking
+		event.Tracking = &tracking
+	}
+
+	return event
+}
+
+type SubscribeInput struct {
+	ShipmentUID string `json:"shipment_uid"`
+}
+
+func (o *SubscribeInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.ShipmentUID == "" {
+		v.Add("shipment_uid", internal_error.CodeRequired, "is required")
+	}
+
+	return v.Err()
+}
+
+type SubscribeResult struct {
+	// Current is the shipment when the subscription started, Events follow from there.
+	Current ShipmentEvent
+	// Events is closed once the subscription context is done or the subscriber fell too far
+	// behind, in which case it should subscribe again and start over from Current.
+	Events <-chan ShipmentEvent
+}
+
+// maxFilteredShipments bounds the shipment uids a single filter can list.
+const maxFilteredShipments = 1000
+
+// ShipmentFilter matches shipments by uid, zone and status. A shipment matches when it is in every
+// non empty list, an empty filter matches every shipment.
+type ShipmentFilter struct {
+	ShipmentUIDs []string `json:"shipment_uids,omitempty"`
+	ZoneIDs      []string `json:"zone_ids,omitempty"`
+	Statuses     []string `json:"statuses,omitempty"`
+}
+
+func (o *ShipmentFilter) IsZero() bool {
+	return len(o.ShipmentUIDs) == 0 && len(o.ZoneIDs) == 0 && len(o.Statuses) == 0
+}
+
+func (o *ShipmentFilter) Validate() error {
+	var v internal_error.Violations
+
+	if len(o.ShipmentUIDs) > maxFilteredShipments {
+		v.Add("shipment_uids", internal_error.CodeTooMany, fmt.Sprintf("must contain at most %d entries", maxFilteredShipments))
+	}
+
+	for _, status := range o.Statuses {
+		if !slices.Contains(ShipmentStatuses, status) {
+			v.Add("statuses", internal_error.CodeInvalid, "must only contain "+strings.Join(ShipmentStatuses, ", "))
+			break
+		}
+	}
+
+	return v.Err()
+}
+
+func (o *ShipmentFilter) Matches(shipmentUID, zoneID, status string) bool {
+	return (len(o.ShipmentUIDs) == 0 || slices.Contains(o.ShipmentUIDs, shipmentUID)) &&
+		(len(o.ZoneIDs) == 0 || slices.Contains(o.ZoneIDs, zoneID)) &&
+		(len(o.Statuses) == 0 || slices.Contains(o.Statuses, status))
+}
+
+// MatchesEvent reports whether the state event moves its shipment to matches. Status filters
+// do not see shipments leave: a shipment moving out of a watched status is not matched anymore,
+// see ShipmentWatch for that.
+func (o *ShipmentFilter) MatchesEvent(event *ShipmentEvent) bool {
+	return o.Matches(event.ShipmentUID, event.ZoneID, event.Status)
+}
+
+// ShipmentWatch matches the events of a filter along with the ones moving a matching shipment out
+// of it, so watching a status also shows shipments leaving it.
+type ShipmentWatch struct {
+	filter ShipmentFilter
+
+	mu sync.Mutex
+	// matching are the shipments the last event or the snapshot left matching the filter.
+	matching map[string]struct{}
+	// changed are the shipments with events before the snapshot was taken in, nil after.
+	changed map[string]struct{}
+}
+
+func NewShipmentWatch(filter ShipmentFilter) *ShipmentWatch {
+	return &ShipmentWatch{
+		filter:   filter,
+		matching: make(map[string]struct{}),
+		changed:  make(map[string]struct{}),
+	}
+}
+
+// Start takes in the snapshot the watch starts from. Events may have come since it was listed:
+// shipments with events are left as their events put them.
+func (w *ShipmentWatch) Start(current []ShipmentEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for _, event := range current {
+		if _, ok := w.changed[event.ShipmentUID]; !ok {
+			w.matching[event.ShipmentUID] = struct{}{}
+		}
+	}
+	w.changed = nil
+}
+
+// Matches reports whether event matches the filter or moves a matching shipment out of it.
+func (w *ShipmentWatch) Matches(event *ShipmentEvent) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.changed != nil {
+		w.changed[event.ShipmentUID] = struct{}{}
+	}
+
+	if w.filter.MatchesEvent(event) {
+		w.matching[event.ShipmentUID] = struct{}{}
+		return true
+	}
+
+	if _, ok := w.matching[event.ShipmentUID]; ok {
+		delete(w.matching, event.ShipmentUID)
+		return true
+	}
+
+	return false
+}
+
+type WatchInput struct {
+	ShipmentFilter
+}
+
+func (o *WatchInput) Validate() error {
+	var v internal_error.Violations
+
+	if o.ShipmentFilter.IsZero() {
+		v.Add("shipment_uids", internal_error.CodeRequired, "is required unless zone_ids or statuses are given")
+	}
+
+	v.Merge("", o.ShipmentFilter.Validate())
+
+	return v.Err()
+}
+
+type WatchResult struct {
+	// Current is the state of the matching shipments when watching started, Events follow from there.
+	Current []ShipmentEvent
+	// Events carries the changes of the matching shipments, the ones moving them out of the filter
+	// included. It is closed once the watch context is done or the watcher fell too far behind, in
+	// which case it should watch again and start over from Current.
+	Events <-chan ShipmentEvent
+}
@@ -0,0 +1,94 @@
+package domain_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+func TestShipmentFilterMatchesEvent(t *testing.T) {
+	event := &domain.ShipmentEvent{ShipmentUID: "shipment", ZoneID: "north", Status: "found"}
+
+	for _, tt := range []struct {
+		name    string
+		filter  domain.ShipmentFilter
+		matches bool
+	}{
+		{"empty", domain.ShipmentFilter{}, true},
+		{"uid", domain.ShipmentFilter{ShipmentUIDs: []string{"other", "shipment"}}, true},
+		{"other uid", domain.ShipmentFilter{ShipmentUIDs: []string{"other"}}, false},
+		{"zone and status", domain.ShipmentFilter{ZoneIDs: []string{"north"}, Statuses: []string{"searching", "found"}}, true},
+		{"zone but not status", domain.ShipmentFilter{ZoneIDs: []string{"north"}, Statuses: []string{"delivered"}}, false},
+		{"other zone", domain.ShipmentFilter{ZoneIDs: []string{"south"}}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if matches := tt.filter.MatchesEvent(event); matches != tt.matches {
+				t.Errorf("matches = %v, want %v", matches, tt.matches)
+			}
+		})
+	}
+}
+
+func TestShipmentWatch(t *testing.T) {
+	watch := domain.NewShipmentWatch(domain.ShipmentFilter{Statuses: []string{"searching", "found"}})
+
+	// moved while the snapshot was listed: its event is newer than the snapshot
+	if watch.Matches(&domain.ShipmentEvent{ShipmentUID: "moved", Status: "picked_up"}) {
+		t.Error("matched a shipment outside of the filter")
+	}
+
+	watch.Start([]domain.ShipmentEvent{
+		{ShipmentUID: "searching", Status: "searching"},
+		{ShipmentUID: "moved", Status: "found"},
+	})
+
+	for _, tt := range []struct {
+		event   domain.ShipmentEvent
+		matches bool
+	}{
+		{domain.ShipmentEvent{ShipmentUID: "searching", Status: "found"}, true},
+		// leaving the filter is matched once
+		{domain.ShipmentEvent{ShipmentUID: "searching", Status: "picked_up"}, true},
+		{domain.ShipmentEvent{ShipmentUID: "searching", Status: "in_transit"}, false},
+		{domain.ShipmentEvent{ShipmentUID: "moved", Status: "in_transit"}, false},
+		{domain.ShipmentEvent{ShipmentUID: "new", Status: "searching"}, true},
+		{domain.ShipmentEvent{ShipmentUID: "new", Status: "cancelled"}, true},
+		{domain.ShipmentEvent{ShipmentUID: "other", Status: "delivered"}, false},
+	} {
+		if matches := watch.Matches(&tt.event); matches != tt.matches {
+			t.Errorf("%s to %s matches = %v, want %v", tt.event.ShipmentUID, tt.event.Status, matches, tt.matches)
+		}
+	}
+}
+
+func TestWatchInputValidate(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		input domain.WatchInput
+		// field is the invalid field, none when empty
+		field string
+	}{
+		{"statuses", domain.WatchInput{ShipmentFilter: domain.ShipmentFilter{Statuses: []string{"queued", "cancelled"}}}, ""},
+		{"empty", domain.WatchInput{}, "shipment_uids"},
+		{"unknown status", domain.WatchInput{ShipmentFilter: domain.ShipmentFilter{Statuses: []string{"lost"}}}, "statuses"},
+		{"too many uids", domain.WatchInput{ShipmentFilter: domain.ShipmentFilter{ShipmentUIDs: make([]string, 1001)}}, "shipment_uids"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.input.Validate()
+
+			if tt.field == "" {
+				if err != nil {
+					t.Errorf("validate: %v, want no error", err)
+				}
+				return
+			}
+
+			var validationErr internal_error.ValidationError
+			if !errors.As(err, &validationErr) || validationErr.Violations[0].Field != tt.field {
+				t.Errorf("validate: %v, want a violation of %q", err, tt.field)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package domain
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// Location is a WGS84 coordinate.
+//
+// Its canonical encoding everywhere outside of json is (x, y) = (long, lat):
+// the postgres POINT columns, GeoJSON coordinates, WKT and WKB all put the longitude first.
+type Location struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+func (o *Location) Validate() error {
+	var v internal_error.Violations
+
+	if o.Lat < -90 || o.Lat > 90 {
+		v.Add("lat", internal_error.CodeOutOfRange, "must be between -90 and 90")
+	}
+
+	if o.Long < -180 || o.Long > 180 {
+		v.Add("long", internal_error.CodeOutOfRange, "must be between -180 and 180")
+	}
+
+	return v.Err()
+}
+
+// Scan reads a postgres POINT "(long,lat)". WKT and hex encoded (E)WKB, as returned by
+// postgis geometry columns, are accepted too.
+func (l *Location) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported type: %T", value)
+	}
+
+	str := strings.TrimSpace(string(data))
+	switch {
+	case strings.HasPrefix(str, "("):
+	case strings.HasPrefix(strings.ToUpper(str), "POINT"), strings.HasPrefix(strings.ToUpper(str), "SRID="):
+		location, err := ParseWKT(str)
+		if err != nil {
+			return err
+		}
+		*l = location
+		return nil
+	default:
+		wkb, err := hex.DecodeString(str)
+		if err != nil {
+			return fmt.Errorf("invalid point format: %s", str)
+		}
+		location, err := ParseWKB(wkb)
+		if err != nil {
+			return err
+		}
+		*l = location
+		return nil
+	}
+
+	str = strings.Trim(str, "()")
+	parts := strings.Split(str, ",")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid point format: %s", str)
+	}
+
+	x, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil {
+		return err
+	}
+
+	y, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil {
+		return err
+	}
+
+	l.Long = x
+	l.Lat = y
+	return nil
+}
+
+// Value writes a postgres POINT "(long,lat)" keeping every digit of the coordinates.
+func (l Location) Value() (driver.Value, error) {
+	return "(" + formatCoordinate(l.Long) + "," + formatCoordinate(l.Lat) + ")", nil
+}
+
+// MarshalJSON writes both the {lat, long} members and a GeoJSON Point, so the same
+// object can be consumed by existing clients and by GeoJSON readers (which ignore foreign members).
+func (l Location) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Lat         float64    `json:"lat"`
+		Long        float64    `json:"long"`
+		Type        string     `json:"type"`
+		Coordinates [2]float64 `json:"coordinates"`
+	}{
+		Lat:         l.Lat,
+		Long:        l.Long,
+		Type:        "Point",
+		Coordinates: [2]float64{l.Long, l.Lat},
+	})
+}
+
+// UnmarshalJSON accepts either {"lat": .., "long": ..} or a GeoJSON Point {"type": "Point", "coordinates": [long, lat]}.
+func (l *Location) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Lat         float64   `json:"lat"`
+		Long        float64   `json:"long"`
+		Type        string    `json:"type"`
+		Coordinates []float64 `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Type == "" && raw.Coordinates == nil {
+		l.Lat = raw.Lat
+		l.Long = raw.Long
+		return nil
+	}
+
+	if raw.Type != "Point" {
+		return fmt.Errorf("unsupported geojson type %q: only Point is supported", raw.Type)
+	}
+
+	// a third (altitude) position element is allowed by GeoJSON and ignored
+	if len(raw.Coordinates) < 2 || len(raw.Coordinates) > 3 {
+		return errors.New("geojson Point coordinates must be [long, lat]")
+	}
+
+	l.Long = raw.Coordinates[0]
+	l.Lat = raw.Coordinates[1]
+	return nil
+}
+
+// WKT returns the well-known text representation "POINT(long lat)".
+func (l Location) WKT() string {
+	return "POINT(" + formatCoordinate(l.Long) + " " + formatCoordinate(l.Lat) + ")"
+}
+
+// ParseWKT parses a WKT point, optionally prefixed with an EWKT "SRID=4326;".
+func ParseWKT(wkt string) (Location, error) {
+	str := strings.TrimSpace(wkt)
+	if strings.HasPrefix(strings.ToUpper(str), "SRID=") {
+		_, rest, ok := strings.Cut(str, ";")
+		if !ok {
+			return Location{}, fmt.Errorf("invalid ewkt: %s", wkt)
+		}
+		str = strings.TrimSpace(rest)
+	}
+
+	upper := strings.ToUpper(str)
+	if !strings.HasPrefix(upper, "POINT") {
+		return Location{}, fmt.Errorf("invalid wkt point: %s", wkt)
+	}
+
+	str = strings.TrimSpace(str[len("POINT"):])
+	if !strings.HasPrefix(str, "(") || !strings.HasSuffix(str, ")") {
+		return Location{}, fmt.Errorf("invalid wkt point: %s", wkt)
+	}
+
+	fields := strings.Fields(str[1 : len(str)-1])
+	if len(fields) != 2 {
+		return Location{}, fmt.Errorf("invalid wkt point: %s", wkt)
+	}
+
+	x, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Location{}, err
+	}
+
+	y, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return Location{}, err
+	}
+
+	return Location{Lat: y, Long: x}, nil
+}
+
+const (
+	wkbPointType = 1
+	wkbSRIDFlag  = 0x20000000
+	wkbZFlag     = 0x80000000
+	wkbMFlag     = 0x40000000
+)
+
+// WKB returns the little endian well-known binary representation of the point.
+func (l Location) WKB() []byte {
+	buf := make([]byte, 21)
+	buf[0] = 1 // little endian
+	binary.LittleEndian.PutUint32(buf[1:], wkbPointType)
+	binary.LittleEndian.PutUint64(buf[5:], math.Float64bits(l.Long))
+	binary.LittleEndian.PutUint64(buf[13:], math.Float64bits(l.Lat))
+	return buf
+}
+
+// ParseWKB parses a WKB point of either byte order. Postgis EWKB (with an SRID) is accepted too.
+func ParseWKB(wkb []byte) (Location, error) {
+	if len(wkb) < 5 {
+		return Location{}, errors.New("invalid wkb: too short")
+	}
+
+	var order binary.ByteOrder
+	switch wkb[0] {
+	case 0:
+		order = binary.BigEndian
+	case 1:
+		order = binary.LittleEndian
+	default:
+		return Location{}, fmt.Errorf("invalid wkb byte order: %d", wkb[0])
+	}
+
+	r := bytes.NewReader(wkb[1:])
+
+	var geometryType uint32
+	if err := binary.Read(r, order, &geometryType); err != nil {
+		return Location{}, err
+	}
+
+	if geometryType&wkbSRIDFlag != 0 {
+		var srid uint32
+		if err := binary.Read(r, order, &srid); err != nil {
+			return Location{}, err
+		}
+	}
+
+	if geometryType&(wkbZFlag|wkbMFlag) != 0 || geometryType&^wkbSRIDFlag != wkbPointType {
+		return Location{}, fmt.Errorf("unsupported wkb geometry type: %d", geometryType)
+	}
+
+	var coordinates [2]float64
+	if err := binary.Read(r, order, &coordinates); err != nil {
+		return Location{}, err
+	}
+
+	return Location{Lat: coordinates[1], Long: coordinates[0]}, nil
+}
+
+func formatCoordinate(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
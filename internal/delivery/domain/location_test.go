@@ -0,0 +1,163 @@
+package domain_test
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+var tehran = domain.Location{Lat: 35.689197, Long: 51.388974}
+
+func TestLocationValueScanRoundTrip(t *testing.T) {
+	for _, location := range []domain.Location{
+		tehran,
+		{Lat: -33.868820000000001, Long: 151.20929300000001},
+		{Lat: 0.1 + 0.2, Long: -179.99999999999997},
+		{},
+	} {
+		value, err := location.Value()
+		if err != nil {
+			t.Fatalf("Value(%v): %v", location, err)
+		}
+
+		var scanned domain.Location
+		if err := scanned.Scan(value); err != nil {
+			t.Fatalf("Scan(%v): %v", value, err)
+		}
+
+		if scanned != location {
+			t.Errorf("round trip of %v returned %v", location, scanned)
+		}
+	}
+}
+
+func TestLocationValueIsLongLat(t *testing.T) {
+	value, err := tehran.Value()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "(51.388974,35.689197)"; value != want {
+		t.Errorf("Value() = %v, want %v", value, want)
+	}
+}
+
+func TestLocationScanPostgisFormats(t *testing.T) {
+	for _, value := range []any{
+		"(51.388974,35.689197)",
+		[]byte("(51.388974,35.689197)"),
+		"POINT(51.388974 35.689197)",
+		"SRID=4326;POINT(51.388974 35.689197)",
+		hex.EncodeToString(tehran.WKB()),
+		// EWKB as returned by postgis for ST_SetSRID(ST_MakePoint(51.388974, 35.689197), 4326)
+		"0101000020E6100000457F68E6C9B149402BC0779B37D84140",
+	} {
+		var location domain.Location
+		if err := location.Scan(value); err != nil {
+			t.Fatalf("Scan(%v): %v", value, err)
+		}
+
+		if location != tehran {
+			t.Errorf("Scan(%v) = %v, want %v", value, location, tehran)
+		}
+	}
+}
+
+func TestLocationUnmarshalJSON(t *testing.T) {
+	for _, data := range []string{
+		`{"lat": 35.689197, "long": 51.388974}`,
+		`{"type": "Point", "coordinates": [51.388974, 35.689197]}`,
+		`{"type": "Point", "coordinates": [51.388974, 35.689197, 1200]}`,
+	} {
+		var location domain.Location
+		if err := json.Unmarshal([]byte(data), &location); err != nil {
+			t.Fatalf("Unmarshal(%s): %v", data, err)
+		}
+
+		if location != tehran {
+			t.Errorf("Unmarshal(%s) = %v, want %v", data, location, tehran)
+		}
+	}
+
+	for _, data := range []string{
+		`{"type": "LineString", "coordinates": [[51.388974, 35.689197], [0, 0]]}`,
+		`{"type": "Point", "coordinates": [51.388974]}`,
+	} {
+		var location domain.Location
+		if err := json.Unmarshal([]byte(data), &location); err == nil {
+			t.Errorf("Unmarshal(%s) succeeded, want an error", data)
+		}
+	}
+}
+
+func TestLocationMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(tehran)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `{"lat":35.689197,"long":51.388974,"type":"Point","coordinates":[51.388974,35.689197]}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+
+	var location domain.Location
+	if err := json.Unmarshal(data, &location); err != nil {
+		t.Fatal(err)
+	}
+
+	if location != tehran {
+		t.Errorf("round trip returned %v, want %v", location, tehran)
+	}
+}
+
+func TestLocationWKTRoundTrip(t *testing.T) {
+	wkt := tehran.WKT()
+	if want := "POINT(51.388974 35.689197)"; wkt != want {
+		t.Errorf("WKT() = %s, want %s", wkt, want)
+	}
+
+	location, err := domain.ParseWKT(wkt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if location != tehran {
+		t.Errorf("ParseWKT(%s) = %v, want %v", wkt, location, tehran)
+	}
+
+	for _, invalid := range []string{"POINT(1)", "LINESTRING(1 2, 3 4)", "POINT 1 2", "SRID=4326"} {
+		if _, err := domain.ParseWKT(invalid); err == nil {
+			t.Errorf("ParseWKT(%s) succeeded, want an error", invalid)
+		}
+	}
+}
+
+func TestLocationWKBRoundTrip(t *testing.T) {
+	wkb := tehran.WKB()
+	if want := "0101000000457f68e6c9b149402bc0779b37d84140"; hex.EncodeToString(wkb) != want {
+		t.Errorf("WKB() = %x, want %s", wkb, want)
+	}
+
+	location, err := domain.ParseWKB(wkb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if location != tehran {
+		t.Errorf("ParseWKB(%x) = %v, want %v", wkb, location, tehran)
+	}
+
+	// big endian encoding of the same point
+	bigEndian, _ := hex.DecodeString("00000000014049b1c9e6687f454041d8379b77c02b")
+	location, err = domain.ParseWKB(bigEndian)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if location != tehran {
+		t.Errorf("ParseWKB(%x) = %v, want %v", bigEndian, location, tehran)
+	}
+}
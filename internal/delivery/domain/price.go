@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"time"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// Price is an amount in the currency's minor unit.
+type Price struct {
+	Amount    int64          `json:"amount"`
+	Currency  string         `json:"currency"`
+	Breakdown PriceBreakdown `json:"breakdown"`
+}
+
+type PriceBreakdown struct {
+	BaseFare         int64   `json:"base_fare"`
+	DistanceFare     int64   `json:"distance_fare"`
+	DistanceMeters   float64 `json:"distance_meters"`
+	ZoneMultiplier   float64 `json:"zone_multiplier"`
+	PeakMultiplier   float64 `json:"peak_multiplier"`
+	DemandMultiplier float64 `json:"demand_multiplier"`
+}
+
+type QuoteInput struct {
+	RoutingInfo             RoutingInfo             `json:"routing_info"`
+	ScheduledDeliveryWindow ScheduledDeliveryWindow `json:"scheduled_delivery_window"`
+}
+
+func (o *QuoteInput) Validate() error {
+	var v internal_error.Violations
+
+	v.Merge("routing_info", o.RoutingInfo.Validate())
+	v.Merge("scheduled_delivery_window", o.ScheduledDeliveryWindow.Validate())
+
+	return v.Err()
+}
+
+// QuoteResult is a price valid until ExpiresAt for the quoted routing info and window.
+// Token is to be sent back as the quote_token of the request.
+type QuoteResult struct {
+	Token     string    `json:"token"`
+	Price     Price     `json:"price"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
@@ -3,12 +3,28 @@ package domain
 import "time"
 
 type Shipment struct {
-	UID                      string
-	UserUID                  string
-	UserAddr                 string
-	OriginPoint              Location
-	DestinationPoint         Location
-	ScheduledDeliveryMinTime time.Time
-	ScheduledDeliveryMaxTime time.Time
-	Status                   string
+	UID                      string    `json:"uid"`
+	UserUID                  string    `json:"user_uid"`
+	UserAddr                 string    `json:"user_addr"`
+	UserAddress              Address   `json:"user_address"`
+	OriginPoint              Location  `json:"origin_point"`
+	DestinationPoint         Location  `json:"destination_point"`
+	ScheduledDeliveryMinTime time.Time `json:"scheduled_delivery_min_time"`
+	ScheduledDeliveryMaxTime time.Time `json:"scheduled_delivery_max_time"`
+	Status                   string    `json:"status"`
+	DistanceMeters           float64   `json:"distance_meters"`
+	ETASeconds               int       `json:"eta_seconds"`
+	ZoneID                   string    `json:"zone_id"`
+	PriceAmount              int64     `json:"price_amount"`
+	PriceCurrency            string    `json:"price_currency"`
+	Tracking                 Tracking  `json:"tracking"`
+}
+
+// SlotCapacity is the booking state of an hour in a zone, shared by the windows covering it.
+type SlotCapacity struct {
+	ZoneID    string
+	StartTime time.Time
+	EndTime   time.Time
+	Capacity  int
+	Reserved  int
 }
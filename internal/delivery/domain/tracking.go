@@ -0,0 +1,109 @@
+package domain
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// WebhookVersion is the latest 3pl webhook payload version. Version 1 payloads only carry
+// shipment_uid and status, version 2 adds the tracking details.
+const WebhookVersion = 2
+
+type Courier struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Vehicle string `json:"vehicle"`
+}
+
+type ProofOfDelivery struct {
+	DeliveredAt   time.Time `json:"delivered_at"`
+	RecipientName string    `json:"recipient_name"`
+	// PhotoRef references the photo taken on delivery in the 3pl's storage.
+	PhotoRef string `json:"photo_ref"`
+}
+
+// Tracking is what the 3pl reported about the delivery of a shipment. Every webhook only
+// carries what changed: absent fields keep their last reported value, see Merge.
+type Tracking struct {
+	Courier         *Courier         `json:"courier,omitempty"`
+	CourierLocation *Location        `json:"courier_location,omitempty"`
+	PickupETA       *time.Time       `json:"pickup_eta,omitempty"`
+	DropoffETA      *time.Time       `json:"dropoff_eta,omitempty"`
+	ProofOfDelivery *ProofOfDelivery `json:"proof_of_delivery,omitempty"`
+	// FailureReason is why the courier could not hand the shipment over, e.g. recipient_unavailable.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+func (o *Tracking) IsZero() bool {
+	return o.Courier == nil && o.CourierLocation == nil && o.PickupETA == nil && o.DropoffETA == nil && o.ProofOfDelivery == nil &&
+		o.FailureReason == ""
+}
+
+// Merge overwrites the fields update carries.
+func (o *Tracking) Merge(update Tracking) {
+	if update.Courier != nil {
+		o.Courier = update.Courier
+	}
+	if update.CourierLocation != nil {
+		o.CourierLocation = update.CourierLocation
+	}
+	if update.PickupETA != nil {
+		o.PickupETA = update.PickupETA
+	}
+	if update.DropoffETA != nil {
+		o.DropoffETA = update.DropoffETA
+	}
+	if update.ProofOfDelivery != nil {
+		o.ProofOfDelivery = update.ProofOfDelivery
+	}
+	if update.FailureReason != "" {
+		o.FailureReason = update.FailureReason
+	}
+}
+
+// validate adds violations of o reported along status to v.
+func (o *Tracking) validate(v *internal_error.Violations, status string) {
+	if o.Courier != nil && o.Courier.ID == "" {
+		v.Add("courier.id", internal_error.CodeRequired, "is required")
+	}
+
+	if o.CourierLocation != nil {
+		v.Merge("courier_location", o.CourierLocation.Validate())
+	}
+
+	if o.ProofOfDelivery != nil {
+		if status != "delivered" {
+			v.Add("proof_of_delivery", internal_error.CodeInvalid, "is only reported once delivered")
+		}
+		if o.ProofOfDelivery.DeliveredAt.IsZero() {
+			v.Add("proof_of_delivery.delivered_at", internal_error.CodeRequired, "is required")
+		}
+	}
+
+	if o.FailureReason != "" && status != "delivery_failed" {
+		v.Add("failure_reason", internal_error.CodeInvalid, "is only reported once the delivery failed")
+	}
+}
+
+func (o Tracking) Value() (driver.Value, error) {
+	return json.Marshal(o)
+}
+
+func (o *Tracking) Scan(src any) error {
+	switch src := src.(type) {
+	case nil:
+		*o = Tracking{}
+		return nil
+	case []byte:
+		return json.Unmarshal(src, o)
+	case string:
+		return json.Unmarshal([]byte(src), o)
+	default:
+		return fmt.Errorf("cannot scan %T into Tracking", src)
+	}
+}
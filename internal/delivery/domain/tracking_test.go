@@ -0,0 +1,114 @@
+package domain_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+func TestWebhookInputUnmarshalJSON(t *testing.T) {
+	data := `{
+		"version": 2,
+		"shipment_uid": "shipment",
+		"status": "delivered",
+		"courier": {"id": "courier", "name": "Ali", "phone": "+98 912 000 0000", "vehicle": "motorbike"},
+		"courier_location": {"lat": 35.72, "long": 51.41},
+		"proof_of_delivery": {"delivered_at": "2026-01-05T09:30:00Z", "recipient_name": "Sara", "photo_ref": "pod/shipment.jpg"}
+	}`
+
+	var input domain.WebhookInput
+	if err := json.Unmarshal([]byte(data), &input); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := input.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if input.Courier == nil || input.Courier.Name != "Ali" || input.CourierLocation == nil || input.ProofOfDelivery == nil {
+		t.Fatalf("tracking = %+v", input.Tracking)
+	}
+	if want := time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC); !input.ProofOfDelivery.DeliveredAt.Equal(want) {
+		t.Errorf("delivered at %v, want %v", input.ProofOfDelivery.DeliveredAt, want)
+	}
+}
+
+func TestWebhookInputValidate(t *testing.T) {
+	tests := []struct {
+		name  string
+		input domain.WebhookInput
+		field string
+	}{
+		{"unknown version", domain.WebhookInput{Version: 3, ShipmentUID: "shipment", Status: "found"}, "version"},
+		{
+			"courier without id",
+			domain.WebhookInput{Version: 2, ShipmentUID: "shipment", Status: "found", Tracking: domain.Tracking{Courier: &domain.Courier{Name: "Ali"}}},
+			"courier.id",
+		},
+		{
+			"proof of delivery before shipping",
+			domain.WebhookInput{
+				Version:     2,
+				ShipmentUID: "shipment",
+				Status:      "found",
+				Tracking:    domain.Tracking{ProofOfDelivery: &domain.ProofOfDelivery{DeliveredAt: time.Now()}},
+			},
+			"proof_of_delivery",
+		},
+		{"unknown status", domain.WebhookInput{Version: 2, ShipmentUID: "shipment", Status: "teleported"}, "status"},
+		{"shipped from version 2", domain.WebhookInput{Version: 2, ShipmentUID: "shipment", Status: "shipped"}, "status"},
+		{
+			"failure reason without failure",
+			domain.WebhookInput{Version: 2, ShipmentUID: "shipment", Status: "delivered", Tracking: domain.Tracking{FailureReason: "refused"}},
+			"failure_reason",
+		},
+		{
+			"invalid courier location",
+			domain.WebhookInput{Version: 2, ShipmentUID: "shipment", Status: "found", Tracking: domain.Tracking{CourierLocation: &domain.Location{Lat: 100}}},
+			"courier_location.lat",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var validationErr internal_error.ValidationError
+			if err := tt.input.Validate(); !errors.As(err, &validationErr) {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+
+			for _, violation := range validationErr.Violations {
+				if violation.Field == tt.field {
+					return
+				}
+			}
+			t.Errorf("violations %+v, want one on %s", validationErr.Violations, tt.field)
+		})
+	}
+
+	// version 1 payloads are not checked for tracking
+	legacy := domain.WebhookInput{ShipmentUID: "shipment", Status: "found", Tracking: domain.Tracking{Courier: &domain.Courier{}}}
+	if err := legacy.Validate(); err != nil {
+		t.Errorf("version 1: %v", err)
+	}
+
+	// and still report delivered shipments as shipped
+	shipped := domain.WebhookInput{ShipmentUID: "shipment", Status: "shipped"}
+	if err := shipped.Validate(); err != nil || shipped.DeliveryStatus() != "delivered" {
+		t.Errorf("version 1 shipped: %v, recorded as %q, want delivered", err, shipped.DeliveryStatus())
+	}
+}
+
+func TestTrackingMerge(t *testing.T) {
+	eta := time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)
+	tracking := domain.Tracking{Courier: &domain.Courier{ID: "courier"}, DropoffETA: &eta}
+
+	location := domain.Location{Lat: 35.7, Long: 51.4}
+	tracking.Merge(domain.Tracking{CourierLocation: &location})
+
+	if tracking.Courier == nil || tracking.DropoffETA == nil || tracking.CourierLocation == nil || *tracking.CourierLocation != location {
+		t.Errorf("tracking = %+v, want courier and dropoff eta kept and location added", tracking)
+	}
+}
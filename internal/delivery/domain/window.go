@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// OperatingHours is the part of a day deliveries can be made in, as offsets from midnight.
+type OperatingHours struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// WindowPolicy holds the rules a scheduled delivery window has to satisfy to be bookable.
+type WindowPolicy struct {
+	// AllowedDurations lists the accepted window lengths (e.g. 1h, 2h and 4h slots).
+	AllowedDurations []time.Duration
+	// AlignToHour requires windows to start on a full hour.
+	AlignToHour bool
+	// OperatingHours limits windows per weekday. When non-empty, weekdays missing from it are closed.
+	OperatingHours map[time.Weekday]OperatingHours
+	// BlackoutDates are days (holidays...) no window can be booked on, compared by their calendar date.
+	BlackoutDates []time.Time
+	// MinLeadTime is the minimum duration between now and the start of a window.
+	MinLeadTime time.Duration
+	// MaxHorizon is how far from now a window may end. Zero means no limit.
+	MaxHorizon time.Duration
+	// Location is the timezone alignment, operating hours and blackout dates are evaluated in.
+	// Defaults to time.Local.
+	Location *time.Location
+}
+
+// Validate checks window against the policy at the given time.
+// Violation fields are relative to the window.
+func (p *WindowPolicy) Validate(window ScheduledDeliveryWindow, now time.Time) error {
+	var v internal_error.Violations
+
+	loc := p.location()
+	start := window.StartTime.In(loc)
+	end := window.EndTime.In(loc)
+
+	if end.Before(now) {
+		v.Add("end_time", internal_error.CodeExpired, "has expired")
+	}
+
+	if p.MinLeadTime > 0 && start.Before(now.Add(p.MinLeadTime)) {
+		v.Add("start_time", internal_error.CodeTooSoon, fmt.Sprintf("must be at least %s from now", formatDuration(p.MinLeadTime)))
+	}
+
+	if p.MaxHorizon > 0 && end.After(now.Add(p.MaxHorizon)) {
+		v.Add("end_time", internal_error.CodeTooFar, fmt.Sprintf("must be within %s from now", formatDuration(p.MaxHorizon)))
+	}
+
+	if len(p.AllowedDurations) > 0 && !slices.Contains(p.AllowedDurations, window.Duration()) {
+		v.Add("", internal_error.CodeInvalidDuration, "must last "+formatDurations(p.AllowedDurations))
+	}
+
+	if p.AlignToHour && !start.Equal(truncateToHour(start)) {
+		v.Add("start_time", internal_error.CodeMisaligned, "must be on the hour")
+	}
+
+	if len(p.OperatingHours) > 0 {
+		hours, ok := p.OperatingHours[start.Weekday()]
+		startOffset := start.Sub(midnight(start))
+		endOffset := startOffset + window.Duration()
+
+		switch {
+		case !ok:
+			v.Add("start_time", internal_error.CodeClosed, fmt.Sprintf("no deliveries on %s", start.Weekday()))
+		case startOffset < hours.Open || endOffset > hours.Close:
+			v.Add("", internal_error.CodeClosed, fmt.Sprintf(
+				"must be within operating hours %s-%s",
+				formatClock(hours.Open),
+				formatClock(hours.Close),
+			))
+		}
+	}
+
+	for _, blackout := range p.BlackoutDates {
+		if sameDate(start, blackout) || sameDate(end, blackout) {
+			v.Add("", internal_error.CodeBlackout, fmt.Sprintf("%s is not available for deliveries", blackout.Format(time.DateOnly)))
+			break
+		}
+	}
+
+	return v.Err()
+}
+
+// Slots lists every bookable window starting on an hour within [from, to).
+func (p *WindowPolicy) Slots(from, to, now time.Time) []ScheduledDeliveryWindow {
+	loc := p.location()
+
+	if earliest := now.Add(p.MinLeadTime); from.Before(earliest) {
+		from = earliest
+	}
+
+	start := truncateToHour(from.In(loc))
+	if start.Before(from) {
+		start = start.Add(time.Hour)
+	}
+
+	durations := slices.Clone(p.AllowedDurations)
+	slices.Sort(durations)
+
+	var slots []ScheduledDeliveryWindow
+	for ; start.Before(to); start = start.Add(time.Hour) {
+		for _, duration := range durations {
+			window := ScheduledDeliveryWindow{StartTime: start, EndTime: start.Add(duration)}
+			if window.EndTime.After(to) {
+				continue
+			}
+
+			if p.Validate(window, now) == nil {
+				slots = append(slots, window)
+			}
+		}
+	}
+
+	return slots
+}
+
+func (p *WindowPolicy) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+	return p.Location
+}
+
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func truncateToHour(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+}
+
+func sameDate(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+func formatClock(offset time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(offset.Hours()), int(offset.Minutes())%60)
+}
+
+// formatDuration drops the zero units time.Duration.String keeps (2h0m0s -> 2h).
+func formatDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = s[:len(s)-2]
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = s[:len(s)-2]
+	}
+	return s
+}
+
+func formatDurations(durations []time.Duration) string {
+	formatted := make([]string, len(durations))
+	for i, d := range durations {
+		formatted[i] = formatDuration(d)
+	}
+
+	if len(formatted) == 1 {
+		return formatted[0]
+	}
+	return strings.Join(formatted[:len(formatted)-1], ", ") + " or " + formatted[len(formatted)-1]
+}
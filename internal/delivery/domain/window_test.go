@@ -0,0 +1,202 @@
+package domain_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// monday is 8:00 on a monday.
+var monday = time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+func newTestWindowPolicy() *domain.WindowPolicy {
+	weekday := domain.OperatingHours{Open: 8 * time.Hour, Close: 20 * time.Hour}
+
+	return &domain.WindowPolicy{
+		AllowedDurations: []time.Duration{time.Hour, 2 * time.Hour},
+		AlignToHour:      true,
+		OperatingHours: map[time.Weekday]domain.OperatingHours{
+			time.Monday:    weekday,
+			time.Tuesday:   weekday,
+			time.Wednesday: weekday,
+			time.Thursday:  weekday,
+			time.Friday:    weekday,
+		},
+		BlackoutDates: []time.Time{time.Date(2026, 1, 7, 0, 0, 0, 0, time.UTC)},
+		MinLeadTime:   2 * time.Hour,
+		MaxHorizon:    7 * 24 * time.Hour,
+		Location:      time.UTC,
+	}
+}
+
+func TestWindowPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		start    time.Time
+		duration time.Duration
+		// field and code of the expected violation, none when code is empty
+		field string
+		code  string
+	}{
+		{"valid", monday.Add(2 * time.Hour), 2 * time.Hour, "", ""},
+		{"expired", monday.Add(-3 * time.Hour), time.Hour, "end_time", internal_error.CodeExpired},
+		{"within lead time", monday.Add(time.Hour), time.Hour, "start_time", internal_error.CodeTooSoon},
+		{"beyond horizon", monday.AddDate(0, 0, 8).Add(2 * time.Hour), time.Hour, "end_time", internal_error.CodeTooFar},
+		{"within horizon", monday.AddDate(0, 0, 4).Add(2 * time.Hour), time.Hour, "", ""},
+		{"duration", monday.Add(2 * time.Hour), 3 * time.Hour, "", internal_error.CodeInvalidDuration},
+		{"off the hour", monday.Add(2*time.Hour + 30*time.Minute), time.Hour, "start_time", internal_error.CodeMisaligned},
+		{"closed weekday", monday.AddDate(0, 0, 5).Add(2 * time.Hour), time.Hour, "start_time", internal_error.CodeClosed},
+		{"past closing", monday.Add(11 * time.Hour), 2 * time.Hour, "", internal_error.CodeClosed},
+		{"until closing", monday.Add(10 * time.Hour), 2 * time.Hour, "", ""},
+		{"blackout date", monday.AddDate(0, 0, 2).Add(2 * time.Hour), time.Hour, "", internal_error.CodeBlackout},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := domain.ScheduledDeliveryWindow{StartTime: tt.start, EndTime: tt.start.Add(tt.duration)}
+
+			err := newTestWindowPolicy().Validate(window, monday)
+
+			if tt.code == "" {
+				if err != nil {
+					t.Fatalf("valid window rejected: %v", err)
+				}
+				return
+			}
+
+			var validationErr internal_error.ValidationError
+			if !errors.As(err, &validationErr) {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+
+			if !slices.ContainsFunc(validationErr.Violations, func(v internal_error.FieldViolation) bool {
+				return v.Field == tt.field && v.Code == tt.code
+			}) {
+				t.Errorf("violations = %+v, want %q on %q", validationErr.Violations, tt.code, tt.field)
+			}
+		})
+	}
+}
+
+func TestWindowPolicyValidateLocation(t *testing.T) {
+	policy := &domain.WindowPolicy{AlignToHour: true, Location: time.FixedZone("IRST", 3*3600+1800)}
+
+	// a full hour in utc is half past in tehran
+	window := domain.ScheduledDeliveryWindow{StartTime: monday.Add(time.Hour), EndTime: monday.Add(2 * time.Hour)}
+	if err := policy.Validate(window, monday); err == nil {
+		t.Error("window off the hour of the policy location accepted")
+	}
+
+	window = domain.ScheduledDeliveryWindow{StartTime: monday.Add(90 * time.Minute), EndTime: monday.Add(150 * time.Minute)}
+	if err := policy.Validate(window, monday); err != nil {
+		t.Errorf("window on the hour of the policy location rejected: %v", err)
+	}
+}
+
+func TestWindowPolicySlots(t *testing.T) {
+	at := func(hour int) time.Time {
+		return monday.Add(time.Duration(hour-8) * time.Hour)
+	}
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []domain.ScheduledDeliveryWindow
+	}{
+		{
+			// the lead time pushes the first slot to 10:00, 2h windows must end by to
+			name: "morning",
+			from: at(8),
+			to:   at(12),
+			want: []domain.ScheduledDeliveryWindow{
+				{StartTime: at(10), EndTime: at(11)},
+				{StartTime: at(10), EndTime: at(12)},
+				{StartTime: at(11), EndTime: at(12)},
+			},
+		},
+		{
+			name: "closing",
+			from: at(18),
+			to:   at(22),
+			want: []domain.ScheduledDeliveryWindow{
+				{StartTime: at(18), EndTime: at(19)},
+				{StartTime: at(18), EndTime: at(20)},
+				{StartTime: at(19), EndTime: at(20)},
+			},
+		},
+		{"blackout date", monday.AddDate(0, 0, 2), monday.AddDate(0, 0, 3), nil},
+		{"weekend", monday.AddDate(0, 0, 5), monday.AddDate(0, 0, 7), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newTestWindowPolicy().Slots(tt.from, tt.to, monday)
+
+			if !slices.EqualFunc(got, tt.want, func(a, b domain.ScheduledDeliveryWindow) bool {
+				return a.StartTime.Equal(b.StartTime) && a.EndTime.Equal(b.EndTime)
+			}) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScheduledDeliveryWindowHours(t *testing.T) {
+	tehran, err := time.LoadLocation("Asia/Tehran")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 10:00 in tehran, 6:30 in utc
+	tehranTen := time.Date(2026, 1, 5, 10, 0, 0, 0, tehran)
+
+	tests := []struct {
+		name   string
+		window domain.ScheduledDeliveryWindow
+		loc    *time.Location
+		starts []time.Time
+	}{
+		{
+			"on the hour",
+			domain.ScheduledDeliveryWindow{StartTime: monday, EndTime: monday.Add(2 * time.Hour)},
+			time.UTC,
+			[]time.Time{monday, monday.Add(time.Hour)},
+		},
+		{
+			// the hours it starts and ends in are covered whole
+			"off the hour",
+			domain.ScheduledDeliveryWindow{StartTime: monday.Add(4 * time.Minute), EndTime: monday.Add(time.Hour + 4*time.Minute)},
+			time.UTC,
+			[]time.Time{monday, monday.Add(time.Hour)},
+		},
+		{
+			"within an hour",
+			domain.ScheduledDeliveryWindow{StartTime: monday.Add(10 * time.Minute), EndTime: monday.Add(50 * time.Minute)},
+			time.UTC,
+			[]time.Time{monday},
+		},
+		{
+			"hours of the location",
+			domain.ScheduledDeliveryWindow{StartTime: tehranTen.UTC(), EndTime: tehranTen.Add(time.Hour).UTC()},
+			tehran,
+			[]time.Time{tehranTen},
+		},
+		{"empty", domain.ScheduledDeliveryWindow{StartTime: monday, EndTime: monday}, time.UTC, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours := tt.window.Hours(tt.loc)
+
+			if len(hours) != len(tt.starts) {
+				t.Fatalf("got %v, want hours starting at %v", hours, tt.starts)
+			}
+			for i, hour := range hours {
+				if !hour.StartTime.Equal(tt.starts[i]) || hour.Duration() != time.Hour {
+					t.Errorf("hour %d = %v-%v, want the hour starting at %v", i, hour.StartTime, hour.EndTime, tt.starts[i])
+				}
+			}
+		})
+	}
+}
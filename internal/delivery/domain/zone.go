@@ -0,0 +1,22 @@
+package domain
+
+import "time"
+
+// DefaultCutoffHour is the local hour after which no new delivery guy is requested for the day, in
+// zones not setting theirs and outside of any zone.
+const DefaultCutoffHour = 23
+
+// Zone is a service area we deliver in, along with its operational settings.
+type Zone struct {
+	ID string
+	// Polygons is a multipolygon: every polygon is a list of rings,
+	// the first one being the outer boundary and the others holes.
+	Polygons [][][]Location
+	// Timezone is the zone's local time that windows and the daily cutoff are evaluated in.
+	Timezone *time.Location
+	// CutoffHour is the local hour after which no new delivery guy is requested for the day.
+	CutoffHour int
+	// Preferred3PL is the third party logistics provider shipments of the zone are dispatched to.
+	// Empty means the default provider.
+	Preferred3PL string
+}
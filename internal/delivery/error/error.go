@@ -1,7 +1,125 @@
 package error
 
-type ValidationError string
+import (
+	"errors"
+	"strings"
+)
+
+const (
+	CodeRequired          = "required"
+	CodeOutOfRange        = "out_of_range"
+	CodeExpired           = "expired"
+	CodeInvalid           = "invalid"
+	CodeTooMany           = "too_many"
+	CodeInvalidDuration   = "invalid_duration"
+	CodeMisaligned        = "misaligned"
+	CodeTooSoon           = "too_soon"
+	CodeTooFar            = "too_far"
+	CodeClosed            = "closed"
+	CodeBlackout          = "blackout"
+	CodeSlotFull          = "slot_full"
+	CodeNotCancellable    = "not_cancellable"
+	CodeOutOfService      = "out_of_service_area"
+	CodeQuoteMismatch     = "quote_mismatch"
+	CodeAddressMismatch   = "address_mismatch"
+	CodeDuplicate         = "duplicate"
+	CodeInvalidTransition = "invalid_transition"
+	CodeNotRedispatchable = "not_redispatchable"
+)
+
+var (
+	ErrShipmentNotFound = NotFoundError("shipment not found")
+	ErrShipmentExists   = ConflictError{Code: CodeDuplicate, Message: "shipment already exists"}
+)
+
+// FieldViolation describes a single invalid field of an input.
+// Field is a dot separated json path (e.g. "routing_info.origin.lat").
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (v FieldViolation) String() string {
+	if v.Field == "" {
+		return v.Message
+	}
+	return v.Field + " " + v.Message
+}
+
+type ValidationError struct {
+	Violations []FieldViolation
+}
+
+func NewValidationError(violations ...FieldViolation) ValidationError {
+	return ValidationError{Violations: violations}
+}
 
 func (e ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, violation := range e.Violations {
+		messages[i] = violation.String()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Violations collects every problem found while validating an input
+// instead of stopping at the first one.
+type Violations []FieldViolation
+
+func (v *Violations) Add(field, code, message string) {
+	*v = append(*v, FieldViolation{Field: field, Code: code, Message: message})
+}
+
+// Merge appends the violations of a nested input's validation error, prefixing their fields with prefix.
+func (v *Violations) Merge(prefix string, err error) {
+	if err == nil {
+		return
+	}
+
+	var validationErr ValidationError
+	if !errors.As(err, &validationErr) {
+		v.Add(prefix, CodeInvalid, err.Error())
+		return
+	}
+
+	for _, violation := range validationErr.Violations {
+		violation.Field = joinField(prefix, violation.Field)
+		*v = append(*v, violation)
+	}
+}
+
+// Err returns a ValidationError holding the collected violations or nil if there are none.
+func (v Violations) Err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return NewValidationError(v...)
+}
+
+func joinField(prefix, field string) string {
+	switch {
+	case prefix == "":
+		return field
+	case field == "":
+		return prefix
+	default:
+		return prefix + "." + field
+	}
+}
+
+type NotFoundError string
+
+func (e NotFoundError) Error() string {
 	return string(e)
 }
+
+// ConflictError reports a request that is valid but cannot be applied to the current state.
+type ConflictError struct {
+	Code    string
+	Message string
+}
+
+func (e ConflictError) Error() string {
+	return e.Message
+}
@@ -0,0 +1,64 @@
+package error_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+func TestViolations(t *testing.T) {
+	var nested internal_error.Violations
+	nested.Add("lat", internal_error.CodeOutOfRange, "must be between -90 and 90")
+	nested.Add("", internal_error.CodeInvalid, "must not be null island")
+
+	var v internal_error.Violations
+	v.Add("shipment_uid", internal_error.CodeRequired, "is required")
+	v.Merge("routing_info.origin", nested.Err())
+	v.Merge("user_info", nil)
+	v.Merge("scheduled_delivery_window", errors.New("cannot be parsed"))
+
+	want := []internal_error.FieldViolation{
+		{Field: "shipment_uid", Code: internal_error.CodeRequired, Message: "is required"},
+		{Field: "routing_info.origin.lat", Code: internal_error.CodeOutOfRange, Message: "must be between -90 and 90"},
+		{Field: "routing_info.origin", Code: internal_error.CodeInvalid, Message: "must not be null island"},
+		{Field: "scheduled_delivery_window", Code: internal_error.CodeInvalid, Message: "cannot be parsed"},
+	}
+
+	var validationErr internal_error.ValidationError
+	if err := v.Err(); !errors.As(err, &validationErr) {
+		t.Fatalf("Err() = %v, want a validation error", err)
+	}
+	if !slices.Equal(validationErr.Violations, want) {
+		t.Errorf("violations = %+v, want %+v", validationErr.Violations, want)
+	}
+
+	wantMessage := "shipment_uid is required; routing_info.origin.lat must be between -90 and 90; " +
+		"routing_info.origin must not be null island; scheduled_delivery_window cannot be parsed"
+	if validationErr.Error() != wantMessage {
+		t.Errorf("Error() = %q, want %q", validationErr.Error(), wantMessage)
+	}
+}
+
+func TestViolationsErrWithoutViolations(t *testing.T) {
+	var v internal_error.Violations
+	v.Merge("user_info", nil)
+
+	if err := v.Err(); err != nil {
+		t.Errorf("Err() = %v, want nil", err)
+	}
+}
+
+func TestViolationsMergeWithoutPrefix(t *testing.T) {
+	var v internal_error.Violations
+	v.Merge("", internal_error.NewValidationError(internal_error.FieldViolation{
+		Field:   "end_time",
+		Code:    internal_error.CodeExpired,
+		Message: "has expired",
+	}))
+
+	if len(v) != 1 || v[0].Field != "end_time" {
+		t.Errorf("violations = %+v, want a single end_time one", v)
+	}
+}
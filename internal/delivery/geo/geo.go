@@ -0,0 +1,52 @@
+package geo
+
+import (
+	"math"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+// EarthRadiusMeters is the mean earth radius used for great-circle distances.
+const EarthRadiusMeters = 6371008.8
+
+// Distance returns the great-circle distance in meters between a and b using the haversine formula.
+func Distance(a, b domain.Location) float64 {
+	lat1 := radians(a.Lat)
+	lat2 := radians(b.Lat)
+	dLat := radians(b.Lat - a.Lat)
+	dLong := radians(b.Long - a.Long)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLong/2), 2)
+
+	return 2 * EarthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// ETA estimates how long it takes to travel distanceMeters at an average speed of speedKmh.
+func ETA(distanceMeters, speedKmh float64) time.Duration {
+	if speedKmh <= 0 {
+		return 0
+	}
+
+	hours := distanceMeters / 1000 / speedKmh
+	return time.Duration(hours * float64(time.Hour)).Round(time.Second)
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+// BoundingBox returns the south-west and north-east corners of a box containing
+// every point within radiusMeters of center. It is meant to prefilter candidates
+// before computing exact distances.
+func BoundingBox(center domain.Location, radiusMeters float64) (domain.Location, domain.Location) {
+	latDelta := radiusMeters / EarthRadiusMeters * 180 / math.Pi
+
+	longDelta := 180.0
+	if c := math.Cos(radians(center.Lat)); c > 1e-9 {
+		longDelta = math.Min(latDelta/c, 180)
+	}
+
+	return domain.Location{Lat: center.Lat - latDelta, Long: center.Long - longDelta},
+		domain.Location{Lat: center.Lat + latDelta, Long: center.Long + longDelta}
+}
@@ -0,0 +1,99 @@
+package geo_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/geo"
+)
+
+var (
+	paris  = domain.Location{Lat: 48.8566, Long: 2.3522}
+	london = domain.Location{Lat: 51.5074, Long: -0.1278}
+)
+
+func TestDistance(t *testing.T) {
+	if d := geo.Distance(paris, london); math.Abs(d-343_556) > 100 {
+		t.Errorf("Distance(paris, london) = %f, want ~343556", d)
+	}
+
+	if d := geo.Distance(paris, paris); d != 0 {
+		t.Errorf("Distance(paris, paris) = %f, want 0", d)
+	}
+
+	if d1, d2 := geo.Distance(paris, london), geo.Distance(london, paris); d1 != d2 {
+		t.Errorf("Distance is not symmetric: %f != %f", d1, d2)
+	}
+}
+
+func TestETA(t *testing.T) {
+	if eta := geo.ETA(12_500, 25); eta != 30*time.Minute {
+		t.Errorf("ETA(12.5km, 25km/h) = %s, want 30m", eta)
+	}
+
+	if eta := geo.ETA(12_500, 0); eta != 0 {
+		t.Errorf("ETA with no speed = %s, want 0", eta)
+	}
+}
+
+func TestBoundingBoxContainsRadius(t *testing.T) {
+	southWest, northEast := geo.BoundingBox(paris, 5_000)
+
+	for _, corner := range []domain.Location{
+		{Lat: southWest.Lat, Long: paris.Long},
+		{Lat: northEast.Lat, Long: paris.Long},
+		{Lat: paris.Lat, Long: southWest.Long},
+		{Lat: paris.Lat, Long: northEast.Long},
+	} {
+		if d := geo.Distance(paris, corner); math.Abs(d-5_000) > 1 {
+			t.Errorf("box edge %v is %f meters away, want 5000", corner, d)
+		}
+	}
+}
+
+func TestMultiPolygonContains(t *testing.T) {
+	polygons, err := geo.ParseGeoJSONGeometry([]byte(`{
+		"type": "MultiPolygon",
+		"coordinates": [
+			[
+				[[0, 0], [10, 0], [10, 10], [0, 10], [0, 0]],
+				[[4, 4], [6, 4], [6, 6], [4, 6], [4, 4]]
+			],
+			[
+				[[20, 20], [30, 20], [25, 30], [20, 20]]
+			]
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		point domain.Location
+		want  bool
+	}{
+		{domain.Location{Long: 2, Lat: 2}, true},
+		{domain.Location{Long: 5, Lat: 5}, false}, // in the hole
+		{domain.Location{Long: 11, Lat: 5}, false},
+		{domain.Location{Long: 25, Lat: 25}, true},
+		{domain.Location{Long: 21, Lat: 29}, false},
+	} {
+		if got := geo.MultiPolygonContains(polygons, tc.point); got != tc.want {
+			t.Errorf("MultiPolygonContains(%v) = %v, want %v", tc.point, got, tc.want)
+		}
+	}
+}
+
+func TestParseGeoJSONGeometryRejectsUnsupported(t *testing.T) {
+	for _, data := range []string{
+		`{"type": "Point", "coordinates": [0, 0]}`,
+		`{"type": "Polygon", "coordinates": [[[0, 0], [1, 1], [0, 0]]]}`,
+		`{"type": "Polygon", "coordinates": []}`,
+	} {
+		if _, err := geo.ParseGeoJSONGeometry([]byte(data)); err == nil {
+			t.Errorf("ParseGeoJSONGeometry(%s) succeeded, want an error", data)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package geo
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+// MultiPolygonContains reports whether point is inside any of polygons.
+// Every polygon is a list of rings, the first one being the outer boundary and the others holes.
+func MultiPolygonContains(polygons [][][]domain.Location, point domain.Location) bool {
+	for _, polygon := range polygons {
+		if PolygonContains(polygon, point) {
+			return true
+		}
+	}
+	return false
+}
+
+// PolygonContains reports whether point is inside the outer ring of polygon and outside of its holes.
+func PolygonContains(polygon [][]domain.Location, point domain.Location) bool {
+	if len(polygon) == 0 || !ringContains(polygon[0], point) {
+		return false
+	}
+
+	for _, hole := range polygon[1:] {
+		if ringContains(hole, point) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ringContains uses ray casting on planar (long, lat) coordinates, which is accurate enough for city sized areas.
+func ringContains(ring []domain.Location, point domain.Location) bool {
+	inside := false
+	for i, j := 0, len(ring)-1; i < len(ring); j, i = i, i+1 {
+		a, b := ring[i], ring[j]
+		if (a.Lat > point.Lat) != (b.Lat > point.Lat) &&
+			point.Long < (b.Long-a.Long)*(point.Lat-a.Lat)/(b.Lat-a.Lat)+a.Long {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// ParseGeoJSONGeometry parses a GeoJSON Polygon or MultiPolygon geometry into a multipolygon.
+func ParseGeoJSONGeometry(data []byte) ([][][]domain.Location, error) {
+	var geometry struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &geometry); err != nil {
+		return nil, err
+	}
+
+	switch geometry.Type {
+	case "Polygon":
+		var coordinates [][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &coordinates); err != nil {
+			return nil, err
+		}
+
+		polygon, err := toPolygon(coordinates)
+		if err != nil {
+			return nil, err
+		}
+		return [][][]domain.Location{polygon}, nil
+
+	case "MultiPolygon":
+		var coordinates [][][][]float64
+		if err := json.Unmarshal(geometry.Coordinates, &coordinates); err != nil {
+			return nil, err
+		}
+
+		polygons := make([][][]domain.Location, len(coordinates))
+		for i := range coordinates {
+			polygon, err := toPolygon(coordinates[i])
+			if err != nil {
+				return nil, err
+			}
+			polygons[i] = polygon
+		}
+		return polygons, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported geojson geometry type %q: only Polygon and MultiPolygon are supported", geometry.Type)
+	}
+}
+
+func toPolygon(coordinates [][][]float64) ([][]domain.Location, error) {
+	if len(coordinates) == 0 {
+		return nil, fmt.Errorf("polygon has no rings")
+	}
+
+	polygon := make([][]domain.Location, len(coordinates))
+	for i, ring := range coordinates {
+		if len(ring) < 4 {
+			return nil, fmt.Errorf("polygon ring must have at least 4 positions, got %d", len(ring))
+		}
+
+		polygon[i] = make([]domain.Location, len(ring))
+		for j, position := range ring {
+			if len(position) < 2 {
+				return nil, fmt.Errorf("polygon position must be [long, lat]")
+			}
+			polygon[i][j] = domain.Location{Long: position[0], Lat: position[1]}
+		}
+	}
+
+	return polygon, nil
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"log/slog"
 	"net/http"
 
@@ -11,36 +12,53 @@ import (
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
 )
 
+type Config struct {
+	// URL is the request endpoint of the default provider.
+	URL string
+	// ProviderURLs maps provider names (zones' preferred 3pl) to their request endpoint.
+	ProviderURLs map[string]string
+}
+
 type _3pl struct {
+	config *Config
 	logger *slog.Logger
 }
 
 var _ usecase.ThirdPartyLogistics = (*_3pl)(nil)
 
-func New3PL(logger *slog.Logger) *_3pl {
-	return &_3pl{logger: logger}
+func New3PL(config *Config, logger *slog.Logger) *_3pl {
+	return &_3pl{config: config, logger: logger}
 }
 
 func (t *_3pl) RequestDeliveryGuy(ctx context.Context, input *domain.ThirdPartyLogisticsRequestDeliveryGuyInput) (*domain.ThirdPartyLogisticsRequestDeliveryGuyResult, error) {
 	logger := t.logger.With(slog.String("infra", "3pl"))
 
-	logger.Info("request delivery guy", slog.String("shipment_uid", input.ShipmentUID))
+	url := t.config.URL
+	if providerURL, ok := t.config.ProviderURLs[input.Provider]; ok {
+		url = providerURL
+	}
+
+	logger.Info("request delivery guy", slog.String("shipment_uid", input.ShipmentUID), slog.String("provider", input.Provider))
 
 	body, err := json.Marshal(map[string]any{
 		"shipment_uid": input.ShipmentUID,
+		"zone_id":      input.ZoneID,
+		"routing_info": input.RoutingInfo,
 	})
 	if err != nil {
 		logger.Error("failed to marshal body", slog.Any("error", err))
 		return nil, err
 	}
-	resp, err := http.Post("http://localhost:9090/request", "applicaion/json", bytes.NewReader(body))
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
 	if err != nil {
 		logger.Error("failed to http post", slog.Any("error", err))
 		return nil, err
 	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode != 200 {
-		logger.Error("failed to http post", slog.Int("status_code", resp.StatusCode), slog.Any("error", err))
-		return nil, err
+		logger.Error("failed to http post", slog.Int("status_code", resp.StatusCode))
+		return nil, fmt.Errorf("3pl responded with status code %d", resp.StatusCode)
 	}
 
 	return nil, nil
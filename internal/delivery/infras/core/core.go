@@ -21,7 +21,13 @@ func NewCore(logger *slog.Logger) *core {
 func (c *core) Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error) {
 	logger := c.logger.With(slog.String("infra", "core"))
 
-	logger.Info("invoke webhook", slog.String("shipment_uid", input.ShipmentUID), slog.String("status", input.Status))
+	logger.Info(
+		"invoke webhook",
+		slog.Int("version", input.Version),
+		slog.String("shipment_uid", input.ShipmentUID),
+		slog.String("status", input.Status),
+		slog.Any("tracking", input.Tracking),
+	)
 
 	return nil, nil
 }
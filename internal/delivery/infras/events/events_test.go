@@ -0,0 +1,136 @@
+package events_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/events"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+
+	_ "github.com/lib/pq"
+)
+
+func forShipment(uid string) func(event *domain.ShipmentEvent) bool {
+	return func(event *domain.ShipmentEvent) bool {
+		return event.ShipmentUID == uid
+	}
+}
+
+// receive waits for the next event of ch, failing the test when none comes in time.
+func receive(t *testing.T, ch <-chan domain.ShipmentEvent) domain.ShipmentEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-ch:
+		if !ok {
+			t.Fatal("subscription closed")
+		}
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+		return domain.ShipmentEvent{}
+	}
+}
+
+func testFanOut(t *testing.T, publisher, subscriber usecase.Events) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	uid := "fan_out_" + time.Now().Format("150405.000000000")
+	mine := subscriber.Subscribe(ctx, forShipment(uid))
+	others := subscriber.Subscribe(ctx, forShipment("other_"+uid))
+
+	for _, status := range []string{"found", "picked_up"} {
+		if err := publisher.Publish(ctx, &domain.ShipmentEvent{ShipmentUID: uid, Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, want := range []string{"found", "picked_up"} {
+		if event := receive(t, mine); event.ShipmentUID != uid || event.Status != want {
+			t.Errorf("received %+v, want %s %s", event, uid, want)
+		}
+	}
+
+	select {
+	case event := <-others:
+		t.Errorf("subscriber of another shipment received %+v", event)
+	default:
+	}
+
+	cancel()
+
+	// the subscription is closed once its context is done
+	select {
+	case _, ok := <-mine:
+		if ok {
+			t.Error("received an event after the subscription ended")
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("subscription was not closed")
+	}
+}
+
+func TestMemoryBus(t *testing.T) {
+	bus := events.NewMemoryBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	testFanOut(t, bus, bus)
+}
+
+func TestMemoryBusDropsSlowSubscribers(t *testing.T) {
+	ctx := context.Background()
+	bus := events.NewMemoryBus(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	slow := bus.Subscribe(ctx, forShipment("shipment"))
+
+	// publishing never blocks on a subscriber that does not keep up
+	for range 1000 {
+		if err := bus.Publish(ctx, &domain.ShipmentEvent{ShipmentUID: "shipment", Status: "found"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var received int
+	for range slow {
+		received++
+	}
+
+	if received == 0 || received == 1000 {
+		t.Errorf("slow subscriber received %d events before being dropped, want some", received)
+	}
+}
+
+func TestPostgresBus(t *testing.T) {
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// two instances sharing the database
+	publisher, err := events.NewPostgresBus(ctx, db, connStr, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	subscriber, err := events.NewPostgresBus(ctx, db, connStr, logger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testFanOut(t, publisher, subscriber)
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind before it is dropped.
+const subscriberBuffer = 64
+
+type subscriber struct {
+	match  func(event *domain.ShipmentEvent) bool
+	events chan domain.ShipmentEvent
+}
+
+// memoryBus fans events out to the subscribers of this instance only.
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	logger      *slog.Logger
+}
+
+var _ usecase.Events = (*memoryBus)(nil)
+
+func NewMemoryBus(logger *slog.Logger) *memoryBus {
+	return &memoryBus{
+		subscribers: make(map[*subscriber]struct{}),
+		logger:      logger,
+	}
+}
+
+func (b *memoryBus) Publish(ctx context.Context, event *domain.ShipmentEvent) error {
+	b.fanOut(event)
+	return nil
+}
+
+func (b *memoryBus) Subscribe(ctx context.Context, match func(event *domain.ShipmentEvent) bool) <-chan domain.ShipmentEvent {
+	s := &subscriber{
+		match:  match,
+		events: make(chan domain.ShipmentEvent, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mu.Unlock()
+
+	context.AfterFunc(ctx, func() { b.unsubscribe(s) })
+
+	return s.events
+}
+
+// fanOut hands event to every matching subscriber without ever blocking the publisher:
+// subscribers whose buffer is full are dropped, they subscribe again and start over from
+// the current state of their shipments.
+func (b *memoryBus) fanOut(event *domain.ShipmentEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscribers {
+		if !s.match(event) {
+			continue
+		}
+
+		select {
+		case s.events <- *event:
+		default:
+			b.logger.Warn("dropping slow subscriber", slog.String("shipment_uid", event.ShipmentUID))
+			delete(b.subscribers, s)
+			close(s.events)
+		}
+	}
+}
+
+func (b *memoryBus) unsubscribe(s *subscriber) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.subscribers[s]; ok {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
+
+// dropAll drops every subscriber, e.g. once events may have been missed.
+func (b *memoryBus) dropAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for s := range b.subscribers {
+		delete(b.subscribers, s)
+		close(s.events)
+	}
+}
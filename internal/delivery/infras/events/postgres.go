@@ -0,0 +1,115 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+	"github.com/lib/pq"
+)
+
+// channel is the postgres notification channel events are published on.
+const channel = "shipment_events"
+
+const (
+	minReconnectInterval = 100 * time.Millisecond
+	maxReconnectInterval = time.Minute
+	// pingInterval is how long the listener may stay idle before its connection is checked.
+	pingInterval = 90 * time.Second
+)
+
+// postgresBus fans events out to the subscribers of every instance: events are published with
+// NOTIFY and every instance, the publishing one included, hands the notifications it LISTENs to
+// to its own subscribers.
+type postgresBus struct {
+	sqlDB    *sql.DB
+	listener *pq.Listener
+	local    *memoryBus
+	logger   *slog.Logger
+}
+
+var _ usecase.Events = (*postgresBus)(nil)
+
+// NewPostgresBus listens for events on a dedicated connection to databaseURL until ctx is done.
+func NewPostgresBus(ctx context.Context, sqlDB *sql.DB, databaseURL string, logger *slog.Logger) (*postgresBus, error) {
+	logger = logger.With(slog.String("channel", channel))
+
+	listener := pq.NewListener(databaseURL, minReconnectInterval, maxReconnectInterval, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logger.Error("event listener connection failed", slog.Any("error", err))
+		}
+	})
+
+	if err := listener.Listen(channel); err != nil {
+		logger.Error("failed to listen", slog.Any("error", err))
+		listener.Close()
+		return nil, err
+	}
+
+	b := &postgresBus{
+		sqlDB:    sqlDB,
+		listener: listener,
+		local:    NewMemoryBus(logger),
+		logger:   logger,
+	}
+
+	go b.listen(ctx)
+
+	return b, nil
+}
+
+func (b *postgresBus) Publish(ctx context.Context, event *domain.ShipmentEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.sqlDB.ExecContext(ctx, `SELECT pg_notify($1, $2)`, channel, string(payload))
+	return err
+}
+
+func (b *postgresBus) Subscribe(ctx context.Context, match func(event *domain.ShipmentEvent) bool) <-chan domain.ShipmentEvent {
+	return b.local.Subscribe(ctx, match)
+}
+
+func (b *postgresBus) listen(ctx context.Context) {
+	defer b.listener.Close()
+
+	ticker := time.NewTicker(pingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-ticker.C:
+			go func() {
+				if err := b.listener.Ping(); err != nil {
+					b.logger.Error("event listener ping failed", slog.Any("error", err))
+				}
+			}()
+
+		case notification := <-b.listener.Notify:
+			// a nil notification follows a reconnection: events published meanwhile are lost,
+			// subscribers start over from the current state of their shipments
+			if notification == nil {
+				b.logger.Warn("event listener reconnected: dropping subscribers")
+				b.local.dropAll()
+				continue
+			}
+
+			var event domain.ShipmentEvent
+			if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+				b.logger.Error("failed to decode event", slog.Any("error", err))
+				continue
+			}
+
+			b.local.fanOut(&event)
+		}
+	}
+}
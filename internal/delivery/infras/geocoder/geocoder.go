@@ -0,0 +1,109 @@
+package geocoder
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+// Entry is a known address and where it is.
+type Entry struct {
+	Address  domain.Address  `json:"address"`
+	Location domain.Location `json:"location"`
+}
+
+// geocoder resolves addresses offline against a fixed list of entries.
+// An address matches on its street, then falls back to its postal code and then to its city,
+// placing it at the first known address there.
+type geocoder struct {
+	geocodes map[string]domain.Geocode
+	logger   *slog.Logger
+}
+
+var _ usecase.Geocoder = (*geocoder)(nil)
+
+func NewGeocoder(entries []Entry, logger *slog.Logger) *geocoder {
+	g := &geocoder{
+		geocodes: make(map[string]domain.Geocode),
+		logger:   logger,
+	}
+
+	for _, entry := range entries {
+		for _, key := range keys(&entry.Address) {
+			if _, exists := g.geocodes[key.key]; !exists {
+				g.geocodes[key.key] = domain.Geocode{Location: entry.Location, Precision: key.precision}
+			}
+		}
+	}
+
+	return g
+}
+
+// LoadFile reads entries from a json array of {"address": {...}, "location": {"lat": ..., "long": ...}}.
+func LoadFile(path string, logger *slog.Logger) (*geocoder, error) {
+	logger = logger.With(slog.String("infra", "geocoder"), slog.String("path", path))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("failed to read geocoder file", slog.Any("error", err))
+		return nil, err
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		logger.Error("failed to decode geocoder file", slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.Info("loaded geocoder entries", slog.Int("count", len(entries)))
+
+	return NewGeocoder(entries, logger), nil
+}
+
+func (g *geocoder) Geocode(ctx context.Context, address *domain.Address) (*domain.Geocode, error) {
+	for _, key := range keys(address) {
+		if geocode, ok := g.geocodes[key.key]; ok {
+			return &geocode, nil
+		}
+	}
+
+	g.logger.Debug("address not found", slog.String("infra", "geocoder"), slog.String("address", address.String()))
+
+	return nil, nil
+}
+
+type lookupKey struct {
+	key       string
+	precision string
+}
+
+// keys returns the lookup keys of address from the most to the least specific one.
+func keys(address *domain.Address) []lookupKey {
+	var (
+		street     = normalize(address.Street)
+		city       = normalize(address.City)
+		postalCode = normalize(address.PostalCode)
+		country    = normalize(address.Country)
+	)
+
+	var keys []lookupKey
+	if street != "" {
+		keys = append(keys, lookupKey{"street:" + strings.Join([]string{street, postalCode, city, country}, "|"), domain.GeocodePrecisionStreet})
+	}
+	if postalCode != "" {
+		keys = append(keys, lookupKey{"postal_code:" + postalCode + "|" + country, domain.GeocodePrecisionPostalCode})
+	}
+	if city != "" {
+		keys = append(keys, lookupKey{"city:" + city + "|" + country, domain.GeocodePrecisionCity})
+	}
+	return keys
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
@@ -0,0 +1,56 @@
+package geocoder_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/geocoder"
+)
+
+func TestGeocode(t *testing.T) {
+	g, err := geocoder.LoadFile("testdata/addresses.json", slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		address domain.Address
+		want    *domain.Geocode
+	}{
+		{
+			"exact street ignoring case and spaces",
+			domain.Address{Street: "12  valiasr st", City: "TEHRAN", PostalCode: "1591", Country: "IR"},
+			&domain.Geocode{Location: domain.Location{Lat: 35.7219, Long: 51.4094}, Precision: domain.GeocodePrecisionStreet},
+		},
+		{
+			"postal code fallback",
+			domain.Address{Street: "99 Unknown St", City: "Tehran", PostalCode: "1391", Country: "IR"},
+			&domain.Geocode{Location: domain.Location{Lat: 35.6997, Long: 51.3380}, Precision: domain.GeocodePrecisionPostalCode},
+		},
+		{
+			"city fallback is coarse",
+			domain.Address{Street: "99 Unknown St", City: "Tehran", Country: "IR"},
+			&domain.Geocode{Location: domain.Location{Lat: 35.7219, Long: 51.4094}, Precision: domain.GeocodePrecisionCity},
+		},
+		{
+			"unknown",
+			domain.Address{Street: "99 Unknown St", City: "Shiraz", Country: "IR"},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := g.Geocode(context.Background(), &tt.address)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,800 @@
+package repo_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/repo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+// implementations are the usecase.Repo implementations the contract runs against.
+// Every test scopes its uids and zone ids with prefix so it can share a database with others.
+var implementations = []struct {
+	name    string
+	newRepo func(t *testing.T, prefix string) usecase.Repo
+}{
+	{
+		name: "memory",
+		newRepo: func(t *testing.T, prefix string) usecase.Repo {
+			return repo.NewMemoryRepo(clock.NewClock(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+		},
+	},
+	{
+		name: "postgres",
+		newRepo: func(t *testing.T, prefix string) usecase.Repo {
+			db := newTestDB(t)
+			t.Cleanup(func() {
+				db.Exec(`DELETE FROM shipments WHERE uid LIKE $1 || '%'`, prefix)
+				db.Exec(`DELETE FROM slot_capacities WHERE zone_id LIKE $1 || '%'`, prefix)
+				db.Exec(`DELETE FROM failed_core_webhooks WHERE shipment_uid LIKE $1 || '%'`, prefix)
+			})
+			r, err := repo.NewRepo(context.Background(), db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return r
+		},
+	},
+}
+
+func TestRepoContract(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, r usecase.Repo, prefix string)
+	}{
+		{"InsertAndGetShipment", testInsertAndGetShipment},
+		{"InsertShipmentsSkipsExisting", testInsertShipmentsSkipsExisting},
+		{"ShipmentStatus", testShipmentStatus},
+		{"MergeShipmentTracking", testMergeShipmentTracking},
+		{"Slots", testSlots},
+		{"ListShipmentsNear", testListShipmentsNear},
+		{"ListShipments", testListShipments},
+		{"PromoteQueuedShipments", testPromoteQueuedShipments},
+		{"ClaimPendingShipments", testClaimPendingShipments},
+		{"CancelDuringDispatch", testCancelDuringDispatch},
+		{"StatusUpdateDuringDispatch", testStatusUpdateDuringDispatch},
+		{"ShipmentHistory", testShipmentHistory},
+		{"CountShipmentsByStatus", testCountShipmentsByStatus},
+		{"FailedCoreWebhooks", testFailedCoreWebhooks},
+	}
+
+	for _, implementation := range implementations {
+		t.Run(implementation.name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					prefix := fmt.Sprintf("contract_%d_", time.Now().UnixNano())
+					tt.run(t, implementation.newRepo(t, prefix), prefix)
+				})
+			}
+		})
+	}
+}
+
+func testInsertAndGetShipment(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	want := newTestShipment(prefix + "shipment")
+	want.DistanceMeters = 1234.5
+	want.ETASeconds = 300
+	want.ZoneID = prefix + "zone"
+	want.PriceAmount = 750
+	want.PriceCurrency = "USD"
+
+	if err := r.InsertShipment(ctx, want); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetShipment(ctx, want.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertShipment(t, got, want)
+
+	if err := r.InsertShipment(ctx, want); !errors.Is(err, internal_error.ErrShipmentExists) {
+		t.Errorf("inserting a duplicate returned %v, want %v", err, internal_error.ErrShipmentExists)
+	}
+
+	if _, err := r.GetShipment(ctx, prefix+"missing"); !errors.Is(err, internal_error.ErrShipmentNotFound) {
+		t.Errorf("getting a missing shipment returned %v, want %v", err, internal_error.ErrShipmentNotFound)
+	}
+}
+
+func testInsertShipmentsSkipsExisting(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	existing := newTestShipment(prefix + "existing")
+	if err := r.InsertShipment(ctx, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := newTestShipment(prefix+"first"), newTestShipment(prefix+"second")
+
+	inserted, err := r.InsertShipments(ctx, []*domain.Shipment{first, existing, second, first})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	slices.Sort(inserted)
+	if want := []string{first.UID, second.UID}; !slices.Equal(inserted, want) {
+		t.Errorf("inserted %v, want %v", inserted, want)
+	}
+
+	for _, want := range []*domain.Shipment{first, second} {
+		got, err := r.GetShipment(ctx, want.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertShipment(t, got, want)
+	}
+}
+
+func testShipmentStatus(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	shipment := newTestShipment(prefix + "shipment")
+	if err := r.InsertShipment(ctx, shipment); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetShipmentStatus(ctx, prefix+"missing", "pending"); !errors.Is(err, internal_error.ErrShipmentNotFound) {
+		t.Errorf("setting the status of a missing shipment returned %v, want %v", err, internal_error.ErrShipmentNotFound)
+	}
+
+	if err := r.SetShipmentStatus(ctx, shipment.UID, "pending"); err != nil {
+		t.Fatal(err)
+	}
+	assertStatus(t, r, shipment.UID, "pending")
+
+	updated, err := r.UpdateShipmentStatus(ctx, shipment.UID, []string{"queued"}, "cancelled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated {
+		t.Error("updated a shipment that was not in fromStatuses")
+	}
+	assertStatus(t, r, shipment.UID, "pending")
+
+	updated, err = r.UpdateShipmentStatus(ctx, shipment.UID, []string{"queued", "pending"}, "cancelled")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Error("did not update a shipment in fromStatuses")
+	}
+	assertStatus(t, r, shipment.UID, "cancelled")
+
+	if updated, err := r.UpdateShipmentStatus(ctx, prefix+"missing", []string{"queued"}, "cancelled"); err != nil || updated {
+		t.Errorf("updating a missing shipment returned (%v, %v), want (false, nil)", updated, err)
+	}
+}
+
+func testMergeShipmentTracking(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	shipment := newTestShipment(prefix + "shipment")
+	if err := r.InsertShipment(ctx, shipment); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.MergeShipmentTracking(ctx, prefix+"missing", domain.Tracking{}); !errors.Is(err, internal_error.ErrShipmentNotFound) {
+		t.Errorf("merging the tracking of a missing shipment returned %v, want %v", err, internal_error.ErrShipmentNotFound)
+	}
+
+	courier := &domain.Courier{ID: "courier", Name: "Ali", Phone: "+98 912 000 0000", Vehicle: "motorbike"}
+	eta := time.Date(2026, 1, 5, 9, 30, 0, 0, time.UTC)
+	if err := r.MergeShipmentTracking(ctx, shipment.UID, domain.Tracking{
+		Courier:         courier,
+		CourierLocation: &domain.Location{Lat: 35.7, Long: 51.4},
+		DropoffETA:      &eta,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	// fields left out keep their value
+	moved := domain.Location{Lat: 35.71, Long: 51.41}
+	if err := r.MergeShipmentTracking(ctx, shipment.UID, domain.Tracking{CourierLocation: &moved}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := r.GetShipment(ctx, shipment.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracking := got.Tracking
+	if tracking.Courier == nil || *tracking.Courier != *courier {
+		t.Errorf("courier = %+v, want %+v", tracking.Courier, courier)
+	}
+	if tracking.CourierLocation == nil || *tracking.CourierLocation != moved {
+		t.Errorf("courier location = %+v, want %+v", tracking.CourierLocation, moved)
+	}
+	if tracking.DropoffETA == nil || !tracking.DropoffETA.Equal(eta) {
+		t.Errorf("dropoff eta = %v, want %v", tracking.DropoffETA, eta)
+	}
+	if tracking.PickupETA != nil || tracking.ProofOfDelivery != nil {
+		t.Errorf("tracking = %+v, want no pickup eta nor proof of delivery", tracking)
+	}
+}
+
+func testSlots(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	zoneID := prefix + "zone"
+	start := time.Now().Add(24 * time.Hour).Truncate(time.Hour)
+	window := domain.ScheduledDeliveryWindow{StartTime: start, EndTime: start.Add(2 * time.Hour)}
+	later := domain.ScheduledDeliveryWindow{StartTime: start.Add(4 * time.Hour), EndTime: start.Add(5 * time.Hour)}
+
+	reserve := func(window domain.ScheduledDeliveryWindow, defaultCapacity int, want bool) {
+		t.Helper()
+		reserved, err := r.ReserveSlot(ctx, zoneID, window.Hours(time.UTC), defaultCapacity)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if reserved != want {
+			t.Errorf("reserved = %v, want %v", reserved, want)
+		}
+	}
+
+	reserve(window, 0, false)
+	reserve(window, 2, true)
+	reserve(window, 2, true)
+	reserve(window, 2, false)
+
+	if err := r.ReleaseSlot(ctx, zoneID, window.Hours(time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+	reserve(window, 2, true)
+
+	reserve(later, 5, true)
+
+	// overlapping windows share the hours they cover, a full hour takes none of the others
+	reserve(domain.ScheduledDeliveryWindow{StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour)}, 2, false)
+	reserve(domain.ScheduledDeliveryWindow{StartTime: start.Add(time.Hour), EndTime: start.Add(3 * time.Hour)}, 2, false)
+
+	// releasing a slot never used is a no-op
+	never := domain.ScheduledDeliveryWindow{StartTime: start.Add(-time.Hour), EndTime: start}
+	if err := r.ReleaseSlot(ctx, zoneID, never.Hours(time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	capacities, err := r.ListSlotCapacities(ctx, zoneID, start.Add(time.Hour), start.Add(24*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(capacities) != 2 {
+		t.Fatalf("listed %d hours, want 2", len(capacities))
+	}
+	for i, want := range []struct {
+		window             domain.ScheduledDeliveryWindow
+		capacity, reserved int
+	}{
+		{domain.ScheduledDeliveryWindow{StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour)}, 2, 2},
+		{later, 5, 1},
+	} {
+		got := capacities[i]
+		if got.ZoneID != zoneID || !got.StartTime.Equal(want.window.StartTime) || !got.EndTime.Equal(want.window.EndTime) ||
+			got.Capacity != want.capacity || got.Reserved != want.reserved {
+			t.Errorf("slot %d = %+v, want %v with %d/%d", i, got, want.window, want.reserved, want.capacity)
+		}
+	}
+
+	capacities, err = r.ListSlotCapacities(ctx, zoneID, start.Add(2*time.Hour), start.Add(4*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(capacities) != 0 {
+		t.Errorf("listed %v between slots, want none", capacities)
+	}
+
+	if err := r.ReleaseSlot(ctx, zoneID, window.Hours(time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	capacities, err = r.ListSlotCapacities(ctx, zoneID, start, start.Add(2*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(capacities) != 2 {
+		t.Fatalf("listed %d hours of the window, want 2", len(capacities))
+	}
+	for _, capacity := range capacities {
+		if capacity.Reserved != 1 {
+			t.Errorf("hour %v reserved %d after release, want 1", capacity.StartTime, capacity.Reserved)
+		}
+	}
+}
+
+func testListShipmentsNear(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	// points north of a center far from anything else, about 111m per 0.001 degree
+	center := domain.Location{Lat: -60, Long: -120}
+	near := newNearShipment(prefix+"near", center, 0.001, "queued")
+	middle := newNearShipment(prefix+"middle", center, 0.002, "pending")
+	far := newNearShipment(prefix+"far", center, 0.003, "queued")
+	outside := newNearShipment(prefix+"outside", center, 0.02, "queued")
+
+	if _, err := r.InsertShipments(ctx, []*domain.Shipment{far, outside, near, middle}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name   string
+		status string
+		limit  int
+		want   []string
+	}{
+		{"closest first", "", 10, []string{near.UID, middle.UID, far.UID}},
+		{"status", "queued", 10, []string{near.UID, far.UID}},
+		{"limit", "", 2, []string{near.UID, middle.UID}},
+	} {
+		shipments, err := r.ListShipmentsNear(ctx, center, 500, tt.status, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		for _, shipment := range shipments {
+			got = append(got, shipment.UID)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: listed %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func testListShipments(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	zone, other := prefix+"zone", prefix+"other"
+
+	var shipments []*domain.Shipment
+	for i, status := range []string{"queued", "found", "found", "delivered", "found"} {
+		shipment := newTestShipment(fmt.Sprintf("%s%d", prefix, i))
+		shipment.ZoneID = zone
+		shipment.Status = status
+		shipments = append(shipments, shipment)
+	}
+	shipments[4].ZoneID = other
+
+	if _, err := r.InsertShipments(ctx, shipments); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		filter   domain.ShipmentFilter
+		afterUID string
+		limit    int
+		want     []string
+	}{
+		{"zones", domain.ShipmentFilter{ZoneIDs: []string{zone, other}}, "", 10, []string{"0", "1", "2", "3", "4"}},
+		{"zone and status", domain.ShipmentFilter{ZoneIDs: []string{zone}, Statuses: []string{"found"}}, "", 10, []string{"1", "2"}},
+		{"uids", domain.ShipmentFilter{ShipmentUIDs: []string{prefix + "3", prefix + "0", prefix + "missing"}}, "", 10, []string{"0", "3"}},
+		{"first page", domain.ShipmentFilter{ZoneIDs: []string{zone, other}}, "", 2, []string{"0", "1"}},
+		{"next page", domain.ShipmentFilter{ZoneIDs: []string{zone, other}}, prefix + "1", 2, []string{"2", "3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, err := r.ListShipments(ctx, tt.filter, tt.afterUID, tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, shipment := range listed {
+				got = append(got, strings.TrimPrefix(shipment.UID, prefix))
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("listed %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func testPromoteQueuedShipments(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+	now := time.Now().Truncate(time.Second)
+
+	due := newTestShipment(prefix + "due")
+	due.ScheduledDeliveryMinTime = now.Add(time.Minute)
+	notDue := newTestShipment(prefix + "not_due")
+	notDue.ScheduledDeliveryMinTime = now.Add(time.Hour)
+	requested := newTestShipment(prefix + "requested")
+	requested.ScheduledDeliveryMinTime = now
+	requested.Status = "requested"
+
+	if _, err := r.InsertShipments(ctx, []*domain.Shipment{due, notDue, requested}); err != nil {
+		t.Fatal(err)
+	}
+
+	var promoted []string
+	for {
+		shipments, err := r.PromoteQueuedShipments(ctx, now.Add(10*time.Minute), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(shipments) > 1 {
+			t.Fatalf("promoted %d shipments, want at most the limit of 1", len(shipments))
+		}
+		if len(shipments) == 0 {
+			break
+		}
+		if shipments[0].Status != "pending" {
+			t.Errorf("promoted %s returned as %q, want pending", shipments[0].UID, shipments[0].Status)
+		}
+		promoted = append(promoted, ownedBy(prefix, []string{shipments[0].UID})...)
+	}
+
+	if want := []string{due.UID}; !slices.Equal(promoted, want) {
+		t.Errorf("promoted %v, want %v", promoted, want)
+	}
+
+	assertStatus(t, r, due.UID, "pending")
+	assertStatus(t, r, notDue.UID, "queued")
+	assertStatus(t, r, requested.UID, "requested")
+}
+
+func testClaimPendingShipments(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+
+	var shipments []*domain.Shipment
+	for i := range 3 {
+		shipment := newTestShipment(fmt.Sprintf("%spending_%d", prefix, i))
+		shipment.Status = "pending"
+		shipments = append(shipments, shipment)
+	}
+	if _, err := r.InsertShipments(ctx, shipments); err != nil {
+		t.Fatal(err)
+	}
+
+	var dispatched []string
+	claimed, err := r.ClaimPendingShipments(ctx, now, retryAt, 1000, func(claimed []domain.Shipment) []string {
+		for _, shipment := range claimed {
+			dispatched = append(dispatched, shipment.UID)
+		}
+
+		// a concurrent claim must skip shipments being dispatched
+		if _, err := r.ClaimPendingShipments(ctx, now, retryAt, 1000, func(concurrent []domain.Shipment) []string {
+			for _, shipment := range concurrent {
+				if strings.HasPrefix(shipment.UID, prefix) {
+					t.Errorf("%s was claimed twice", shipment.UID)
+				}
+			}
+			return nil
+		}); err != nil {
+			t.Error(err)
+		}
+
+		return []string{shipments[0].UID, shipments[2].UID}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dispatched = ownedBy(prefix, dispatched)
+	slices.Sort(dispatched)
+	if want := []string{shipments[0].UID, shipments[1].UID, shipments[2].UID}; !slices.Equal(dispatched, want) {
+		t.Errorf("dispatched %v, want %v", dispatched, want)
+	}
+	if claimed < len(shipments) {
+		t.Errorf("claimed %d shipments, want at least %d", claimed, len(shipments))
+	}
+
+	assertStatus(t, r, shipments[0].UID, "requested")
+	assertStatus(t, r, shipments[1].UID, "pending")
+	assertStatus(t, r, shipments[2].UID, "requested")
+
+	// shipments left pending are claimable again once released, but not before retryAt
+	reclaim := func(now time.Time) []string {
+		var reclaimed []string
+		if _, err := r.ClaimPendingShipments(ctx, now, now.Add(time.Minute), 1000, func(claimed []domain.Shipment) []string {
+			for _, shipment := range claimed {
+				reclaimed = append(reclaimed, shipment.UID)
+			}
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		return ownedBy(prefix, reclaimed)
+	}
+
+	if reclaimed := reclaim(retryAt.Add(-time.Second)); len(reclaimed) != 0 {
+		t.Errorf("reclaimed %v before the retry, want none", reclaimed)
+	}
+	if reclaimed, want := reclaim(retryAt), []string{shipments[1].UID}; !slices.Equal(reclaimed, want) {
+		t.Errorf("reclaimed %v, want %v", reclaimed, want)
+	}
+}
+
+func testCancelDuringDispatch(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+
+	shipment := newTestShipment(prefix + "shipment")
+	shipment.Status = "pending"
+	if err := r.InsertShipment(ctx, shipment); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		cancelled bool
+		err       error
+	}
+	cancel := make(chan result, 1)
+
+	if _, err := r.ClaimPendingShipments(ctx, now, retryAt, 1000, func(claimed []domain.Shipment) []string {
+		// the cancel races the dispatch: it either waits for it or is refused, never both lost
+		go func() {
+			cancelled, err := r.UpdateShipmentStatus(ctx, shipment.UID, []string{"queued", "pending"}, "cancelled")
+			cancel <- result{cancelled, err}
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		return []string{shipment.UID}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-cancel
+	if got.err != nil {
+		t.Fatal(got.err)
+	}
+	if got.cancelled {
+		t.Error("shipment cancelled while it was dispatched")
+	}
+
+	assertStatus(t, r, shipment.UID, "requested")
+}
+
+func testStatusUpdateDuringDispatch(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+	now := time.Now()
+	retryAt := now.Add(time.Minute)
+
+	shipment := newTestShipment(prefix + "shipment")
+	shipment.Status = "pending"
+	if err := r.InsertShipment(ctx, shipment); err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		updated bool
+		err     error
+	}
+	update := make(chan result, 1)
+
+	if _, err := r.ClaimPendingShipments(ctx, now, retryAt, 1000, func(claimed []domain.Shipment) []string {
+		// a webhook of the requested shipment may arrive before its dispatch is over: it waits for
+		// the claim to be released and applies to the status the dispatch left
+		go func() {
+			updated, err := r.UpdateShipmentStatus(ctx, shipment.UID, []string{"requested"}, "searching")
+			update <- result{updated, err}
+		}()
+		time.Sleep(50 * time.Millisecond)
+
+		select {
+		case got := <-update:
+			t.Error("status updated while the shipment was dispatched")
+			update <- got
+		default:
+		}
+
+		return []string{shipment.UID}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := <-update
+	if got.err != nil {
+		t.Fatal(got.err)
+	}
+	if !got.updated {
+		t.Error("status not updated once the dispatch was over")
+	}
+
+	assertStatus(t, r, shipment.UID, "searching")
+}
+
+func testShipmentHistory(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	shipment := newTestShipment(prefix + "shipment")
+	if err := r.InsertShipment(ctx, shipment); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.SetShipmentStatus(ctx, shipment.UID, "pending"); err != nil {
+		t.Fatal(err)
+	}
+	// setting the same status again is not a change
+	if err := r.SetShipmentStatus(ctx, shipment.UID, "pending"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.UpdateShipmentStatus(ctx, shipment.UID, []string{"pending"}, "cancelled"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := r.ListShipmentHistory(ctx, shipment.UID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses []string
+	for i, change := range history {
+		statuses = append(statuses, change.Status)
+		if change.At.IsZero() || i > 0 && change.At.Before(history[i-1].At) {
+			t.Errorf("change %d at %v is not in order", i, change.At)
+		}
+	}
+	if want := []string{"queued", "pending", "cancelled"}; !slices.Equal(statuses, want) {
+		t.Errorf("history = %v, want %v", statuses, want)
+	}
+
+	if history, err := r.ListShipmentHistory(ctx, prefix+"missing"); err != nil || len(history) != 0 {
+		t.Errorf("history of a missing shipment = (%v, %v), want none", history, err)
+	}
+}
+
+func testCountShipmentsByStatus(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+
+	// other tests may share the database: only the shipments inserted here are accounted for
+	before, err := r.CountShipmentsByStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, status := range []string{"queued", "found", "found"} {
+		shipment := newTestShipment(fmt.Sprintf("%s%d", prefix, i))
+		shipment.Status = status
+		if err := r.InsertShipment(ctx, shipment); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	after, err := r.CountShipmentsByStatus(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for status, want := range map[string]int{"queued": 1, "found": 2, "delivered": 0} {
+		if added := after[status] - before[status]; added != want {
+			t.Errorf("%s: counted %d more shipments, want %d", status, added, want)
+		}
+	}
+}
+
+func testFailedCoreWebhooks(t *testing.T, r usecase.Repo, prefix string) {
+	ctx := context.Background()
+	at := time.Now().Truncate(time.Second)
+
+	before, err := r.CountFailedCoreWebhooks(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	older := &domain.CoreWebhookInput{ShipmentUID: prefix + "older", Status: "found"}
+	newer := &domain.CoreWebhookInput{
+		Version:     2,
+		ShipmentUID: prefix + "newer",
+		Status:      "delivery_failed",
+		Tracking:    domain.Tracking{FailureReason: "recipient_unavailable"},
+	}
+
+	if err := r.SaveFailedCoreWebhook(ctx, newer, "first failure", at.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SaveFailedCoreWebhook(ctx, older, "refused", at); err != nil {
+		t.Fatal(err)
+	}
+	// failing again counts an attempt of the same webhook
+	if err := r.SaveFailedCoreWebhook(ctx, newer, "second failure", at.Add(2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := r.CountFailedCoreWebhooks(ctx); err != nil || count-before != 2 {
+		t.Errorf("counted %d more failed webhooks (%v), want 2", count-before, err)
+	}
+
+	webhooks, err := r.ListFailedCoreWebhooks(ctx, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var owned []domain.FailedCoreWebhook
+	for _, webhook := range webhooks {
+		if strings.HasPrefix(webhook.ShipmentUID, prefix) {
+			owned = append(owned, webhook)
+		}
+	}
+
+	if len(owned) != 2 || owned[0].ShipmentUID != older.ShipmentUID || owned[1].ShipmentUID != newer.ShipmentUID {
+		t.Fatalf("listed %+v, want the older then the newer webhook", owned)
+	}
+
+	got := owned[1]
+	if got.Version != 2 || got.Status != newer.Status || got.FailureReason != "recipient_unavailable" {
+		t.Errorf("webhook = %+v, want %+v", got.CoreWebhookInput, *newer)
+	}
+	if got.Error != "second failure" || got.Attempts != 2 {
+		t.Errorf("failure = (%q, %d attempts), want (%q, 2 attempts)", got.Error, got.Attempts, "second failure")
+	}
+	if !got.FirstFailedAt.Equal(at.Add(time.Minute)) || !got.LastFailedAt.Equal(at.Add(2*time.Minute)) {
+		t.Errorf("failed from %v to %v, want from %v to %v", got.FirstFailedAt, got.LastFailedAt, at.Add(time.Minute), at.Add(2*time.Minute))
+	}
+
+	if err := r.DeleteFailedCoreWebhook(ctx, older.ShipmentUID, older.Status); err != nil {
+		t.Fatal(err)
+	}
+
+	if count, err := r.CountFailedCoreWebhooks(ctx); err != nil || count-before != 1 {
+		t.Errorf("counted %d more failed webhooks after deleting one (%v), want 1", count-before, err)
+	}
+}
+
+func newNearShipment(uid string, center domain.Location, northDegrees float64, status string) *domain.Shipment {
+	shipment := newTestShipment(uid)
+	shipment.OriginPoint = domain.Location{Lat: center.Lat + northDegrees, Long: center.Long}
+	shipment.Status = status
+	return shipment
+}
+
+// ownedBy filters out uids of other tests sharing the database.
+func ownedBy(prefix string, uids []string) []string {
+	var owned []string
+	for _, uid := range uids {
+		if strings.HasPrefix(uid, prefix) {
+			owned = append(owned, uid)
+		}
+	}
+	return owned
+}
+
+func assertStatus(t *testing.T, r usecase.Repo, uid, want string) {
+	t.Helper()
+
+	shipment, err := r.GetShipment(context.Background(), uid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shipment.Status != want {
+		t.Errorf("%s: status = %q, want %q", uid, shipment.Status, want)
+	}
+}
+
+func assertShipment(t *testing.T, got, want *domain.Shipment) {
+	t.Helper()
+
+	if got.UID != want.UID ||
+		got.UserUID != want.UserUID ||
+		got.UserAddr != want.UserAddr ||
+		got.UserAddress != want.UserAddress ||
+		got.OriginPoint != want.OriginPoint ||
+		got.DestinationPoint != want.DestinationPoint ||
+		!got.ScheduledDeliveryMinTime.Equal(want.ScheduledDeliveryMinTime) ||
+		!got.ScheduledDeliveryMaxTime.Equal(want.ScheduledDeliveryMaxTime) ||
+		got.Status != want.Status ||
+		got.DistanceMeters != want.DistanceMeters ||
+		got.ETASeconds != want.ETASeconds ||
+		got.ZoneID != want.ZoneID ||
+		got.PriceAmount != want.PriceAmount ||
+		got.PriceCurrency != want.PriceCurrency {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
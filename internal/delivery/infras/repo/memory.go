@@ -0,0 +1,482 @@
+package repo
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/geo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+type failedKey struct {
+	shipmentUID string
+	status      string
+}
+
+type slotKey struct {
+	zoneID    string
+	startTime int64
+	endTime   int64
+}
+
+// memoryRepo is a usecase.Repo keeping everything in memory, for tests and local runs without postgres.
+// It honors the semantics of the postgres repo, including skipping shipments claimed by another worker.
+type memoryRepo struct {
+	mu        sync.Mutex
+	shipments map[string]*domain.Shipment
+	// order keeps insertion order so listings are deterministic
+	order []string
+	// claimed holds the shipments being dispatched, each with a channel closed once released
+	claimed map[string]chan struct{}
+	// dispatchAfter holds when shipments the 3pl could not be requested for are due again
+	dispatchAfter map[string]time.Time
+	slots         map[slotKey]*domain.SlotCapacity
+	history       map[string][]domain.ShipmentStatusChange
+	failed        map[failedKey]*domain.FailedCoreWebhook
+
+	// clock stamps the history, where the postgres repo uses the time of the database.
+	clock  usecase.Clock
+	logger *slog.Logger
+}
+
+var _ usecase.Repo = (*memoryRepo)(nil)
+
+func NewMemoryRepo(clock usecase.Clock, logger *slog.Logger) *memoryRepo {
+	return &memoryRepo{
+		shipments:     make(map[string]*domain.Shipment),
+		claimed:       make(map[string]chan struct{}),
+		dispatchAfter: make(map[string]time.Time),
+		slots:         make(map[slotKey]*domain.SlotCapacity),
+		history:       make(map[string][]domain.ShipmentStatusChange),
+		failed:        make(map[failedKey]*domain.FailedCoreWebhook),
+		clock:         clock,
+		logger:        logger,
+	}
+}
+
+func (r *memoryRepo) GetShipment(ctx context.Context, shipmentUID string) (*domain.Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shipment, ok := r.shipments[shipmentUID]
+	if !ok {
+		return nil, internal_error.ErrShipmentNotFound
+	}
+
+	copied := *shipment
+	return &copied, nil
+}
+
+func (r *memoryRepo) InsertShipment(ctx context.Context, shipment *domain.Shipment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.shipments[shipment.UID]; exists {
+		return internal_error.ErrShipmentExists
+	}
+
+	r.insert(shipment)
+
+	return nil
+}
+
+func (r *memoryRepo) InsertShipments(ctx context.Context, shipments []*domain.Shipment) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	inserted := make([]string, 0, len(shipments))
+	for _, shipment := range shipments {
+		if _, exists := r.shipments[shipment.UID]; exists {
+			continue
+		}
+
+		r.insert(shipment)
+		inserted = append(inserted, shipment.UID)
+	}
+
+	return inserted, nil
+}
+
+func (r *memoryRepo) SetShipmentStatus(ctx context.Context, shipmentUID string, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shipment, ok := r.shipments[shipmentUID]
+	if !ok {
+		return internal_error.ErrShipmentNotFound
+	}
+
+	r.setStatus(shipment, status)
+
+	return nil
+}
+
+func (r *memoryRepo) MergeShipmentTracking(ctx context.Context, shipmentUID string, update domain.Tracking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shipment, ok := r.shipments[shipmentUID]
+	if !ok {
+		return internal_error.ErrShipmentNotFound
+	}
+
+	shipment.Tracking.Merge(update)
+
+	return nil
+}
+
+func (r *memoryRepo) UpdateShipmentStatus(ctx context.Context, shipmentUID string, fromStatuses []string, status string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// like the postgres row locks, a claim holds the status until the dispatch is over, the update
+	// then goes on against the status the dispatch left
+	if err := r.waitUnclaimed(ctx, shipmentUID); err != nil {
+		return false, err
+	}
+
+	shipment, ok := r.shipments[shipmentUID]
+	if !ok || !slices.Contains(fromStatuses, shipment.Status) {
+		return false, nil
+	}
+
+	r.setStatus(shipment, status)
+
+	return true, nil
+}
+
+func (r *memoryRepo) ReserveSlot(ctx context.Context, zoneID string, hours []domain.ScheduledDeliveryWindow, defaultCapacity int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// every hour is checked before any is taken so a full one leaves the others untouched
+	for _, hour := range hours {
+		slot, ok := r.slots[newSlotKey(zoneID, hour)]
+		if !ok && defaultCapacity <= 0 || ok && slot.Reserved >= slot.Capacity {
+			return false, nil
+		}
+	}
+
+	for _, hour := range hours {
+		key := newSlotKey(zoneID, hour)
+
+		slot, ok := r.slots[key]
+		if !ok {
+			slot = &domain.SlotCapacity{
+				ZoneID:    zoneID,
+				StartTime: hour.StartTime,
+				EndTime:   hour.EndTime,
+				Capacity:  defaultCapacity,
+			}
+			r.slots[key] = slot
+		}
+
+		slot.Reserved++
+	}
+
+	return len(hours) > 0, nil
+}
+
+func (r *memoryRepo) ReleaseSlot(ctx context.Context, zoneID string, hours []domain.ScheduledDeliveryWindow) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, hour := range hours {
+		if slot, ok := r.slots[newSlotKey(zoneID, hour)]; ok && slot.Reserved > 0 {
+			slot.Reserved--
+		}
+	}
+
+	return nil
+}
+
+func (r *memoryRepo) ListSlotCapacities(ctx context.Context, zoneID string, from, to time.Time) ([]domain.SlotCapacity, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var capacities []domain.SlotCapacity
+	for _, slot := range r.slots {
+		if slot.ZoneID == zoneID && slot.StartTime.Before(to) && slot.EndTime.After(from) {
+			capacities = append(capacities, *slot)
+		}
+	}
+
+	sort.Slice(capacities, func(i, j int) bool {
+		if !capacities[i].StartTime.Equal(capacities[j].StartTime) {
+			return capacities[i].StartTime.Before(capacities[j].StartTime)
+		}
+		return capacities[i].EndTime.Before(capacities[j].EndTime)
+	})
+
+	return capacities, nil
+}
+
+func (r *memoryRepo) ListShipmentsNear(ctx context.Context, center domain.Location, radiusMeters float64, status string, limit int) ([]domain.Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	type candidate struct {
+		shipment domain.Shipment
+		distance float64
+	}
+
+	var candidates []candidate
+	for _, uid := range r.order {
+		shipment := r.shipments[uid]
+		if status != "" && shipment.Status != status {
+			continue
+		}
+
+		if distance := geo.Distance(center, shipment.OriginPoint); distance <= radiusMeters {
+			candidates = append(candidates, candidate{shipment: *shipment, distance: distance})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distance < candidates[j].distance
+	})
+
+	shipments := make([]domain.Shipment, 0, min(limit, len(candidates)))
+	for _, candidate := range candidates[:min(limit, len(candidates))] {
+		shipments = append(shipments, candidate.shipment)
+	}
+
+	return shipments, nil
+}
+
+func (r *memoryRepo) ListShipments(ctx context.Context, filter domain.ShipmentFilter, afterUID string, limit int) ([]domain.Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shipments []domain.Shipment
+	for _, shipment := range r.shipments {
+		if shipment.UID > afterUID && filter.Matches(shipment.UID, shipment.ZoneID, shipment.Status) {
+			shipments = append(shipments, *shipment)
+		}
+	}
+
+	sort.Slice(shipments, func(i, j int) bool {
+		return shipments[i].UID < shipments[j].UID
+	})
+
+	return shipments[:min(limit, len(shipments))], nil
+}
+
+func (r *memoryRepo) PromoteQueuedShipments(ctx context.Context, before time.Time, limit int) ([]domain.Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var shipments []domain.Shipment
+	for _, uid := range r.order {
+		if len(shipments) == limit {
+			break
+		}
+
+		shipment := r.shipments[uid]
+		if shipment.Status == "queued" && !shipment.ScheduledDeliveryMinTime.After(before) {
+			r.setStatus(shipment, "pending")
+			shipments = append(shipments, *shipment)
+		}
+	}
+
+	return shipments, nil
+}
+
+func (r *memoryRepo) ClaimPendingShipments(ctx context.Context, now, retryAt time.Time, limit int, dispatch func(shipments []domain.Shipment) []string) (int, error) {
+	r.mu.Lock()
+
+	var shipments []domain.Shipment
+	for _, uid := range r.order {
+		if len(shipments) == limit {
+			break
+		}
+
+		_, claimed := r.claimed[uid]
+		if shipment := r.shipments[uid]; shipment.Status == "pending" && !claimed && !r.dispatchAfter[uid].After(now) {
+			r.claimed[uid] = make(chan struct{})
+			shipments = append(shipments, *shipment)
+		}
+	}
+
+	r.mu.Unlock()
+
+	if len(shipments) == 0 {
+		return 0, nil
+	}
+
+	// like the postgres advisory locks, claims are held while dispatching without blocking the rest of the repo
+	requested := dispatch(shipments)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// only shipments still pending are requested, as in postgres: a webhook may have moved one on
+	for _, uid := range requested {
+		if shipment, ok := r.shipments[uid]; ok && shipment.Status == "pending" {
+			r.setStatus(shipment, "requested")
+		}
+	}
+
+	for _, shipment := range shipments {
+		if r.shipments[shipment.UID].Status == "pending" {
+			r.dispatchAfter[shipment.UID] = retryAt
+		} else {
+			delete(r.dispatchAfter, shipment.UID)
+		}
+
+		close(r.claimed[shipment.UID])
+		delete(r.claimed, shipment.UID)
+	}
+
+	return len(shipments), nil
+}
+
+func (r *memoryRepo) ListShipmentHistory(ctx context.Context, shipmentUID string) ([]domain.ShipmentStatusChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return slices.Clone(r.history[shipmentUID]), nil
+}
+
+func (r *memoryRepo) CountShipmentsByStatus(ctx context.Context) (map[string]int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := make(map[string]int)
+	for _, shipment := range r.shipments {
+		counts[shipment.Status]++
+	}
+
+	return counts, nil
+}
+
+func (r *memoryRepo) SaveFailedCoreWebhook(ctx context.Context, input *domain.CoreWebhookInput, failure string, failedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := failedKey{shipmentUID: input.ShipmentUID, status: input.Status}
+
+	webhook, ok := r.failed[key]
+	if !ok {
+		r.failed[key] = &domain.FailedCoreWebhook{
+			CoreWebhookInput: *input,
+			Error:            failure,
+			Attempts:         1,
+			FirstFailedAt:    failedAt,
+			LastFailedAt:     failedAt,
+		}
+		return nil
+	}
+
+	webhook.CoreWebhookInput = *input
+	webhook.Error = failure
+	webhook.Attempts++
+	webhook.LastFailedAt = failedAt
+
+	return nil
+}
+
+func (r *memoryRepo) ListFailedCoreWebhooks(ctx context.Context, limit int) ([]domain.FailedCoreWebhook, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	webhooks := make([]domain.FailedCoreWebhook, 0, len(r.failed))
+	for _, webhook := range r.failed {
+		webhooks = append(webhooks, *webhook)
+	}
+
+	sort.Slice(webhooks, func(i, j int) bool {
+		if !webhooks[i].FirstFailedAt.Equal(webhooks[j].FirstFailedAt) {
+			return webhooks[i].FirstFailedAt.Before(webhooks[j].FirstFailedAt)
+		}
+		if webhooks[i].ShipmentUID != webhooks[j].ShipmentUID {
+			return webhooks[i].ShipmentUID < webhooks[j].ShipmentUID
+		}
+		return webhooks[i].Status < webhooks[j].Status
+	})
+
+	return webhooks[:min(limit, len(webhooks))], nil
+}
+
+func (r *memoryRepo) CountFailedDispatches(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := 0
+	for uid := range r.dispatchAfter {
+		if r.shipments[uid].Status == "pending" {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func (r *memoryRepo) CountFailedCoreWebhooks(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return len(r.failed), nil
+}
+
+func (r *memoryRepo) DeleteFailedCoreWebhook(ctx context.Context, shipmentUID string, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.failed, failedKey{shipmentUID: shipmentUID, status: status})
+
+	return nil
+}
+
+// waitUnclaimed blocks until no dispatch claims the shipment, r.mu must be held and is released
+// while waiting.
+func (r *memoryRepo) waitUnclaimed(ctx context.Context, shipmentUID string) error {
+	for {
+		released, claimed := r.claimed[shipmentUID]
+		if !claimed {
+			return nil
+		}
+
+		r.mu.Unlock()
+		select {
+		case <-released:
+			r.mu.Lock()
+		case <-ctx.Done():
+			r.mu.Lock()
+			return ctx.Err()
+		}
+	}
+}
+
+// insert stores a copy of shipment, r.mu must be held.
+func (r *memoryRepo) insert(shipment *domain.Shipment) {
+	copied := *shipment
+	r.shipments[shipment.UID] = &copied
+	r.order = append(r.order, shipment.UID)
+	r.history[shipment.UID] = []domain.ShipmentStatusChange{{Status: shipment.Status, At: r.clock.Now()}}
+}
+
+// setStatus changes the status of shipment and records it in its history like the postgres
+// triggers do, r.mu must be held.
+func (r *memoryRepo) setStatus(shipment *domain.Shipment, status string) {
+	if shipment.Status == status {
+		return
+	}
+
+	shipment.Status = status
+	r.history[shipment.UID] = append(r.history[shipment.UID], domain.ShipmentStatusChange{Status: status, At: r.clock.Now()})
+}
+
+func newSlotKey(zoneID string, window domain.ScheduledDeliveryWindow) slotKey {
+	return slotKey{
+		zoneID:    zoneID,
+		startTime: window.StartTime.UnixNano(),
+		endTime:   window.EndTime.UnixNano(),
+	}
+}
@@ -3,35 +3,64 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/geo"
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+	"github.com/lib/pq"
 )
 
 type repo struct {
 	sqlDB  *sql.DB
 	logger *slog.Logger
+
+	// postGIS is whether the optional postgis migration created the geography columns.
+	postGIS bool
 }
 
+const shipmentColumns = `uid, user_uid, user_addr, user_address, origin_point, destination_point, scheduled_delivery_min_time, scheduled_delivery_max_time, status, distance_meters, eta_seconds, zone_id, price_amount, price_currency, tracking`
+
+// uniqueViolation is the postgres error code of unique constraint violations.
+const uniqueViolation = "23505"
+
+// dispatchLock keys the advisory locks claims hold on the shipments they dispatch, along with the
+// hashtext of their uid.
+const dispatchLock = 0x64697370
+
 var _ usecase.Repo = (*repo)(nil)
 
+// NewRepo detects whether postgis is available once, failing rather than falling back to plain
+// points for the life of the process when it cannot tell.
 func NewRepo(
+	ctx context.Context,
 	sqlDB *sql.DB,
 	logger *slog.Logger,
-) *repo {
-	return &repo{
-		sqlDB:  sqlDB,
-		logger: logger,
+) (*repo, error) {
+	postGIS, err := detectPostGIS(ctx, sqlDB)
+	if err != nil {
+		logger.Error("failed to detect postgis", slog.Any("infra", "repo"), slog.Any("error", err))
+		return nil, err
 	}
+
+	return &repo{
+		sqlDB:   sqlDB,
+		logger:  logger,
+		postGIS: postGIS,
+	}, nil
 }
 
 func (r *repo) GetShipment(ctx context.Context, shipmentUID string) (*domain.Shipment, error) {
 	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "get_shipment"))
 
 	queryStmt := `
-	SELECT uid, user_uid, user_addr, origin_point, destination_point, scheduled_delivery_min_time, scheduled_delivery_max_time, status
+	SELECT ` + shipmentColumns + `
 	FROM shipments
 	WHERE uid = $1
 	`
@@ -39,17 +68,11 @@ func (r *repo) GetShipment(ctx context.Context, shipmentUID string) (*domain.Shi
 	row := r.sqlDB.QueryRowContext(ctx, queryStmt, shipmentUID)
 
 	var shipment domain.Shipment
-	err := row.Scan(
-		&shipment.UID,
-		&shipment.UserUID,
-		&shipment.UserAddr,
-		&shipment.OriginPoint,
-		&shipment.DestinationPoint,
-		&shipment.ScheduledDeliveryMinTime,
-		&shipment.ScheduledDeliveryMaxTime,
-		&shipment.Status,
-	)
-	if err != nil {
+	if err := scanShipment(row, &shipment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, internal_error.ErrShipmentNotFound
+		}
+
 		logger.Error("error scanning shipment", slog.Any("error", err))
 		return nil, err
 	}
@@ -62,11 +85,14 @@ func (r *repo) InsertShipment(ctx context.Context, shipment *domain.Shipment) er
 
 	insertStmt := `
 		INSERT INTO shipments(
-			uid, user_uid, user_addr, 
+			uid, user_uid, user_addr, user_address,
 			origin_point, destination_point, 
 			scheduled_delivery_min_time, scheduled_delivery_max_time,
-			status
-		) VALUES($1, $2, $3, point($4, $5), point($6, $7), $8, $9, $10)`
+			status,
+			distance_meters, eta_seconds,
+			zone_id,
+			price_amount, price_currency
+		) VALUES($1, $2, $3, $4, $5::point, $6::point, $7, $8, $9, $10, $11, $12, $13, $14)`
 
 	if _, err := r.sqlDB.ExecContext(
 		ctx,
@@ -74,14 +100,23 @@ func (r *repo) InsertShipment(ctx context.Context, shipment *domain.Shipment) er
 		shipment.UID,
 		shipment.UserUID,
 		shipment.UserAddr,
-		shipment.OriginPoint.Lat,
-		shipment.OriginPoint.Long,
-		shipment.DestinationPoint.Lat,
-		shipment.DestinationPoint.Long,
+		shipment.UserAddress,
+		shipment.OriginPoint,
+		shipment.DestinationPoint,
 		shipment.ScheduledDeliveryMinTime,
 		shipment.ScheduledDeliveryMaxTime,
 		shipment.Status,
+		shipment.DistanceMeters,
+		shipment.ETASeconds,
+		shipment.ZoneID,
+		shipment.PriceAmount,
+		shipment.PriceCurrency,
 	); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == uniqueViolation {
+			return internal_error.ErrShipmentExists
+		}
+
 		logger.Error("failed to insert record", slog.Any("error", err))
 		return err
 	}
@@ -89,6 +124,146 @@ func (r *repo) InsertShipment(ctx context.Context, shipment *domain.Shipment) er
 	return nil
 }
 
+func (r *repo) InsertShipments(ctx context.Context, shipments []*domain.Shipment) ([]string, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "insert_shipments"))
+
+	var (
+		uids             = make([]string, len(shipments))
+		userUIDs         = make([]string, len(shipments))
+		userAddrs        = make([]string, len(shipments))
+		userAddresses    = make([]string, len(shipments))
+		originLongs      = make([]float64, len(shipments))
+		originLats       = make([]float64, len(shipments))
+		destinationLongs = make([]float64, len(shipments))
+		destinationLats  = make([]float64, len(shipments))
+		minTimes         = make([]string, len(shipments))
+		maxTimes         = make([]string, len(shipments))
+		statuses         = make([]string, len(shipments))
+		distances        = make([]float64, len(shipments))
+		etas             = make([]int64, len(shipments))
+		zoneIDs          = make([]string, len(shipments))
+		priceAmounts     = make([]int64, len(shipments))
+		priceCurrencies  = make([]string, len(shipments))
+	)
+	for i, shipment := range shipments {
+		uids[i] = shipment.UID
+		userUIDs[i] = shipment.UserUID
+		userAddrs[i] = shipment.UserAddr
+		userAddress, err := json.Marshal(shipment.UserAddress)
+		if err != nil {
+			logger.Error("failed to encode user address", slog.Any("error", err))
+			return nil, err
+		}
+		userAddresses[i] = string(userAddress)
+		originLongs[i] = shipment.OriginPoint.Long
+		originLats[i] = shipment.OriginPoint.Lat
+		destinationLongs[i] = shipment.DestinationPoint.Long
+		destinationLats[i] = shipment.DestinationPoint.Lat
+		minTimes[i] = shipment.ScheduledDeliveryMinTime.Format(time.RFC3339Nano)
+		maxTimes[i] = shipment.ScheduledDeliveryMaxTime.Format(time.RFC3339Nano)
+		statuses[i] = shipment.Status
+		distances[i] = shipment.DistanceMeters
+		etas[i] = int64(shipment.ETASeconds)
+		zoneIDs[i] = shipment.ZoneID
+		priceAmounts[i] = shipment.PriceAmount
+		priceCurrencies[i] = shipment.PriceCurrency
+	}
+
+	tx, err := r.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("error", err))
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	insertStmt := `
+		INSERT INTO shipments(
+			uid, user_uid, user_addr, user_address,
+			origin_point, destination_point,
+			scheduled_delivery_min_time, scheduled_delivery_max_time,
+			status,
+			distance_meters, eta_seconds,
+			zone_id,
+			price_amount, price_currency
+		)
+		SELECT
+			uid, user_uid, user_addr, user_address::jsonb,
+			point(origin_long, origin_lat), point(destination_long, destination_lat),
+			min_time, max_time,
+			status,
+			distance_meters, eta_seconds,
+			zone_id,
+			price_amount, price_currency
+		FROM unnest(
+			$1::text[], $2::text[], $3::text[], $4::text[],
+			$5::float8[], $6::float8[], $7::float8[], $8::float8[],
+			$9::timestamptz[], $10::timestamptz[],
+			$11::text[],
+			$12::float8[], $13::integer[],
+			$14::text[],
+			$15::bigint[], $16::text[]
+		) AS t(
+			uid, user_uid, user_addr, user_address,
+			origin_long, origin_lat, destination_long, destination_lat,
+			min_time, max_time,
+			status,
+			distance_meters, eta_seconds,
+			zone_id,
+			price_amount, price_currency
+		)
+		ON CONFLICT (uid) DO NOTHING
+		RETURNING uid;`
+
+	rows, err := tx.QueryContext(
+		ctx,
+		insertStmt,
+		pq.Array(uids),
+		pq.Array(userUIDs),
+		pq.Array(userAddrs),
+		pq.Array(userAddresses),
+		pq.Array(originLongs),
+		pq.Array(originLats),
+		pq.Array(destinationLongs),
+		pq.Array(destinationLats),
+		pq.Array(minTimes),
+		pq.Array(maxTimes),
+		pq.Array(statuses),
+		pq.Array(distances),
+		pq.Array(etas),
+		pq.Array(zoneIDs),
+		pq.Array(priceAmounts),
+		pq.Array(priceCurrencies),
+	)
+	if err != nil {
+		logger.Error("failed to insert records", slog.Any("error", err))
+		return nil, err
+	}
+
+	inserted := make([]string, 0, len(shipments))
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			rows.Close()
+			logger.Error("error scanning uid", slog.Any("error", err))
+			return nil, err
+		}
+		inserted = append(inserted, uid)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate inserted rows", slog.Any("error", err))
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("transaction commit failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	return inserted, nil
+}
+
 func (r *repo) SetShipmentStatus(ctx context.Context, shipmentUID string, status string) error {
 	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "set_shipment_status"))
 
@@ -103,6 +278,10 @@ func (r *repo) SetShipmentStatus(ctx context.Context, shipmentUID string, status
 
 	var uid string
 	if err := row.Scan(&uid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return internal_error.ErrShipmentNotFound
+		}
+
 		logger.Error("failed to scan row from update statement result", slog.String("shipment_uid", shipmentUID), slog.String("status", status), slog.Any("error", err))
 		return err
 	}
@@ -114,3 +293,764 @@ func (r *repo) SetShipmentStatus(ctx context.Context, shipmentUID string, status
 
 	return nil
 }
+
+func (r *repo) MergeShipmentTracking(ctx context.Context, shipmentUID string, update domain.Tracking) error {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "merge_shipment_tracking"))
+
+	// fields left out of update are omitted from its json: concatenating keeps their current value
+	updateStmt := `
+	UPDATE shipments
+	SET tracking = tracking || $1::jsonb
+	WHERE uid = $2;
+	`
+
+	result, err := r.sqlDB.ExecContext(ctx, updateStmt, update, shipmentUID)
+	if err != nil {
+		logger.Error("failed to merge tracking", slog.String("shipment_uid", shipmentUID), slog.Any("error", err))
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("failed to get affected rows", slog.Any("error", err))
+		return err
+	}
+
+	if affected == 0 {
+		return internal_error.ErrShipmentNotFound
+	}
+
+	return nil
+}
+
+func (r *repo) UpdateShipmentStatus(ctx context.Context, shipmentUID string, fromStatuses []string, status string) (bool, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "update_shipment_status"))
+
+	tx, err := r.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("error", err))
+		return false, err
+	}
+	defer tx.Rollback()
+
+	// a shipment being dispatched is updated once its claim is over, from the status it was left in
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, hashtext($2));`, dispatchLock, shipmentUID); err != nil {
+		logger.Error("failed to wait for the dispatch claim", slog.String("shipment_uid", shipmentUID), slog.Any("error", err))
+		return false, err
+	}
+
+	updateStmt := `
+	UPDATE shipments
+	SET status = $1
+	WHERE uid = $2
+	  AND status = ANY($3);
+	`
+
+	result, err := tx.ExecContext(ctx, updateStmt, status, shipmentUID, pq.Array(fromStatuses))
+	if err != nil {
+		logger.Error("failed to update status", slog.String("shipment_uid", shipmentUID), slog.String("status", status), slog.Any("error", err))
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("failed to get affected rows", slog.Any("error", err))
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("transaction commit failed", slog.Any("error", err))
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+// slotHoursStmt selects the hours starting at $2, see hourStarts.
+const slotHoursStmt = `
+	SELECT hour, hour + interval '1 hour'
+	FROM unnest($2::timestamptz[]) AS hour
+`
+
+// hourStarts encodes the starts of hours for slotHoursStmt.
+func hourStarts(hours []domain.ScheduledDeliveryWindow) any {
+	starts := make([]string, len(hours))
+	for i, hour := range hours {
+		starts[i] = hour.StartTime.Format(time.RFC3339Nano)
+	}
+	return pq.Array(starts)
+}
+
+func (r *repo) ReserveSlot(ctx context.Context, zoneID string, hours []domain.ScheduledDeliveryWindow, defaultCapacity int) (bool, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "reserve_slot"))
+
+	if len(hours) == 0 {
+		return false, nil
+	}
+
+	tx, err := r.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("error", err))
+		return false, err
+	}
+	defer tx.Rollback()
+
+	insertStmt := `
+	INSERT INTO slot_capacities(zone_id, start_time, end_time, capacity, reserved)
+	SELECT $1::text, hours.start_time, hours.end_time, $3::integer, 0
+	FROM (` + slotHoursStmt + `) AS hours(start_time, end_time)
+	WHERE $3::integer > 0
+	ON CONFLICT (zone_id, start_time, end_time) DO NOTHING;
+	`
+
+	if _, err := tx.ExecContext(ctx, insertStmt, zoneID, hourStarts(hours), defaultCapacity); err != nil {
+		logger.Error("failed to insert slot capacities", slog.String("zone_id", zoneID), slog.Any("error", err))
+		return false, err
+	}
+
+	// the hours are locked in order so overlapping windows reserved concurrently never deadlock
+	updateStmt := `
+	WITH locked AS (
+		SELECT zone_id, start_time, end_time
+		FROM slot_capacities
+		WHERE zone_id = $1
+		  AND (start_time, end_time) IN (` + slotHoursStmt + `)
+		ORDER BY start_time
+		FOR UPDATE
+	)
+	UPDATE slot_capacities
+	SET reserved = slot_capacities.reserved + 1
+	FROM locked
+	WHERE slot_capacities.zone_id = locked.zone_id
+	  AND slot_capacities.start_time = locked.start_time
+	  AND slot_capacities.end_time = locked.end_time
+	  AND slot_capacities.reserved < slot_capacities.capacity;
+	`
+
+	result, err := tx.ExecContext(ctx, updateStmt, zoneID, hourStarts(hours))
+	if err != nil {
+		logger.Error("failed to reserve slot", slog.String("zone_id", zoneID), slog.Any("error", err))
+		return false, err
+	}
+
+	reserved, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("failed to count reserved hours", slog.Any("error", err))
+		return false, err
+	}
+
+	// a full or missing hour rolls the others back
+	if reserved != int64(len(hours)) {
+		return false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("transaction commit failed", slog.Any("error", err))
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *repo) ReleaseSlot(ctx context.Context, zoneID string, hours []domain.ScheduledDeliveryWindow) error {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "release_slot"))
+
+	updateStmt := `
+	UPDATE slot_capacities
+	SET reserved = reserved - 1
+	WHERE zone_id = $1
+	  AND (start_time, end_time) IN (` + slotHoursStmt + `)
+	  AND reserved > 0;
+	`
+
+	if _, err := r.sqlDB.ExecContext(ctx, updateStmt, zoneID, hourStarts(hours)); err != nil {
+		logger.Error("failed to release slot", slog.String("zone_id", zoneID), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) ListSlotCapacities(ctx context.Context, zoneID string, from, to time.Time) ([]domain.SlotCapacity, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "list_slot_capacities"))
+
+	queryStmt := `
+	SELECT zone_id, start_time, end_time, capacity, reserved
+	FROM slot_capacities
+	WHERE zone_id = $1
+	  AND start_time < $3
+	  AND end_time > $2
+	ORDER BY start_time, end_time;
+	`
+
+	rows, err := r.sqlDB.QueryContext(ctx, queryStmt, zoneID, from, to)
+	if err != nil {
+		logger.Error("failed to query slot capacities", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var capacities []domain.SlotCapacity
+	for rows.Next() {
+		var capacity domain.SlotCapacity
+		if err := rows.Scan(
+			&capacity.ZoneID,
+			&capacity.StartTime,
+			&capacity.EndTime,
+			&capacity.Capacity,
+			&capacity.Reserved,
+		); err != nil {
+			logger.Error("error scanning slot capacity", slog.Any("error", err))
+			return nil, err
+		}
+		capacities = append(capacities, capacity)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate slot capacities", slog.Any("error", err))
+		return nil, err
+	}
+
+	return capacities, nil
+}
+
+func (r *repo) ListShipmentsNear(ctx context.Context, center domain.Location, radiusMeters float64, status string, limit int) ([]domain.Shipment, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "list_shipments_near"))
+
+	var (
+		rows *sql.Rows
+		err  error
+	)
+	if r.postGIS {
+		queryStmt := `
+		SELECT ` + shipmentColumns + `
+		FROM shipments
+		WHERE ST_DWithin(origin_geog, ST_SetSRID(ST_MakePoint($1::float8, $2::float8), 4326)::geography, $3::float8)
+		  AND ($4::text = '' OR status = $4::text)
+		ORDER BY origin_geog <-> ST_SetSRID(ST_MakePoint($1::float8, $2::float8), 4326)::geography
+		LIMIT $5;
+		`
+
+		rows, err = r.sqlDB.QueryContext(ctx, queryStmt, center.Long, center.Lat, radiusMeters, status, limit)
+	} else {
+		// without postgis candidates are prefiltered on a bounding box (using the point gist index)
+		// and then filtered on their haversine distance
+		queryStmt := `
+		SELECT ` + shipmentColumns + `
+		FROM (
+			SELECT *, 2 * $6::float8 * asin(least(1, sqrt(
+				power(sin(radians(origin_point[1] - $2::float8) / 2), 2) +
+				cos(radians($2::float8)) * cos(radians(origin_point[1])) * power(sin(radians(origin_point[0] - $1::float8) / 2), 2)
+			))) AS distance
+			FROM shipments
+			WHERE origin_point <@ box(point($7::float8, $8::float8), point($9::float8, $10::float8))
+			  AND ($4::text = '' OR status = $4::text)
+		) AS candidates
+		WHERE distance <= $3::float8
+		ORDER BY distance
+		LIMIT $5;
+		`
+
+		southWest, northEast := geo.BoundingBox(center, radiusMeters)
+		rows, err = r.sqlDB.QueryContext(
+			ctx,
+			queryStmt,
+			center.Long,
+			center.Lat,
+			radiusMeters,
+			status,
+			limit,
+			geo.EarthRadiusMeters,
+			southWest.Long,
+			southWest.Lat,
+			northEast.Long,
+			northEast.Lat,
+		)
+	}
+	if err != nil {
+		logger.Error("failed to query shipments", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []domain.Shipment
+	for rows.Next() {
+		var shipment domain.Shipment
+		if err := scanShipment(rows, &shipment); err != nil {
+			logger.Error("error scanning shipment", slog.Any("error", err))
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate shipments", slog.Any("error", err))
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+func (r *repo) ListShipments(ctx context.Context, filter domain.ShipmentFilter, afterUID string, limit int) ([]domain.Shipment, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "list_shipments"))
+
+	queryStmt := `
+	SELECT ` + shipmentColumns + `
+	FROM shipments
+	WHERE (cardinality($1::text[]) = 0 OR uid = ANY($1))
+	  AND (cardinality($2::text[]) = 0 OR zone_id = ANY($2))
+	  AND (cardinality($3::text[]) = 0 OR status = ANY($3))
+	  AND uid > $4
+	ORDER BY uid
+	LIMIT $5;
+	`
+
+	rows, err := r.sqlDB.QueryContext(
+		ctx,
+		queryStmt,
+		pq.Array(filter.ShipmentUIDs),
+		pq.Array(filter.ZoneIDs),
+		pq.Array(filter.Statuses),
+		afterUID,
+		limit,
+	)
+	if err != nil {
+		logger.Error("failed to query shipments", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []domain.Shipment
+	for rows.Next() {
+		var shipment domain.Shipment
+		if err := scanShipment(rows, &shipment); err != nil {
+			logger.Error("error scanning shipment", slog.Any("error", err))
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate shipments", slog.Any("error", err))
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+func (r *repo) PromoteQueuedShipments(ctx context.Context, before time.Time, limit int) ([]domain.Shipment, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "promote_queued_shipments"))
+
+	updateStmt := `
+	UPDATE shipments
+	SET status = 'pending'
+	WHERE uid IN (
+		SELECT uid FROM shipments
+		WHERE status = 'queued'
+		  AND scheduled_delivery_min_time <= $1
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING ` + shipmentColumns + `;
+	`
+
+	rows, err := r.sqlDB.QueryContext(ctx, updateStmt, before, limit)
+	if err != nil {
+		logger.Error("failed to execute batch update", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []domain.Shipment
+	for rows.Next() {
+		var shipment domain.Shipment
+		if err := scanShipment(rows, &shipment); err != nil {
+			logger.Error("error scanning shipment", slog.Any("error", err))
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate promoted rows", slog.Any("error", err))
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+func (r *repo) ClaimPendingShipments(ctx context.Context, now, retryAt time.Time, limit int, dispatch func(shipments []domain.Shipment) []string) (int, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "claim_pending_shipments"))
+
+	// claims hold advisory locks of their session rather than row locks so no transaction stays
+	// open while dispatching, the locks live and die with this connection
+	conn, err := r.sqlDB.Conn(ctx)
+	if err != nil {
+		logger.Error("failed to get a connection", slog.Any("error", err))
+		return 0, err
+	}
+	defer conn.Close()
+
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), `SELECT pg_advisory_unlock_all();`); err != nil {
+			logger.Error("failed to release the claim, dropping its connection", slog.Any("error", err))
+			conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+	}()
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("error", err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	// the rows stay locked while their advisory locks are taken, concurrent claims skip them either way
+	queryStmt := `
+	SELECT ` + shipmentColumns + `
+	FROM shipments
+	WHERE status = 'pending'
+	  AND (dispatch_after IS NULL OR dispatch_after <= $2)
+	LIMIT $1
+	FOR UPDATE SKIP LOCKED;
+	`
+
+	rows, err := tx.QueryContext(ctx, queryStmt, limit, now)
+	if err != nil {
+		logger.Error("failed to query", slog.Any("error", err))
+		return 0, err
+	}
+
+	candidates := make(map[string]domain.Shipment, limit)
+	uids := make([]string, 0, limit)
+	for rows.Next() {
+		var shipment domain.Shipment
+		if err := scanShipment(rows, &shipment); err != nil {
+			rows.Close()
+			logger.Error("error scanning shipment", slog.Any("error", err))
+			return 0, err
+		}
+		candidates[shipment.UID] = shipment
+		uids = append(uids, shipment.UID)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate pending rows", slog.Any("error", err))
+		return 0, err
+	}
+
+	// a status update of the shipment may be holding its lock already, see UpdateShipmentStatus
+	lockStmt := `
+	SELECT uid
+	FROM unnest($2::text[]) AS uid
+	WHERE pg_try_advisory_lock($1, hashtext(uid));
+	`
+
+	rows, err = tx.QueryContext(ctx, lockStmt, dispatchLock, pq.Array(uids))
+	if err != nil {
+		logger.Error("failed to lock shipments", slog.Any("error", err))
+		return 0, err
+	}
+
+	shipments := make([]domain.Shipment, 0, len(uids))
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			rows.Close()
+			logger.Error("error scanning locked uid", slog.Any("error", err))
+			return 0, err
+		}
+		shipments = append(shipments, candidates[uid])
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate locked uids", slog.Any("error", err))
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("transaction commit failed", slog.Any("error", err))
+		return 0, err
+	}
+
+	if len(shipments) == 0 {
+		return 0, nil
+	}
+
+	requested := dispatch(shipments)
+
+	tx, err = conn.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("error", err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	updateStmt := `
+	UPDATE shipments
+	SET status = 'requested', dispatch_after = NULL
+	WHERE uid = ANY($1)
+	  AND status = 'pending';
+	`
+
+	if _, err := tx.ExecContext(ctx, updateStmt, pq.Array(requested)); err != nil {
+		logger.Error("failed to execute batch update", slog.Any("error", err))
+		return 0, err
+	}
+
+	// the ones left pending are not claimed again before retryAt
+	claimed := make([]string, len(shipments))
+	for i, shipment := range shipments {
+		claimed[i] = shipment.UID
+	}
+
+	retryStmt := `
+	UPDATE shipments
+	SET dispatch_after = $2
+	WHERE uid = ANY($1)
+	  AND status = 'pending';
+	`
+
+	if _, err := tx.ExecContext(ctx, retryStmt, pq.Array(claimed), retryAt); err != nil {
+		logger.Error("failed to postpone unrequested shipments", slog.Any("error", err))
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("transaction commit failed", slog.Any("error", err))
+		return 0, err
+	}
+
+	return len(shipments), nil
+}
+
+func (r *repo) ListShipmentHistory(ctx context.Context, shipmentUID string) ([]domain.ShipmentStatusChange, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "list_shipment_history"))
+
+	queryStmt := `
+	SELECT status, changed_at
+	FROM shipment_status_history
+	WHERE shipment_uid = $1
+	ORDER BY id;
+	`
+
+	rows, err := r.sqlDB.QueryContext(ctx, queryStmt, shipmentUID)
+	if err != nil {
+		logger.Error("failed to query shipment history", slog.String("shipment_uid", shipmentUID), slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []domain.ShipmentStatusChange
+	for rows.Next() {
+		var change domain.ShipmentStatusChange
+		if err := rows.Scan(&change.Status, &change.At); err != nil {
+			logger.Error("error scanning status change", slog.Any("error", err))
+			return nil, err
+		}
+		history = append(history, change)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate shipment history", slog.Any("error", err))
+		return nil, err
+	}
+
+	return history, nil
+}
+
+func (r *repo) CountShipmentsByStatus(ctx context.Context) (map[string]int, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "count_shipments_by_status"))
+
+	queryStmt := `
+	SELECT status, count(*)
+	FROM shipments
+	GROUP BY status;
+	`
+
+	rows, err := r.sqlDB.QueryContext(ctx, queryStmt)
+	if err != nil {
+		logger.Error("failed to count shipments", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			logger.Error("error scanning status count", slog.Any("error", err))
+			return nil, err
+		}
+		counts[status] = count
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate status counts", slog.Any("error", err))
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+func (r *repo) SaveFailedCoreWebhook(ctx context.Context, input *domain.CoreWebhookInput, failure string, failedAt time.Time) error {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "save_failed_core_webhook"))
+
+	upsertStmt := `
+	INSERT INTO failed_core_webhooks (shipment_uid, status, version, tracking, error, first_failed_at, last_failed_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $6)
+	ON CONFLICT (shipment_uid, status) DO UPDATE
+	SET version        = EXCLUDED.version,
+	    tracking       = EXCLUDED.tracking,
+	    error          = EXCLUDED.error,
+	    attempts       = failed_core_webhooks.attempts + 1,
+	    last_failed_at = EXCLUDED.last_failed_at;
+	`
+
+	if _, err := r.sqlDB.ExecContext(
+		ctx,
+		upsertStmt,
+		input.ShipmentUID,
+		input.Status,
+		input.Version,
+		input.Tracking,
+		failure,
+		failedAt,
+	); err != nil {
+		logger.Error("failed to save failed core webhook", slog.String("shipment_uid", input.ShipmentUID), slog.String("status", input.Status), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) ListFailedCoreWebhooks(ctx context.Context, limit int) ([]domain.FailedCoreWebhook, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "list_failed_core_webhooks"))
+
+	queryStmt := `
+	SELECT shipment_uid, status, version, tracking, error, attempts, first_failed_at, last_failed_at
+	FROM failed_core_webhooks
+	ORDER BY first_failed_at, shipment_uid, status
+	LIMIT $1;
+	`
+
+	rows, err := r.sqlDB.QueryContext(ctx, queryStmt, limit)
+	if err != nil {
+		logger.Error("failed to query failed core webhooks", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []domain.FailedCoreWebhook
+	for rows.Next() {
+		var webhook domain.FailedCoreWebhook
+		if err := rows.Scan(
+			&webhook.ShipmentUID,
+			&webhook.Status,
+			&webhook.Version,
+			&webhook.Tracking,
+			&webhook.Error,
+			&webhook.Attempts,
+			&webhook.FirstFailedAt,
+			&webhook.LastFailedAt,
+		); err != nil {
+			logger.Error("error scanning failed core webhook", slog.Any("error", err))
+			return nil, err
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate failed core webhooks", slog.Any("error", err))
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *repo) CountFailedDispatches(ctx context.Context) (int, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "count_failed_dispatches"))
+
+	queryStmt := `
+	SELECT count(*)
+	FROM shipments
+	WHERE status = 'pending'
+	  AND dispatch_after IS NOT NULL;
+	`
+
+	var count int
+	if err := r.sqlDB.QueryRowContext(ctx, queryStmt).Scan(&count); err != nil {
+		logger.Error("failed to count failed dispatches", slog.Any("error", err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repo) CountFailedCoreWebhooks(ctx context.Context) (int, error) {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "count_failed_core_webhooks"))
+
+	var count int
+	if err := r.sqlDB.QueryRowContext(ctx, `SELECT count(*) FROM failed_core_webhooks;`).Scan(&count); err != nil {
+		logger.Error("failed to count failed core webhooks", slog.Any("error", err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repo) DeleteFailedCoreWebhook(ctx context.Context, shipmentUID string, status string) error {
+	logger := r.logger.With(slog.Any("infra", "repo"), slog.String("method", "delete_failed_core_webhook"))
+
+	deleteStmt := `
+	DELETE FROM failed_core_webhooks
+	WHERE shipment_uid = $1
+	  AND status = $2;
+	`
+
+	if _, err := r.sqlDB.ExecContext(ctx, deleteStmt, shipmentUID, status); err != nil {
+		logger.Error("failed to delete failed core webhook", slog.String("shipment_uid", shipmentUID), slog.String("status", status), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+// detectPostGIS reports whether the optional postgis migration created the geography columns.
+func detectPostGIS(ctx context.Context, sqlDB *sql.DB) (bool, error) {
+	queryStmt := `
+	SELECT EXISTS (
+		SELECT 1 FROM information_schema.columns
+		WHERE table_name = 'shipments' AND column_name = 'origin_geog'
+	);
+	`
+
+	var postGIS bool
+	err := sqlDB.QueryRowContext(ctx, queryStmt).Scan(&postGIS)
+	return postGIS, err
+}
+
+func scanShipment(row interface{ Scan(dest ...any) error }, shipment *domain.Shipment) error {
+	return row.Scan(
+		&shipment.UID,
+		&shipment.UserUID,
+		&shipment.UserAddr,
+		&shipment.UserAddress,
+		&shipment.OriginPoint,
+		&shipment.DestinationPoint,
+		&shipment.ScheduledDeliveryMinTime,
+		&shipment.ScheduledDeliveryMaxTime,
+		&shipment.Status,
+		&shipment.DistanceMeters,
+		&shipment.ETASeconds,
+		&shipment.ZoneID,
+		&shipment.PriceAmount,
+		&shipment.PriceCurrency,
+		&shipment.Tracking,
+	)
+}
@@ -0,0 +1,121 @@
+package repo_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/repo"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+)
+
+// newTestDB connects to the postgres pointed by DATABASE_URL and applies the migrations.
+// Tests are skipped when it is not set.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://../../../../migrations", "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func newTestShipment(uid string) *domain.Shipment {
+	start := time.Now().Add(time.Hour).Truncate(time.Hour)
+
+	return &domain.Shipment{
+		UID:                      uid,
+		UserUID:                  "user_" + uid,
+		UserAddr:                 "address_" + uid,
+		UserAddress:              domain.Address{Street: "address_" + uid, City: "Tehran", Country: "IR"},
+		OriginPoint:              domain.Location{Lat: 35.689197, Long: 51.388974},
+		DestinationPoint:         domain.Location{Lat: -33.868820000000001, Long: 151.20929300000001},
+		ScheduledDeliveryMinTime: start,
+		ScheduledDeliveryMaxTime: start.Add(2 * time.Hour),
+		Status:                   "queued",
+	}
+}
+
+func TestShipmentPointsRoundTrip(t *testing.T) {
+	db := newTestDB(t)
+	ctx := context.Background()
+	r, err := repo.NewRepo(ctx, db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	single := newTestShipment("test_points_round_trip_single")
+	batch := newTestShipment("test_points_round_trip_batch")
+
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM shipments WHERE uid = ANY(ARRAY[$1, $2])`, single.UID, batch.UID)
+	})
+
+	if err := r.InsertShipment(ctx, single); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := r.InsertShipments(ctx, []*domain.Shipment{batch}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []*domain.Shipment{single, batch} {
+		got, err := r.GetShipment(ctx, want.UID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.OriginPoint != want.OriginPoint {
+			t.Errorf("%s: origin_point = %v, want %v", want.UID, got.OriginPoint, want.OriginPoint)
+		}
+
+		if got.DestinationPoint != want.DestinationPoint {
+			t.Errorf("%s: destination_point = %v, want %v", want.UID, got.DestinationPoint, want.DestinationPoint)
+		}
+
+		if got.UserAddress != want.UserAddress {
+			t.Errorf("%s: user_address = %+v, want %+v", want.UID, got.UserAddress, want.UserAddress)
+		}
+
+		// the stored point itself must be (long, lat)
+		var x, y float64
+		if err := db.QueryRow(`SELECT origin_point[0], origin_point[1] FROM shipments WHERE uid = $1`, want.UID).Scan(&x, &y); err != nil {
+			t.Fatal(err)
+		}
+
+		if x != want.OriginPoint.Long || y != want.OriginPoint.Lat {
+			t.Errorf("%s: stored origin_point = (%v,%v), want (long, lat) = (%v,%v)", want.UID, x, y, want.OriginPoint.Long, want.OriginPoint.Lat)
+		}
+	}
+}
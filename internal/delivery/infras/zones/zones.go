@@ -0,0 +1,210 @@
+package zones
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/geo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+type zones struct {
+	zones  []domain.Zone
+	byID   map[string]*domain.Zone
+	logger *slog.Logger
+}
+
+var _ usecase.Zones = (*zones)(nil)
+
+func NewZones(list []domain.Zone, logger *slog.Logger) *zones {
+	z := &zones{
+		zones:  list,
+		byID:   make(map[string]*domain.Zone, len(list)),
+		logger: logger,
+	}
+
+	for i := range z.zones {
+		z.byID[z.zones[i].ID] = &z.zones[i]
+	}
+
+	return z
+}
+
+// LoadFile reads zones from a GeoJSON FeatureCollection of Polygon/MultiPolygon features.
+// Zone settings are read from the feature properties: id, timezone, cutoff_hour and preferred_3pl.
+func LoadFile(path string, logger *slog.Logger) (*zones, error) {
+	logger = logger.With(slog.String("infra", "zones"), slog.String("path", path))
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("failed to read zones file", slog.Any("error", err))
+		return nil, err
+	}
+
+	var collection struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry   json.RawMessage `json:"geometry"`
+			Properties struct {
+				ID           string `json:"id"`
+				Timezone     string `json:"timezone"`
+				CutoffHour   *int   `json:"cutoff_hour"`
+				Preferred3PL string `json:"preferred_3pl"`
+			} `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		logger.Error("failed to decode zones file", slog.Any("error", err))
+		return nil, err
+	}
+
+	if collection.Type != "FeatureCollection" {
+		return nil, fmt.Errorf("zones file must be a geojson FeatureCollection, got %q", collection.Type)
+	}
+
+	// ids index zones: the postgres table has them as primary key, the file is checked here
+	var v internal_error.Violations
+	seen := make(map[string]int, len(collection.Features))
+
+	list := make([]domain.Zone, 0, len(collection.Features))
+	for i, feature := range collection.Features {
+		if first, ok := seen[feature.Properties.ID]; ok && feature.Properties.ID != "" {
+			v.Add(
+				fmt.Sprintf("features[%d].properties.id", i),
+				internal_error.CodeDuplicate,
+				fmt.Sprintf("is already the id of feature %d", first),
+			)
+			continue
+		}
+		seen[feature.Properties.ID] = i
+
+		cutoffHour := domain.DefaultCutoffHour
+		if feature.Properties.CutoffHour != nil {
+			cutoffHour = *feature.Properties.CutoffHour
+		}
+
+		zone, err := newZone(
+			feature.Properties.ID,
+			feature.Geometry,
+			feature.Properties.Timezone,
+			cutoffHour,
+			feature.Properties.Preferred3PL,
+		)
+		if err != nil {
+			logger.Error("invalid zone feature", slog.Int("index", i), slog.Any("error", err))
+			return nil, fmt.Errorf("feature %d: %w", i, err)
+		}
+
+		list = append(list, *zone)
+	}
+
+	if err := v.Err(); err != nil {
+		logger.Error("invalid zones file", slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.Info("loaded zones", slog.Int("count", len(list)))
+
+	return NewZones(list, logger), nil
+}
+
+// LoadDB reads zones from the zones table.
+func LoadDB(ctx context.Context, sqlDB *sql.DB, logger *slog.Logger) (*zones, error) {
+	logger = logger.With(slog.String("infra", "zones"))
+
+	rows, err := sqlDB.QueryContext(ctx, `SELECT id, geometry, timezone, cutoff_hour, preferred_3pl FROM zones ORDER BY id`)
+	if err != nil {
+		logger.Error("failed to query zones", slog.Any("error", err))
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []domain.Zone
+	for rows.Next() {
+		var (
+			id, timezone, preferred3PL string
+			geometry                   []byte
+			cutoffHour                 int
+		)
+		if err := rows.Scan(&id, &geometry, &timezone, &cutoffHour, &preferred3PL); err != nil {
+			logger.Error("error scanning zone", slog.Any("error", err))
+			return nil, err
+		}
+
+		zone, err := newZone(id, geometry, timezone, cutoffHour, preferred3PL)
+		if err != nil {
+			logger.Error("invalid zone", slog.String("zone_id", id), slog.Any("error", err))
+			return nil, fmt.Errorf("zone %s: %w", id, err)
+		}
+
+		list = append(list, *zone)
+	}
+
+	if err := rows.Err(); err != nil {
+		logger.Error("failed to iterate zones", slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.Info("loaded zones", slog.Int("count", len(list)))
+
+	return NewZones(list, logger), nil
+}
+
+func newZone(id string, geometry []byte, timezone string, cutoffHour int, preferred3PL string) (*domain.Zone, error) {
+	if id == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	polygons, err := geo.ParseGeoJSONGeometry(geometry)
+	if err != nil {
+		return nil, err
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	if cutoffHour < 0 || cutoffHour > 24 {
+		return nil, fmt.Errorf("cutoff_hour must be between 0 and 24")
+	}
+
+	return &domain.Zone{
+		ID:           id,
+		Polygons:     polygons,
+		Timezone:     location,
+		CutoffHour:   cutoffHour,
+		Preferred3PL: preferred3PL,
+	}, nil
+}
+
+func (z *zones) Locate(ctx context.Context, location domain.Location) (*domain.Zone, error) {
+	for i := range z.zones {
+		if geo.MultiPolygonContains(z.zones[i].Polygons, location) {
+			return &z.zones[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (z *zones) Get(ctx context.Context, zoneID string) (*domain.Zone, error) {
+	zone, ok := z.byID[zoneID]
+	if !ok {
+		return nil, internal_error.NotFoundError(fmt.Sprintf("zone %s not found", zoneID))
+	}
+	return zone, nil
+}
+
+func (z *zones) Len() int {
+	return len(z.zones)
+}
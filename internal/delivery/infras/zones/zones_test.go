@@ -0,0 +1,189 @@
+package zones_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/zones"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+)
+
+func newLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func TestLoadFile(t *testing.T) {
+	z, err := zones.LoadFile("testdata/zones.geojson", newLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if z.Len() != 2 {
+		t.Fatalf("loaded %d zones, want 2", z.Len())
+	}
+
+	assertZones(t, z, "tehran", "karaj")
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	square := `{"type": "Polygon", "coordinates": [[[51.2, 35.5], [51.6, 35.5], [51.6, 35.8], [51.2, 35.5]]]}`
+
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"not geojson", `[]`},
+		{"not a feature collection", `{"type": "Feature"}`},
+		{"missing id", `{"type": "FeatureCollection", "features": [{"geometry": ` + square + `}]}`},
+		{"unknown timezone", `{"type": "FeatureCollection", "features": [{"properties": {"id": "a", "timezone": "Mars/Olympus"}, "geometry": ` + square + `}]}`},
+		{"cutoff hour out of range", `{"type": "FeatureCollection", "features": [{"properties": {"id": "a", "cutoff_hour": 25}, "geometry": ` + square + `}]}`},
+		{"point geometry", `{"type": "FeatureCollection", "features": [{"properties": {"id": "a"}, "geometry": {"type": "Point", "coordinates": [51.2, 35.5]}}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "zones.geojson")
+			if err := os.WriteFile(path, []byte(tt.content), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := zones.LoadFile(path, newLogger()); err == nil {
+				t.Error("loaded an invalid zones file")
+			}
+		})
+	}
+
+	if _, err := zones.LoadFile("testdata/missing.geojson", newLogger()); err == nil {
+		t.Error("loaded a missing zones file")
+	}
+
+	path := filepath.Join(t.TempDir(), "zones.geojson")
+	duplicate := `{"type": "FeatureCollection", "features": [` +
+		`{"properties": {"id": "a"}, "geometry": ` + square + `}, {"properties": {"id": "a"}, "geometry": ` + square + `}]}`
+	if err := os.WriteFile(path, []byte(duplicate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var validationErr internal_error.ValidationError
+	if _, err := zones.LoadFile(path, newLogger()); !errors.As(err, &validationErr) ||
+		validationErr.Violations[0].Field != "features[1].properties.id" || validationErr.Violations[0].Code != internal_error.CodeDuplicate {
+		t.Errorf("loading duplicate zone ids: %v, want a duplicate violation on the second feature", err)
+	}
+}
+
+func TestLoadDB(t *testing.T) {
+	connStr := os.Getenv("DATABASE_URL")
+	if connStr == "" {
+		t.Skip("DATABASE_URL is not set")
+	}
+
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance("file://../../../../migrations", "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	// zones are loaded all at once, the ones of other tests would be counted along
+	prefix := fmt.Sprintf("zones_%d_", time.Now().UnixNano())
+	t.Cleanup(func() {
+		db.Exec(`DELETE FROM zones WHERE id LIKE $1 || '%'`, prefix)
+	})
+
+	if _, err := db.Exec(`
+	INSERT INTO zones(id, geometry, timezone, cutoff_hour, preferred_3pl)
+	VALUES
+		($1, '{"type": "Polygon", "coordinates": [[[51.20, 35.55], [51.62, 35.55], [51.62, 35.83], [51.20, 35.83], [51.20, 35.55]], [[51.30, 35.60], [51.35, 35.60], [51.35, 35.65], [51.30, 35.65], [51.30, 35.60]]]}', 'Asia/Tehran', 21, 'fast'),
+		($2, '{"type": "MultiPolygon", "coordinates": [[[[50.90, 35.78], [51.05, 35.78], [51.05, 35.88], [50.90, 35.88], [50.90, 35.78]]]]}', DEFAULT, DEFAULT, DEFAULT);
+	`, prefix+"tehran", prefix+"karaj"); err != nil {
+		t.Fatal(err)
+	}
+
+	z, err := zones.LoadDB(context.Background(), db, newLogger())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertZones(t, z, prefix+"tehran", prefix+"karaj")
+}
+
+// assertZones checks z holds the zones of testdata/zones.geojson with the given ids.
+func assertZones(t *testing.T, z usecase.Zones, tehranID, karajID string) {
+	t.Helper()
+
+	ctx := context.Background()
+
+	tehran, err := z.Get(ctx, tehranID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tehran.Timezone.String() != "Asia/Tehran" || tehran.CutoffHour != 21 || tehran.Preferred3PL != "fast" {
+		t.Errorf("tehran settings = %v, %d, %q, want Asia/Tehran, 21, fast", tehran.Timezone, tehran.CutoffHour, tehran.Preferred3PL)
+	}
+
+	// settings left out fall back to their defaults
+	karaj, err := z.Get(ctx, karajID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if karaj.Timezone != time.UTC || karaj.CutoffHour != domain.DefaultCutoffHour || karaj.Preferred3PL != "" {
+		t.Errorf("karaj settings = %v, %d, %q, want the defaults", karaj.Timezone, karaj.CutoffHour, karaj.Preferred3PL)
+	}
+
+	if _, err := z.Get(ctx, "missing"); !errors.As(err, new(internal_error.NotFoundError)) {
+		t.Errorf("getting a missing zone returned %v, want a not found error", err)
+	}
+
+	for _, tt := range []struct {
+		name     string
+		location domain.Location
+		zoneID   string
+	}{
+		{"inside a polygon", domain.Location{Lat: 35.7, Long: 51.4}, tehranID},
+		{"inside a multipolygon", domain.Location{Lat: 35.8, Long: 51.0}, karajID},
+		{"inside a hole", domain.Location{Lat: 35.62, Long: 51.32}, ""},
+		{"outside", domain.Location{Lat: 29.6, Long: 52.5}, ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			zone, err := z.Locate(ctx, tt.location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var zoneID string
+			if zone != nil {
+				zoneID = zone.ID
+			}
+			if zoneID != tt.zoneID {
+				t.Errorf("located in %q, want %q", zoneID, tt.zoneID)
+			}
+		})
+	}
+}
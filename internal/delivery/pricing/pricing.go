@@ -0,0 +1,175 @@
+package pricing
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+var (
+	ErrInvalidQuote  = errors.New("quote token is invalid")
+	ErrExpiredQuote  = errors.New("quote token has expired")
+	ErrQuoteMismatch = errors.New("quote token was issued for another routing info or window")
+)
+
+// PeakHours applies Multiplier to windows starting within [From, To) local hours.
+type PeakHours struct {
+	From       int
+	To         int
+	Multiplier float64
+}
+
+type Config struct {
+	Currency string
+	// BaseFare and PerKm are in the currency's minor unit.
+	BaseFare int64
+	PerKm    int64
+	// ZoneMultipliers scales prices per zone id. Zones missing from it are not scaled.
+	ZoneMultipliers map[string]float64
+	PeakHours       []PeakHours
+	// DemandFactor is how much a full slot increases the price: the demand multiplier
+	// goes linearly from 1 for an empty slot to 1 + DemandFactor for a full one.
+	DemandFactor float64
+	// QuoteTTL is how long a quote can be booked with.
+	QuoteTTL time.Duration
+	// Secret signs quote tokens. It has to be shared by every instance of the service.
+	Secret []byte
+}
+
+type PriceInput struct {
+	DistanceMeters float64
+	ZoneID         string
+	// WindowStart is expected in the zone's timezone so peak hours match the local time.
+	WindowStart time.Time
+	// Utilization is the reserved share of the window's slot, between 0 and 1.
+	Utilization float64
+}
+
+type Engine struct {
+	config *Config
+}
+
+func NewEngine(config *Config) *Engine {
+	return &Engine{config: config}
+}
+
+func (e *Engine) Price(input *PriceInput) domain.Price {
+	breakdown := domain.PriceBreakdown{
+		BaseFare:         e.config.BaseFare,
+		DistanceFare:     int64(math.Round(input.DistanceMeters / 1000 * float64(e.config.PerKm))),
+		DistanceMeters:   input.DistanceMeters,
+		ZoneMultiplier:   1,
+		PeakMultiplier:   1,
+		DemandMultiplier: 1 + e.config.DemandFactor*math.Min(math.Max(input.Utilization, 0), 1),
+	}
+
+	if multiplier, ok := e.config.ZoneMultipliers[input.ZoneID]; ok {
+		breakdown.ZoneMultiplier = multiplier
+	}
+
+	hour := input.WindowStart.Hour()
+	for _, peak := range e.config.PeakHours {
+		if hour >= peak.From && hour < peak.To {
+			breakdown.PeakMultiplier = peak.Multiplier
+			break
+		}
+	}
+
+	amount := float64(breakdown.BaseFare+breakdown.DistanceFare) *
+		breakdown.ZoneMultiplier *
+		breakdown.PeakMultiplier *
+		breakdown.DemandMultiplier
+
+	return domain.Price{
+		Amount:    int64(math.Round(amount)),
+		Currency:  e.config.Currency,
+		Breakdown: breakdown,
+	}
+}
+
+type claims struct {
+	Price       domain.Price `json:"price"`
+	ExpiresAt   int64        `json:"expires_at"`
+	Fingerprint string       `json:"fingerprint"`
+}
+
+// IssueQuote signs price into a token bound to the routing info and window it was computed for.
+func (e *Engine) IssueQuote(price domain.Price, routingInfo domain.RoutingInfo, window domain.ScheduledDeliveryWindow, now time.Time) (*domain.QuoteResult, error) {
+	expiresAt := now.Add(e.config.QuoteTTL).Truncate(time.Second)
+
+	payload, err := json.Marshal(claims{
+		Price:       price,
+		ExpiresAt:   expiresAt.Unix(),
+		Fingerprint: fingerprint(routingInfo, window),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return &domain.QuoteResult{
+		Token:     encoded + "." + e.sign(encoded),
+		Price:     price,
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// VerifyQuote returns the price a token was issued with if it is authentic, not expired
+// and was issued for the same routing info and window.
+func (e *Engine) VerifyQuote(token string, routingInfo domain.RoutingInfo, window domain.ScheduledDeliveryWindow, now time.Time) (*domain.Price, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(e.sign(encoded))) {
+		return nil, ErrInvalidQuote
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidQuote
+	}
+
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidQuote
+	}
+
+	if !now.Before(time.Unix(c.ExpiresAt, 0)) {
+		return nil, ErrExpiredQuote
+	}
+
+	if c.Fingerprint != fingerprint(routingInfo, window) {
+		return nil, ErrQuoteMismatch
+	}
+
+	return &c.Price, nil
+}
+
+func (e *Engine) sign(encoded string) string {
+	mac := hmac.New(sha256.New, e.config.Secret)
+	mac.Write([]byte(encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func fingerprint(routingInfo domain.RoutingInfo, window domain.ScheduledDeliveryWindow) string {
+	h := sha256.New()
+	for _, v := range []float64{
+		routingInfo.Origin.Long,
+		routingInfo.Origin.Lat,
+		routingInfo.Destination.Long,
+		routingInfo.Destination.Lat,
+	} {
+		h.Write([]byte(strconv.FormatFloat(v, 'f', -1, 64) + "|"))
+	}
+	h.Write([]byte(strconv.FormatInt(window.StartTime.UnixNano(), 10) + "|"))
+	h.Write([]byte(strconv.FormatInt(window.EndTime.UnixNano(), 10)))
+	return hex.EncodeToString(h.Sum(nil))
+}
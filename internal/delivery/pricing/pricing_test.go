@@ -0,0 +1,86 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+func TestPrice(t *testing.T) {
+	engine := NewEngine(&Config{
+		Currency:        "USD",
+		BaseFare:        300,
+		PerKm:           100,
+		ZoneMultipliers: map[string]float64{"downtown": 1.5},
+		PeakHours:       []PeakHours{{From: 17, To: 21, Multiplier: 2}},
+		DemandFactor:    1,
+	})
+
+	offPeak := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	peak := time.Date(2026, 1, 1, 18, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		input PriceInput
+		want  int64
+	}{
+		{"base", PriceInput{DistanceMeters: 2000, WindowStart: offPeak}, 500},
+		{"zone", PriceInput{DistanceMeters: 2000, ZoneID: "downtown", WindowStart: offPeak}, 750},
+		{"peak", PriceInput{DistanceMeters: 2000, WindowStart: peak}, 1000},
+		{"demand", PriceInput{DistanceMeters: 2000, WindowStart: offPeak, Utilization: 0.5}, 750},
+		{"demand is capped", PriceInput{DistanceMeters: 2000, WindowStart: offPeak, Utilization: 3}, 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price := engine.Price(&tt.input)
+			if price.Amount != tt.want || price.Currency != "USD" {
+				t.Fatalf("got %d %s, want %d USD", price.Amount, price.Currency, tt.want)
+			}
+		})
+	}
+}
+
+func TestQuote(t *testing.T) {
+	engine := NewEngine(&Config{Currency: "USD", BaseFare: 300, QuoteTTL: time.Minute, Secret: []byte("secret")})
+
+	now := time.Now()
+	routing := domain.RoutingInfo{
+		Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+		Destination: domain.Location{Lat: 35.8, Long: 51.5},
+	}
+	window := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+	price := engine.Price(&PriceInput{})
+
+	quote, err := engine.IssueQuote(price, routing, window, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := engine.VerifyQuote(quote.Token, routing, window, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Amount != price.Amount {
+		t.Fatalf("got amount %d, want %d", got.Amount, price.Amount)
+	}
+
+	if _, err := engine.VerifyQuote(quote.Token, routing, window, now.Add(time.Minute)); err != ErrExpiredQuote {
+		t.Fatalf("got %v, want %v", err, ErrExpiredQuote)
+	}
+
+	other := routing
+	other.Destination.Lat++
+	if _, err := engine.VerifyQuote(quote.Token, other, window, now); err != ErrQuoteMismatch {
+		t.Fatalf("got %v, want %v", err, ErrQuoteMismatch)
+	}
+
+	if _, err := engine.VerifyQuote(quote.Token+"x", routing, window, now); err != ErrInvalidQuote {
+		t.Fatalf("got %v, want %v", err, ErrInvalidQuote)
+	}
+
+	forged := NewEngine(&Config{QuoteTTL: time.Minute, Secret: []byte("other")})
+	if _, err := forged.VerifyQuote(quote.Token, routing, window, now); err != ErrInvalidQuote {
+		t.Fatalf("got %v, want %v", err, ErrInvalidQuote)
+	}
+}
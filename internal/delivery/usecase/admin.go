@@ -0,0 +1,286 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+)
+
+// redispatchableStatuses are the statuses of shipments handed to the 3pl that no courier has picked
+// up yet: a delivery guy can be requested again for them.
+var redispatchableStatuses = []string{"requested", "searching", "not_found"}
+
+var _ Admin = (*usecase)(nil)
+
+func (u *usecase) History(ctx context.Context, input *domain.HistoryInput) (*domain.HistoryResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "history"), slog.String("shipment_uid", input.ShipmentUID))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	history, err := u.repo.ListShipmentHistory(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to list shipment history", slog.Any("error", err))
+		return nil, err
+	}
+
+	if history == nil {
+		history = []domain.ShipmentStatusChange{}
+	}
+
+	return &domain.HistoryResult{Shipment: *shipment, History: history}, nil
+}
+
+func (u *usecase) Transition(ctx context.Context, input *domain.TransitionInput) (*domain.TransitionResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "transition"), slog.String("shipment_uid", input.ShipmentUID), slog.String("status", input.Status))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	if !domain.CanTransition(shipment.Status, input.Status) {
+		logger.Info("transition is not allowed", slog.String("from", shipment.Status))
+		return nil, errInvalidTransition(shipment.Status, input.Status)
+	}
+
+	// the shipment may have moved on since it was read: it only transitions from the status checked
+	updated, err := u.repo.UpdateShipmentStatus(ctx, input.ShipmentUID, []string{shipment.Status}, input.Status)
+	if err != nil {
+		logger.Error("failed to update shipment status", slog.Any("error", err))
+		return nil, err
+	}
+
+	if !updated {
+		logger.Info("shipment status changed concurrently", slog.String("from", shipment.Status))
+		return nil, internal_error.ConflictError{
+			Code:    internal_error.CodeInvalidTransition,
+			Message: fmt.Sprintf("shipment is no longer %s, inspect it again", shipment.Status),
+		}
+	}
+
+	logger.Warn("shipment status forced", slog.String("from", shipment.Status))
+
+	if input.Status == "cancelled" {
+		u.releaseSlot(ctx, logger, shipment.ZoneID, domain.ScheduledDeliveryWindow{
+			StartTime: shipment.ScheduledDeliveryMinTime,
+			EndTime:   shipment.ScheduledDeliveryMaxTime,
+		})
+	}
+
+	shipment.Status = input.Status
+	u.publish(ctx, logger, shipment)
+	u.notifyCore(ctx, logger, shipment)
+
+	return &domain.TransitionResult{Shipment: *shipment}, nil
+}
+
+func (u *usecase) Redispatch(ctx context.Context, input *domain.RedispatchInput) (*domain.RedispatchResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "redispatch"), slog.String("shipment_uid", input.ShipmentUID))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	if !slices.Contains(redispatchableStatuses, shipment.Status) {
+		logger.Info("shipment is not redispatchable", slog.String("status", shipment.Status))
+		return nil, internal_error.ConflictError{
+			Code: internal_error.CodeNotRedispatchable,
+			Message: fmt.Sprintf(
+				"shipment cannot be re-dispatched once %s, only while %s",
+				shipment.Status,
+				strings.Join(redispatchableStatuses, ", "),
+			),
+		}
+	}
+
+	zone, err := u.shipmentZone(ctx, logger, shipment.ZoneID)
+	if err != nil {
+		logger.Error("failed to fetch zone", slog.String("zone_id", shipment.ZoneID), slog.Any("error", err))
+		return nil, err
+	}
+
+	if _, err := u._3pl.RequestDeliveryGuy(ctx, &domain.ThirdPartyLogisticsRequestDeliveryGuyInput{
+		ShipmentUID: shipment.UID,
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      shipment.OriginPoint,
+			Destination: shipment.DestinationPoint,
+		},
+		ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+			StartTime: shipment.ScheduledDeliveryMinTime,
+			EndTime:   shipment.ScheduledDeliveryMaxTime,
+		},
+		ZoneID:   shipment.ZoneID,
+		Provider: preferred3PL(zone),
+	}); err != nil {
+		logger.Error("failed to request delivery guy", slog.Any("error", err))
+		return nil, err
+	}
+
+	logger.Warn("delivery guy requested again", slog.String("from", shipment.Status))
+
+	if shipment.Status == "requested" {
+		return &domain.RedispatchResult{Shipment: *shipment}, nil
+	}
+
+	updated, err := u.repo.UpdateShipmentStatus(ctx, shipment.UID, redispatchableStatuses, "requested")
+	if err != nil {
+		logger.Error("failed to update shipment status", slog.Any("error", err))
+		return nil, err
+	}
+
+	// the 3pl reported a courier meanwhile: its status is kept
+	if !updated {
+		logger.Info("shipment status changed concurrently")
+
+		shipment, err = u.repo.GetShipment(ctx, shipment.UID)
+		if err != nil {
+			logger.Error("failed to fetch shipment", slog.Any("error", err))
+			return nil, err
+		}
+
+		return &domain.RedispatchResult{Shipment: *shipment}, nil
+	}
+
+	shipment.Status = "requested"
+	u.publish(ctx, logger, shipment)
+	u.notifyCore(ctx, logger, shipment)
+
+	return &domain.RedispatchResult{Shipment: *shipment}, nil
+}
+
+func (u *usecase) ReplayCoreWebhooks(ctx context.Context, input *domain.ReplayCoreWebhooksInput) (*domain.ReplayCoreWebhooksResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "replay_core_webhooks"))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	limit := input.Limit
+	if limit == 0 {
+		limit = domain.DefaultReplayLimit
+	}
+
+	webhooks, err := u.repo.ListFailedCoreWebhooks(ctx, limit)
+	if err != nil {
+		logger.Error("failed to list failed core webhooks", slog.Any("error", err))
+		return nil, err
+	}
+
+	result := &domain.ReplayCoreWebhooksResult{Failed: []domain.FailedCoreWebhook{}}
+
+	for _, webhook := range webhooks {
+		logger := logger.With(slog.String("shipment_uid", webhook.ShipmentUID), slog.String("status", webhook.Status))
+
+		// failing again is recorded by the core as one more attempt
+		if _, err := u.core.Webhook(ctx, &webhook.CoreWebhookInput); err != nil {
+			logger.Warn("core webhook failed again", slog.Int("attempts", webhook.Attempts+1), slog.Any("error", err))
+
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+
+			webhook.Error = err.Error()
+			webhook.Attempts++
+			webhook.LastFailedAt = u.clock.Now()
+			result.Failed = append(result.Failed, webhook)
+			continue
+		}
+
+		if err := u.repo.DeleteFailedCoreWebhook(ctx, webhook.ShipmentUID, webhook.Status); err != nil {
+			logger.Error("failed to delete replayed core webhook", slog.Any("error", err))
+			return nil, err
+		}
+
+		result.Replayed++
+	}
+
+	return result, nil
+}
+
+func (u *usecase) Stats(ctx context.Context, input *domain.StatsInput) (*domain.StatsResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "stats"))
+
+	counts, err := u.repo.CountShipmentsByStatus(ctx)
+	if err != nil {
+		logger.Error("failed to count shipments", slog.Any("error", err))
+		return nil, err
+	}
+
+	for _, status := range domain.ShipmentStatuses {
+		if _, ok := counts[status]; !ok {
+			counts[status] = 0
+		}
+	}
+
+	failedDispatches, err := u.repo.CountFailedDispatches(ctx)
+	if err != nil {
+		logger.Error("failed to count failed dispatches", slog.Any("error", err))
+		return nil, err
+	}
+
+	failed, err := u.repo.CountFailedCoreWebhooks(ctx)
+	if err != nil {
+		logger.Error("failed to count failed core webhooks", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &domain.StatsResult{
+		ShipmentsByStatus:  counts,
+		FailedDispatches:   failedDispatches,
+		FailedCoreWebhooks: failed,
+	}, nil
+}
+
+// notifyCore tells the core about a status an operator moved a shipment to. The change is stored
+// already: a failure is only logged, the recording core keeps the webhook to be replayed.
+func (u *usecase) notifyCore(ctx context.Context, logger *slog.Logger, shipment *domain.Shipment) {
+	if _, err := u.core.Webhook(ctx, &domain.CoreWebhookInput{
+		ShipmentUID: shipment.UID,
+		Status:      shipment.Status,
+	}); err != nil {
+		logger.Error("failed to invoke core webhook", slog.Any("error", err))
+	}
+}
+
+func errInvalidTransition(from, to string) error {
+	allowed := domain.Transitions(from)
+	if len(allowed) == 0 {
+		return internal_error.ConflictError{
+			Code:    internal_error.CodeInvalidTransition,
+			Message: fmt.Sprintf("shipment cannot move from %s, it is final", from),
+		}
+	}
+
+	return internal_error.ConflictError{
+		Code:    internal_error.CodeInvalidTransition,
+		Message: fmt.Sprintf("shipment cannot move from %s to %s, only to %s", from, to, strings.Join(allowed, ", ")),
+	}
+}
@@ -0,0 +1,281 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/repo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+// flakyCore fails every webhook while down.
+type flakyCore struct {
+	mu   sync.Mutex
+	down bool
+	sent []string
+}
+
+func (c *flakyCore) Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.down {
+		return nil, errors.New("core is down")
+	}
+
+	c.sent = append(c.sent, input.ShipmentUID+":"+input.Status)
+	return &domain.CoreWebhookResult{}, nil
+}
+
+func (c *flakyCore) setDown(down bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.down = down
+}
+
+type adminFixture struct {
+	uc interface {
+		usecase.UseCase
+		usecase.Admin
+	}
+	repo  usecase.Repo
+	core  *flakyCore
+	_3pl  *fake3PL
+	clock usecase.Clock
+}
+
+func newAdminFixture(t *testing.T, statuses map[string]string) *adminFixture {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(now)
+
+	f := &adminFixture{
+		repo:  repo.NewMemoryRepo(fakeClock, logger),
+		core:  &flakyCore{},
+		_3pl:  &fake3PL{},
+		clock: fakeClock,
+	}
+
+	f.uc = usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		usecase.NewRecordingCore(f.core, f.repo, fakeClock, logger),
+		f._3pl,
+		f.repo,
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	for uid, status := range statuses {
+		if err := f.repo.InsertShipment(context.Background(), &domain.Shipment{
+			UID:                      uid,
+			ScheduledDeliveryMinTime: now.Add(time.Hour),
+			ScheduledDeliveryMaxTime: now.Add(2 * time.Hour),
+			Status:                   status,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return f
+}
+
+func TestTransition(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tt := range []struct {
+		name   string
+		from   string
+		to     string
+		reason string
+	}{
+		{"allowed", "searching", "pending", ""},
+		{"cancel once the 3pl gave up", "not_found", "cancelled", ""},
+		{"cancel while the 3pl searches", "searching", "cancelled", internal_error.CodeInvalidTransition},
+		{"not allowed", "queued", "delivered", internal_error.CodeInvalidTransition},
+		{"only by requesting", "pending", "requested", internal_error.CodeInvalidTransition},
+		{"from final", "delivered", "in_transit", internal_error.CodeInvalidTransition},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAdminFixture(t, map[string]string{"shipment": tt.from})
+
+			result, err := f.uc.Transition(ctx, &domain.TransitionInput{ShipmentUID: "shipment", Status: tt.to})
+
+			if tt.reason != "" {
+				var conflictErr internal_error.ConflictError
+				if !errors.As(err, &conflictErr) || conflictErr.Code != tt.reason {
+					t.Fatalf("transition returned %v, want a %s conflict", err, tt.reason)
+				}
+
+				shipment, err := f.repo.GetShipment(ctx, "shipment")
+				if err != nil {
+					t.Fatal(err)
+				}
+				if shipment.Status != tt.from {
+					t.Errorf("status = %s after a refused transition, want %s", shipment.Status, tt.from)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Shipment.Status != tt.to {
+				t.Errorf("status = %s, want %s", result.Shipment.Status, tt.to)
+			}
+			if want := []string{"shipment:" + tt.to}; !slices.Equal(f.core.sent, want) {
+				t.Errorf("core was sent %v, want %v", f.core.sent, want)
+			}
+
+			history, err := f.uc.History(ctx, &domain.HistoryInput{ShipmentUID: "shipment"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var statuses []string
+			for _, change := range history.History {
+				statuses = append(statuses, change.Status)
+
+				if !change.At.Equal(f.clock.Now()) {
+					t.Errorf("%s changed at %v, want the time of the clock %v", change.Status, change.At, f.clock.Now())
+				}
+			}
+			if want := []string{tt.from, tt.to}; !slices.Equal(statuses, want) {
+				t.Errorf("history = %v, want %v", statuses, want)
+			}
+		})
+	}
+
+	f := newAdminFixture(t, nil)
+
+	var validationErr internal_error.ValidationError
+	if _, err := f.uc.Transition(ctx, &domain.TransitionInput{ShipmentUID: "shipment", Status: "shipped"}); !errors.As(err, &validationErr) {
+		t.Errorf("transition to an unknown status returned %v, want a validation error", err)
+	}
+
+	if _, err := f.uc.Transition(ctx, &domain.TransitionInput{ShipmentUID: "missing", Status: "pending"}); !errors.Is(err, internal_error.ErrShipmentNotFound) {
+		t.Errorf("transition of a missing shipment returned %v, want %v", err, internal_error.ErrShipmentNotFound)
+	}
+}
+
+func TestRedispatch(t *testing.T) {
+	ctx := context.Background()
+
+	f := newAdminFixture(t, map[string]string{
+		"not_found": "not_found",
+		"requested": "requested",
+		"picked_up": "picked_up",
+	})
+
+	result, err := f.uc.Redispatch(ctx, &domain.RedispatchInput{ShipmentUID: "not_found"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Shipment.Status != "requested" {
+		t.Errorf("status = %s, want requested", result.Shipment.Status)
+	}
+
+	// already requested shipments are requested again without a status change to report
+	if _, err := f.uc.Redispatch(ctx, &domain.RedispatchInput{ShipmentUID: "requested"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var conflictErr internal_error.ConflictError
+	if _, err := f.uc.Redispatch(ctx, &domain.RedispatchInput{ShipmentUID: "picked_up"}); !errors.As(err, &conflictErr) || conflictErr.Code != internal_error.CodeNotRedispatchable {
+		t.Errorf("redispatching a picked up shipment returned %v, want a %s conflict", err, internal_error.CodeNotRedispatchable)
+	}
+
+	if want := []string{"not_found", "requested"}; !slices.Equal(f._3pl.requested, want) {
+		t.Errorf("requested delivery guys for %v, want %v", f._3pl.requested, want)
+	}
+	if want := []string{"not_found:requested"}; !slices.Equal(f.core.sent, want) {
+		t.Errorf("core was sent %v, want %v", f.core.sent, want)
+	}
+}
+
+func TestStatsFailedDispatches(t *testing.T) {
+	ctx := context.Background()
+
+	f := newAdminFixture(t, map[string]string{"failed": "pending", "requested": "pending"})
+
+	now := f.clock.Now()
+	if _, err := f.repo.ClaimPendingShipments(ctx, now, now.Add(time.Minute), 10, func(shipments []domain.Shipment) []string {
+		return []string{"requested"}
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := f.uc.Stats(ctx, &domain.StatsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FailedDispatches != 1 || stats.ShipmentsByStatus["pending"] != 1 {
+		t.Errorf("stats = %+v, want 1 pending shipment failed to dispatch", stats)
+	}
+}
+
+func TestReplayCoreWebhooks(t *testing.T) {
+	ctx := context.Background()
+
+	f := newAdminFixture(t, map[string]string{"first": "searching", "second": "searching"})
+
+	// the core is down while the shipments change: their webhooks are kept
+	f.core.setDown(true)
+	for _, uid := range []string{"first", "second"} {
+		if _, err := f.uc.Transition(ctx, &domain.TransitionInput{ShipmentUID: uid, Status: "found"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, err := f.uc.Stats(ctx, &domain.StatsInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.FailedCoreWebhooks != 2 || stats.ShipmentsByStatus["found"] != 2 || stats.ShipmentsByStatus["queued"] != 0 {
+		t.Errorf("stats = %+v, want 2 found shipments and 2 failed webhooks", stats)
+	}
+	if _, ok := stats.ShipmentsByStatus["queued"]; !ok {
+		t.Error("stats leave out statuses without shipments")
+	}
+
+	result, err := f.uc.ReplayCoreWebhooks(ctx, &domain.ReplayCoreWebhooksInput{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Replayed != 0 || len(result.Failed) != 1 || result.Failed[0].Attempts != 2 {
+		t.Errorf("replayed while down: %+v, want one failing for the second time", result)
+	}
+
+	f.core.setDown(false)
+
+	result, err = f.uc.ReplayCoreWebhooks(ctx, &domain.ReplayCoreWebhooksInput{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Replayed != 2 || len(result.Failed) != 0 {
+		t.Errorf("replayed once up: %+v, want both", result)
+	}
+
+	slices.Sort(f.core.sent)
+	if want := []string{"first:found", "second:found"}; !slices.Equal(f.core.sent, want) {
+		t.Errorf("core was sent %v, want %v", f.core.sent, want)
+	}
+
+	if stats, err := f.uc.Stats(ctx, &domain.StatsInput{}); err != nil || stats.FailedCoreWebhooks != 0 {
+		t.Errorf("failed webhooks after replay = (%+v, %v), want none", stats, err)
+	}
+}
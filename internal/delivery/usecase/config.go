@@ -0,0 +1,25 @@
+package usecase
+
+import (
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/pricing"
+)
+
+type Config struct {
+	// MaxBatchSize is the maximum number of items accepted by a single RequestBatch call.
+	MaxBatchSize int
+	// WindowPolicy is the set of rules scheduled delivery windows are booked by.
+	WindowPolicy domain.WindowPolicy
+	// DefaultSlotCapacity is the number of shipments a window can take unless overridden in storage.
+	// Zero disables capacity management.
+	DefaultSlotCapacity int
+	// MaxDistanceMeters is the longest accepted distance between origin and destination. Zero means no limit.
+	MaxDistanceMeters float64
+	// AverageSpeedKmh is the courier speed ETAs are estimated with.
+	AverageSpeedKmh float64
+	// MaxAddressMismatchMeters is the furthest the geocoded user address can be from the destination.
+	// Zero disables the check.
+	MaxAddressMismatchMeters float64
+	// Pricing configures how deliveries are priced and quoted.
+	Pricing pricing.Config
+}
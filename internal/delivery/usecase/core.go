@@ -0,0 +1,48 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+)
+
+// recordingCore is a Core recording the webhooks that fail, for ReplayCoreWebhooks to send them
+// again later.
+type recordingCore struct {
+	core   Core
+	repo   Repo
+	clock  Clock
+	logger *slog.Logger
+}
+
+var _ Core = (*recordingCore)(nil)
+
+func NewRecordingCore(core Core, repo Repo, clock Clock, logger *slog.Logger) *recordingCore {
+	return &recordingCore{
+		core:   core,
+		repo:   repo,
+		clock:  clock,
+		logger: logger,
+	}
+}
+
+func (c *recordingCore) Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error) {
+	result, err := c.core.Webhook(ctx, input)
+	if err == nil {
+		return result, nil
+	}
+
+	// recorded even when ctx is done so shutting down does not lose the webhook
+	if saveErr := c.repo.SaveFailedCoreWebhook(context.WithoutCancel(ctx), input, err.Error(), c.clock.Now()); saveErr != nil {
+		c.logger.Error(
+			"failed to record failed core webhook",
+			slog.Any("usecase", "recording_core"),
+			slog.String("shipment_uid", input.ShipmentUID),
+			slog.String("status", input.Status),
+			slog.Any("error", saveErr),
+		)
+	}
+
+	return nil, err
+}
@@ -2,11 +2,16 @@ package usecase
 
 import (
 	"context"
+	"time"
 
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
 )
 
 type (
+	Clock interface {
+		Now() time.Time
+	}
+
 	Core interface {
 		Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error)
 	}
@@ -17,12 +22,103 @@ type (
 
 	Repo interface {
 		GetShipment(ctx context.Context, shipmentUID string) (*domain.Shipment, error)
+		// InsertShipment returns internal_error.ErrShipmentExists when the uid is already taken.
 		InsertShipment(ctx context.Context, shipment *domain.Shipment) error
+		// InsertShipments inserts all shipments in a single transaction and returns the uids of inserted ones.
+		// Shipments whose uid already exists are skipped rather than failing the whole batch.
+		InsertShipments(ctx context.Context, shipments []*domain.Shipment) ([]string, error)
 		SetShipmentStatus(ctx context.Context, shipmentUID string, status string) error
+		// MergeShipmentTracking overwrites the tracking fields update carries, see domain.Tracking.Merge.
+		MergeShipmentTracking(ctx context.Context, shipmentUID string, update domain.Tracking) error
+		// UpdateShipmentStatus sets the status only if the shipment is currently in one of fromStatuses
+		// and reports whether it did.
+		UpdateShipmentStatus(ctx context.Context, shipmentUID string, fromStatuses []string, status string) (bool, error)
+
+		// ReserveSlot atomically takes one unit of the capacity of every hour of a window, see
+		// ScheduledDeliveryWindow.Hours, creating them with defaultCapacity on first use. It takes
+		// none and reports false when any of them is already full.
+		ReserveSlot(ctx context.Context, zoneID string, hours []domain.ScheduledDeliveryWindow, defaultCapacity int) (bool, error)
+		ReleaseSlot(ctx context.Context, zoneID string, hours []domain.ScheduledDeliveryWindow) error
+		// ListSlotCapacities returns the hours of a zone overlapping [from, to) that have been used or configured.
+		ListSlotCapacities(ctx context.Context, zoneID string, from, to time.Time) ([]domain.SlotCapacity, error)
+
+		// ListShipmentsNear returns shipments whose origin is within radiusMeters of center, closest first.
+		// An empty status matches every status.
+		ListShipmentsNear(ctx context.Context, center domain.Location, radiusMeters float64, status string, limit int) ([]domain.Shipment, error)
+
+		// ListShipments returns up to limit shipments matching filter whose uid sorts after afterUID,
+		// in uid order so pages can be walked by passing the last uid of a page as afterUID.
+		ListShipments(ctx context.Context, filter domain.ShipmentFilter, afterUID string, limit int) ([]domain.Shipment, error)
+
+		// PromoteQueuedShipments moves up to limit queued shipments whose window starts by before
+		// to pending and returns them.
+		PromoteQueuedShipments(ctx context.Context, before time.Time, limit int) ([]domain.Shipment, error)
+		// ClaimPendingShipments locks up to limit pending shipments due by now that no other claim holds,
+		// hands them to dispatch and marks the uids it returns as requested. The others are due again at
+		// retryAt. It returns the number of claimed shipments.
+		ClaimPendingShipments(ctx context.Context, now, retryAt time.Time, limit int, dispatch func(shipments []domain.Shipment) []string) (int, error)
+
+		// ListShipmentHistory returns the statuses a shipment went through, oldest first.
+		ListShipmentHistory(ctx context.Context, shipmentUID string) ([]domain.ShipmentStatusChange, error)
+		// CountShipmentsByStatus counts the shipments of every status having any.
+		CountShipmentsByStatus(ctx context.Context) (map[string]int, error)
+		// CountFailedDispatches counts the pending shipments the 3pl could not be requested for last
+		// time they were claimed.
+		CountFailedDispatches(ctx context.Context) (int, error)
+
+		// SaveFailedCoreWebhook records a core webhook that failed at failedAt with failure. Failing
+		// again for the same shipment and status counts one more attempt of the recorded webhook.
+		SaveFailedCoreWebhook(ctx context.Context, input *domain.CoreWebhookInput, failure string, failedAt time.Time) error
+		// ListFailedCoreWebhooks returns up to limit failed core webhooks, the oldest failure first.
+		ListFailedCoreWebhooks(ctx context.Context, limit int) ([]domain.FailedCoreWebhook, error)
+		CountFailedCoreWebhooks(ctx context.Context) (int, error)
+		DeleteFailedCoreWebhook(ctx context.Context, shipmentUID string, status string) error
+	}
+
+	Zones interface {
+		// Locate returns the zone containing location or nil when it is outside of every zone.
+		Locate(ctx context.Context, location domain.Location) (*domain.Zone, error)
+		Get(ctx context.Context, zoneID string) (*domain.Zone, error)
+	}
+
+	Geocoder interface {
+		// Geocode returns where address is and how precisely, or nil when it cannot be resolved.
+		Geocode(ctx context.Context, address *domain.Address) (*domain.Geocode, error)
+	}
+
+	Events interface {
+		// Publish fans event out to the matching subscribers of every instance.
+		Publish(ctx context.Context, event *domain.ShipmentEvent) error
+		// Subscribe returns the events matching match published from now on. The channel is closed once
+		// ctx is done or the subscriber falls too far behind.
+		Subscribe(ctx context.Context, match func(event *domain.ShipmentEvent) bool) <-chan domain.ShipmentEvent
 	}
 
 	UseCase interface {
 		Request(ctx context.Context, input *domain.RequestInput) (*domain.RequestResult, error)
+		RequestBatch(ctx context.Context, input *domain.BatchRequestInput) (*domain.BatchRequestResult, error)
+		Slots(ctx context.Context, input *domain.SlotsInput) (*domain.SlotsResult, error)
+		Get(ctx context.Context, input *domain.GetInput) (*domain.GetResult, error)
+		List(ctx context.Context, input *domain.ListInput) (*domain.ListResult, error)
+		Cancel(ctx context.Context, input *domain.CancelInput) (*domain.CancelResult, error)
+		Nearby(ctx context.Context, input *domain.NearbyInput) (*domain.NearbyResult, error)
+		Quote(ctx context.Context, input *domain.QuoteInput) (*domain.QuoteResult, error)
 		Webhook(ctx context.Context, input *domain.WebhookInput) (*domain.WebhookResult, error)
+		// Subscribe streams the changes of a shipment until ctx is done.
+		Subscribe(ctx context.Context, input *domain.SubscribeInput) (*domain.SubscribeResult, error)
+		// Watch streams the changes of every shipment matching a filter until ctx is done.
+		Watch(ctx context.Context, input *domain.WatchInput) (*domain.WatchResult, error)
+	}
+
+	// Admin operates on shipments past what the api allows, for operators fixing stuck ones.
+	Admin interface {
+		History(ctx context.Context, input *domain.HistoryInput) (*domain.HistoryResult, error)
+		// Transition forces a shipment into a status the state machine allows from its current one.
+		Transition(ctx context.Context, input *domain.TransitionInput) (*domain.TransitionResult, error)
+		// Redispatch requests a delivery guy again for a shipment stuck waiting on the 3pl.
+		Redispatch(ctx context.Context, input *domain.RedispatchInput) (*domain.RedispatchResult, error)
+		// ReplayCoreWebhooks sends the failed core webhooks again, forgetting the ones succeeding.
+		ReplayCoreWebhooks(ctx context.Context, input *domain.ReplayCoreWebhooksInput) (*domain.ReplayCoreWebhooksResult, error)
+		Stats(ctx context.Context, input *domain.StatsInput) (*domain.StatsResult, error)
 	}
 )
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/geo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/pricing"
+)
+
+func (u *usecase) Quote(ctx context.Context, input *domain.QuoteInput) (*domain.QuoteResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "quote"))
+
+	now := u.clock.Now()
+
+	var v internal_error.Violations
+	v.Merge("", input.Validate())
+
+	zone, err := u.validateDelivery(ctx, &v, input.RoutingInfo, input.ScheduledDeliveryWindow, now)
+	if err != nil {
+		logger.Error("failed to validate delivery", slog.Any("error", err))
+		return nil, err
+	}
+
+	if err := v.Err(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	price, err := u.currentPrice(ctx, zone, input.RoutingInfo, input.ScheduledDeliveryWindow)
+	if err != nil {
+		logger.Error("failed to price delivery", slog.Any("error", err))
+		return nil, err
+	}
+
+	quote, err := u.pricing.IssueQuote(price, input.RoutingInfo, input.ScheduledDeliveryWindow, now)
+	if err != nil {
+		logger.Error("failed to issue quote", slog.Any("error", err))
+		return nil, err
+	}
+
+	return quote, nil
+}
+
+// agreedPrice returns the quoted price if any or prices the delivery right now.
+func (u *usecase) agreedPrice(
+	ctx context.Context,
+	quoted *domain.Price,
+	zone *domain.Zone,
+	routingInfo domain.RoutingInfo,
+	window domain.ScheduledDeliveryWindow,
+) (domain.Price, error) {
+	if quoted != nil {
+		return *quoted, nil
+	}
+	return u.currentPrice(ctx, zone, routingInfo, window)
+}
+
+func (u *usecase) currentPrice(
+	ctx context.Context,
+	zone *domain.Zone,
+	routingInfo domain.RoutingInfo,
+	window domain.ScheduledDeliveryWindow,
+) (domain.Price, error) {
+	var zoneID string
+	if zone != nil {
+		zoneID = zone.ID
+	}
+
+	utilization, err := u.slotUtilization(ctx, zone, window)
+	if err != nil {
+		return domain.Price{}, err
+	}
+
+	return u.pricing.Price(&pricing.PriceInput{
+		DistanceMeters: geo.Distance(routingInfo.Origin, routingInfo.Destination),
+		ZoneID:         zoneID,
+		WindowStart:    window.StartTime.In(u.zoneLocation(zone)),
+		Utilization:    utilization,
+	}), nil
+}
+
+// slotUtilization returns the reserved share of the window's tightest hour, zero when capacity
+// management is disabled.
+func (u *usecase) slotUtilization(ctx context.Context, zone *domain.Zone, window domain.ScheduledDeliveryWindow) (float64, error) {
+	if u.config.DefaultSlotCapacity <= 0 {
+		return 0, nil
+	}
+
+	var zoneID string
+	if zone != nil {
+		zoneID = zone.ID
+	}
+
+	capacities, err := u.repo.ListSlotCapacities(ctx, zoneID, window.StartTime, window.EndTime)
+	if err != nil {
+		return 0, err
+	}
+
+	capacity := tightestHour(capacities, window.Hours(u.zoneLocation(zone)), u.config.DefaultSlotCapacity)
+	if capacity.Capacity <= 0 {
+		return 1, nil
+	}
+
+	return float64(capacity.Reserved) / float64(capacity.Capacity), nil
+}
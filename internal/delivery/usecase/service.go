@@ -2,65 +2,114 @@ package usecase
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
 	"log/slog"
+	"slices"
 	"time"
 
 	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
 	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/geo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/pricing"
 )
 
+// maxSlotsRange bounds the period a single Slots call can enumerate.
+const maxSlotsRange = 7 * 24 * time.Hour
+
+const defaultNearbyLimit = 100
+
+// maxWatchSnapshot bounds the shipments a watch starts from.
+const maxWatchSnapshot = 1000
+
+// cancellableStatuses are the statuses a shipment can be cancelled in: it has not been handed to the 3pl yet.
+var cancellableStatuses = []string{"queued", "pending"}
+
+var errEventsDisabled = errors.New("shipment events are disabled")
+
+var errSlotFull = internal_error.ConflictError{
+	Code:    internal_error.CodeSlotFull,
+	Message: "scheduled_delivery_window slot is full",
+}
+
 type usecase struct {
-	core   Core
-	_3pl   ThirdPartyLogistics
-	repo   Repo
-	logger *slog.Logger
+	config   *Config
+	pricing  *pricing.Engine
+	core     Core
+	_3pl     ThirdPartyLogistics
+	repo     Repo
+	zones    Zones
+	geocoder Geocoder
+	events   Events
+	clock    Clock
+	logger   *slog.Logger
 }
 
 var _ UseCase = (*usecase)(nil)
 
 func NewUseCase(
+	config *Config,
 	core Core,
 	_3pl ThirdPartyLogistics,
 	repo Repo,
+	zones Zones,
+	geocoder Geocoder,
+	events Events,
+	clock Clock,
 	logger *slog.Logger,
 ) *usecase {
 	return &usecase{
-		core:   core,
-		_3pl:   _3pl,
-		repo:   repo,
-		logger: logger,
+		config:   config,
+		pricing:  pricing.NewEngine(&config.Pricing),
+		core:     core,
+		_3pl:     _3pl,
+		repo:     repo,
+		zones:    zones,
+		geocoder: geocoder,
+		events:   events,
+		clock:    clock,
+		logger:   logger,
 	}
 }
 
 func (u *usecase) Request(ctx context.Context, input *domain.RequestInput) (*domain.RequestResult, error) {
 	logger := u.logger.With(slog.Any("usecase", "request"), slog.String("shipment_uid", input.ShipmentUID))
 
-	if err := input.Validate(); err != nil {
+	now := u.clock.Now()
+
+	zone, quoted, err := u.validateRequest(ctx, input, now)
+	if err != nil {
 		logger.Error("input validation failed", slog.Any("error", err))
-		return nil, internal_error.ValidationError(err.Error())
+		return nil, err
+	}
+
+	price, err := u.agreedPrice(ctx, quoted, zone, input.RoutingInfo, input.ScheduledDeliveryWindow)
+	if err != nil {
+		logger.Error("failed to price shipment", slog.Any("error", err))
+		return nil, err
 	}
 
 	status := "queued"
-	if input.ScheduledDeliveryWindow.StartTime.Before(time.Now()) {
+	if input.ScheduledDeliveryWindow.StartTime.Before(now) {
 		status = "requested"
 	}
 
-	shipment := &domain.Shipment{
-		UID:                      input.ShipmentUID,
-		UserUID:                  input.UserInfo.UserUID,
-		UserAddr:                 input.UserInfo.Address,
-		OriginPoint:              input.RoutingInfo.Origin,
-		DestinationPoint:         input.RoutingInfo.Destination,
-		ScheduledDeliveryMinTime: input.ScheduledDeliveryWindow.StartTime,
-		ScheduledDeliveryMaxTime: input.ScheduledDeliveryWindow.EndTime,
-		Status:                   status,
+	shipment := u.newShipment(input, zone, price, status)
+
+	if err := u.reserveSlot(ctx, zone, input.ScheduledDeliveryWindow); err != nil {
+		logger.Error("failed to reserve slot", slog.Any("error", err))
+		return nil, err
 	}
 
 	if err := u.repo.InsertShipment(ctx, shipment); err != nil {
 		logger.Error("failed to insert shipment", slog.Any("error", err))
+		u.releaseSlot(ctx, logger, shipment.ZoneID, input.ScheduledDeliveryWindow)
 		return nil, err
 	}
 
+	u.publish(ctx, logger, shipment)
+
 	if status != "queued" {
 		logger.Info("shipment is within the scheduled window: request delivery guy now")
 
@@ -68,6 +117,8 @@ func (u *usecase) Request(ctx context.Context, input *domain.RequestInput) (*dom
 			ShipmentUID:             input.ShipmentUID,
 			RoutingInfo:             input.RoutingInfo,
 			ScheduledDeliveryWindow: input.ScheduledDeliveryWindow,
+			ZoneID:                  shipment.ZoneID,
+			Provider:                preferred3PL(zone),
 		}); err != nil {
 			logger.Error("failed to request delivery guy", slog.Any("error", err))
 			return nil, err
@@ -77,51 +128,434 @@ func (u *usecase) Request(ctx context.Context, input *domain.RequestInput) (*dom
 	return nil, nil
 }
 
+func (u *usecase) RequestBatch(ctx context.Context, input *domain.BatchRequestInput) (*domain.BatchRequestResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "request_batch"), slog.Int("batch_length", len(input.Items)))
+
+	if len(input.Items) == 0 {
+		logger.Error("input validation failed: empty batch")
+		return nil, internal_error.NewValidationError(internal_error.FieldViolation{
+			Field:   "items",
+			Code:    internal_error.CodeRequired,
+			Message: "is required",
+		})
+	}
+
+	if len(input.Items) > u.config.MaxBatchSize {
+		logger.Error("input validation failed: batch too large", slog.Int("max_batch_size", u.config.MaxBatchSize))
+		return nil, internal_error.NewValidationError(internal_error.FieldViolation{
+			Field:   "items",
+			Code:    internal_error.CodeTooMany,
+			Message: fmt.Sprintf("must contain at most %d entries", u.config.MaxBatchSize),
+		})
+	}
+
+	now := u.clock.Now()
+	results := make([]domain.BatchRequestItemResult, len(input.Items))
+	shipments := make([]*domain.Shipment, 0, len(input.Items))
+	seen := make(map[string]struct{}, len(input.Items))
+
+	for i := range input.Items {
+		item := &input.Items[i]
+		results[i].ShipmentUID = item.ShipmentUID
+
+		zone, quoted, err := u.validateRequest(ctx, item, now)
+		if err != nil {
+			var validationErr internal_error.ValidationError
+			if !errors.As(err, &validationErr) {
+				logger.Error("failed to validate item", slog.Any("error", err))
+				u.releaseSlots(ctx, logger, shipments, nil)
+				return nil, err
+			}
+
+			results[i].Status = domain.BatchItemStatusInvalid
+			results[i].Reason = err.Error()
+			results[i].Violations = validationErr.Violations
+			continue
+		}
+
+		if _, ok := seen[item.ShipmentUID]; ok {
+			results[i].Status = domain.BatchItemStatusDuplicate
+			continue
+		}
+		seen[item.ShipmentUID] = struct{}{}
+
+		// shipments already within their window are left to the shipping worker instead of requesting
+		// a delivery guy for each one of them inline: unlike Request's, they are pending, not requested,
+		// until the worker has requested them
+		status := "queued"
+		if item.ScheduledDeliveryWindow.StartTime.Before(now) {
+			status = "pending"
+		}
+
+		price, err := u.agreedPrice(ctx, quoted, zone, item.RoutingInfo, item.ScheduledDeliveryWindow)
+		if err != nil {
+			logger.Error("failed to price shipment", slog.Any("error", err))
+			u.releaseSlots(ctx, logger, shipments, nil)
+			return nil, err
+		}
+
+		shipment := u.newShipment(item, zone, price, status)
+
+		if err := u.reserveSlot(ctx, zone, item.ScheduledDeliveryWindow); err != nil {
+			var conflictErr internal_error.ConflictError
+			if !errors.As(err, &conflictErr) {
+				logger.Error("failed to reserve slot", slog.Any("error", err))
+				u.releaseSlots(ctx, logger, shipments, nil)
+				return nil, err
+			}
+
+			results[i].Status = domain.BatchItemStatusInvalid
+			results[i].Reason = err.Error()
+			results[i].Violations = []internal_error.FieldViolation{{
+				Field:   "scheduled_delivery_window",
+				Code:    conflictErr.Code,
+				Message: "is full",
+			}}
+			continue
+		}
+
+		shipments = append(shipments, shipment)
+	}
+
+	inserted := make(map[string]struct{}, len(shipments))
+	if len(shipments) > 0 {
+		insertedUIDs, err := u.repo.InsertShipments(ctx, shipments)
+		if err != nil {
+			logger.Error("failed to insert shipments", slog.Any("error", err))
+			u.releaseSlots(ctx, logger, shipments, nil)
+			return nil, err
+		}
+
+		for _, uid := range insertedUIDs {
+			inserted[uid] = struct{}{}
+		}
+	}
+
+	for _, shipment := range shipments {
+		if _, ok := inserted[shipment.UID]; ok {
+			u.publish(ctx, logger, shipment)
+		}
+	}
+
+	// shipments that already existed did not take the slot they reserved
+	u.releaseSlots(ctx, logger, shipments, inserted)
+
+	for i := range results {
+		if results[i].Status != "" {
+			continue
+		}
+
+		if _, ok := inserted[results[i].ShipmentUID]; ok {
+			results[i].Status = domain.BatchItemStatusCreated
+		} else {
+			results[i].Status = domain.BatchItemStatusDuplicate
+		}
+	}
+
+	logger.Info("batch processed", slog.Int("created", len(inserted)))
+
+	return &domain.BatchRequestResult{Results: results}, nil
+}
+
+func (u *usecase) Slots(ctx context.Context, input *domain.SlotsInput) (*domain.SlotsResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "slots"))
+
+	now := u.clock.Now()
+
+	from := input.From
+	if from.IsZero() {
+		from = now
+	}
+
+	to := input.To
+	if to.IsZero() {
+		to = from.Add(24 * time.Hour)
+	}
+
+	var v internal_error.Violations
+	if !to.After(from) {
+		v.Add("to", internal_error.CodeInvalid, "must be after from")
+	}
+	if to.Sub(from) > maxSlotsRange {
+		v.Add("to", internal_error.CodeTooFar, "must be within 7 days of from")
+	}
+	if err := v.Err(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	zone, err := u.zone(ctx, input.ZoneID)
+	if err != nil {
+		logger.Error("failed to fetch zone", slog.String("zone_id", input.ZoneID), slog.Any("error", err))
+		return nil, err
+	}
+
+	windows := u.windowPolicy(zone).Slots(from, to, now)
+
+	slots := make([]domain.Slot, len(windows))
+	for i, window := range windows {
+		slots[i] = domain.Slot{
+			StartTime: window.StartTime,
+			EndTime:   window.EndTime,
+		}
+	}
+
+	if u.config.DefaultSlotCapacity <= 0 {
+		return &domain.SlotsResult{Slots: slots}, nil
+	}
+
+	capacities, err := u.repo.ListSlotCapacities(ctx, input.ZoneID, from, to)
+	if err != nil {
+		logger.Error("failed to list slot capacities", slog.Any("error", err))
+		return nil, err
+	}
+
+	for i, window := range windows {
+		// a window is bookable as long as its tightest hour is
+		capacity := tightestHour(capacities, window.Hours(u.zoneLocation(zone)), u.config.DefaultSlotCapacity)
+
+		remaining := max(capacity.Capacity-capacity.Reserved, 0)
+		slots[i].Capacity = &capacity.Capacity
+		slots[i].Remaining = &remaining
+	}
+
+	return &domain.SlotsResult{Slots: slots}, nil
+}
+
+func (u *usecase) Get(ctx context.Context, input *domain.GetInput) (*domain.GetResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "get"), slog.String("shipment_uid", input.ShipmentUID))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &domain.GetResult{Shipment: *shipment}, nil
+}
+
+func (u *usecase) List(ctx context.Context, input *domain.ListInput) (*domain.ListResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "list"))
+
+	var v internal_error.Violations
+	v.Merge("", input.Validate())
+
+	afterUID, err := decodePageToken(input.PageToken)
+	if err != nil {
+		v.Add("page_token", internal_error.CodeInvalid, "is invalid")
+	}
+
+	if err := v.Err(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	pageSize := input.PageSize
+	if pageSize == 0 {
+		pageSize = domain.DefaultListPageSize
+	}
+
+	// one more shipment tells whether a next page exists
+	shipments, err := u.repo.ListShipments(ctx, input.ShipmentFilter, afterUID, pageSize+1)
+	if err != nil {
+		logger.Error("failed to list shipments", slog.Any("error", err))
+		return nil, err
+	}
+
+	result := &domain.ListResult{Shipments: shipments}
+	if len(shipments) > pageSize {
+		result.Shipments = shipments[:pageSize]
+		result.NextPageToken = encodePageToken(shipments[pageSize-1].UID)
+	}
+
+	if result.Shipments == nil {
+		result.Shipments = []domain.Shipment{}
+	}
+
+	return result, nil
+}
+
+func (u *usecase) Cancel(ctx context.Context, input *domain.CancelInput) (*domain.CancelResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "cancel"), slog.String("shipment_uid", input.ShipmentUID))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	cancelled, err := u.repo.UpdateShipmentStatus(ctx, input.ShipmentUID, cancellableStatuses, "cancelled")
+	if err != nil {
+		logger.Error("failed to update shipment status", slog.Any("error", err))
+		return nil, err
+	}
+
+	if !cancelled {
+		logger.Info("shipment is not cancellable", slog.String("status", shipment.Status))
+
+		message := fmt.Sprintf("shipment cannot be cancelled once %s", shipment.Status)
+		if slices.Contains(cancellableStatuses, shipment.Status) {
+			// it was read cancellable but is being handed to the 3pl meanwhile
+			message = "shipment is being handed to the 3pl and can no longer be cancelled"
+		}
+
+		return nil, internal_error.ConflictError{Code: internal_error.CodeNotCancellable, Message: message}
+	}
+
+	u.releaseSlot(ctx, logger, shipment.ZoneID, domain.ScheduledDeliveryWindow{
+		StartTime: shipment.ScheduledDeliveryMinTime,
+		EndTime:   shipment.ScheduledDeliveryMaxTime,
+	})
+
+	shipment.Status = "cancelled"
+	u.publish(ctx, logger, shipment)
+
+	if _, err := u.core.Webhook(ctx, &domain.CoreWebhookInput{
+		ShipmentUID: input.ShipmentUID,
+		Status:      "cancelled",
+	}); err != nil {
+		logger.Error("failed to invoke core webhook", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &domain.CancelResult{}, nil
+}
+
+func (u *usecase) Nearby(ctx context.Context, input *domain.NearbyInput) (*domain.NearbyResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "nearby"))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	limit := input.Limit
+	if limit == 0 {
+		limit = defaultNearbyLimit
+	}
+
+	shipments, err := u.repo.ListShipmentsNear(ctx, input.Center, input.RadiusMeters, input.Status, limit)
+	if err != nil {
+		logger.Error("failed to list shipments near", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &domain.NearbyResult{Shipments: shipments}, nil
+}
+
 func (u *usecase) Webhook(ctx context.Context, input *domain.WebhookInput) (*domain.WebhookResult, error) {
 	logger := u.logger.With(slog.Any("usecase", "webhook"), slog.String("shipment_uid", input.ShipmentUID))
 
 	if err := input.Validate(); err != nil {
 		logger.Error("input validation failed", slog.Any("error", err))
-		return nil, internal_error.ValidationError(err.Error())
+		return nil, err
 	}
 
-	if err := u.repo.SetShipmentStatus(ctx, input.ShipmentUID, input.Status); err != nil {
-		logger.Error("failed to set shipment status", slog.Any("error", err))
+	status := input.DeliveryStatus()
+
+	// tracking is only read from version 2 payloads on
+	version, tracking := max(input.Version, 1), domain.Tracking{}
+	if version >= 2 {
+		tracking = input.Tracking
+	}
+
+	transitioned, err := u.repo.UpdateShipmentStatus(ctx, input.ShipmentUID, domain.WebhookFromStatuses(status), status)
+	if err != nil {
+		logger.Error("failed to update shipment status", slog.Any("error", err))
 		return nil, err
 	}
 
-	if input.Status == "not_found" {
-		logger.Info("could not find a delivery guy")
+	if !transitioned {
+		shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+		if err != nil {
+			logger.Error("failed to fetch shipment", slog.Any("error", err))
+			return nil, err
+		}
+
+		// webhooks arriving late or twice must not move a shipment back, nor out of a final status.
+		// The current status reported again only goes on to carry tracking changes.
+		if shipment.Status != status || tracking.IsZero() {
+			logger.Warn("ignoring stale webhook", slog.String("status", status), slog.String("current_status", shipment.Status))
+			return nil, nil
+		}
+	}
+
+	if !tracking.IsZero() {
+		if err := u.repo.MergeShipmentTracking(ctx, input.ShipmentUID, tracking); err != nil {
+			logger.Error("failed to merge shipment tracking", slog.Any("error", err))
+			return nil, err
+		}
+	}
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
 
-		if time.Now().Hour() < 23 {
-			logger.Info("clock is not 23:00 yet: request another delivery guy")
+	u.publish(ctx, logger, shipment)
 
-			shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	// what a status sets off only happens on entering it, not when it is reported again
+	if transitioned {
+		switch status {
+		case "not_found":
+			logger.Info("could not find a delivery guy")
+
+			zone, err := u.shipmentZone(ctx, logger, shipment.ZoneID)
 			if err != nil {
-				logger.Error("failed to fetch shipment", slog.Any("error", err))
+				logger.Error("failed to fetch zone", slog.String("zone_id", shipment.ZoneID), slog.Any("error", err))
 				return nil, err
 			}
 
-			if _, err := u._3pl.RequestDeliveryGuy(ctx, &domain.ThirdPartyLogisticsRequestDeliveryGuyInput{
-				ShipmentUID: input.ShipmentUID,
-				RoutingInfo: domain.RoutingInfo{
-					Origin:      shipment.OriginPoint,
-					Destination: shipment.DestinationPoint,
-				},
-				ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
-					StartTime: shipment.ScheduledDeliveryMinTime,
-					EndTime:   shipment.ScheduledDeliveryMaxTime,
-				},
-			}); err != nil {
-				logger.Error("failed to request delivery guy", slog.Any("error", err))
-				return nil, err
+			cutoffHour := domain.DefaultCutoffHour
+			if zone != nil {
+				cutoffHour = zone.CutoffHour
+			}
+
+			if u.clock.Now().In(u.zoneLocation(zone)).Hour() < cutoffHour {
+				logger.Info("zone cutoff is not reached yet: request another delivery guy", slog.Int("cutoff_hour", cutoffHour))
+
+				if _, err := u._3pl.RequestDeliveryGuy(ctx, &domain.ThirdPartyLogisticsRequestDeliveryGuyInput{
+					ShipmentUID: input.ShipmentUID,
+					RoutingInfo: domain.RoutingInfo{
+						Origin:      shipment.OriginPoint,
+						Destination: shipment.DestinationPoint,
+					},
+					ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+						StartTime: shipment.ScheduledDeliveryMinTime,
+						EndTime:   shipment.ScheduledDeliveryMaxTime,
+					},
+					ZoneID:   shipment.ZoneID,
+					Provider: preferred3PL(zone),
+				}); err != nil {
+					logger.Error("failed to request delivery guy", slog.Any("error", err))
+					return nil, err
+				}
 			}
+
+		case "delivery_failed":
+			logger.Warn("delivery failed: the courier brings the shipment back to its origin", slog.String("failure_reason", tracking.FailureReason))
+
+		case "returned":
+			logger.Info("failed delivery is back at its origin")
 		}
 	}
 
 	if _, err := u.core.Webhook(ctx, &domain.CoreWebhookInput{
+		Version:     version,
 		ShipmentUID: input.ShipmentUID,
-		Status:      input.Status,
+		Status:      status,
+		Tracking:    tracking,
 	}); err != nil {
 		logger.Error("failed to invoke core webhook", slog.Any("error", err))
 		return nil, err
@@ -129,3 +563,348 @@ func (u *usecase) Webhook(ctx context.Context, input *domain.WebhookInput) (*dom
 
 	return nil, nil
 }
+
+func (u *usecase) Subscribe(ctx context.Context, input *domain.SubscribeInput) (*domain.SubscribeResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "subscribe"), slog.String("shipment_uid", input.ShipmentUID))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	if u.events == nil {
+		logger.Error("shipment events are disabled")
+		return nil, errEventsDisabled
+	}
+
+	// subscribe before reading the shipment so no change falls in between, the subscription
+	// is dropped with ctx when the shipment cannot be read
+	events := u.events.Subscribe(ctx, func(event *domain.ShipmentEvent) bool {
+		return event.ShipmentUID == input.ShipmentUID
+	})
+
+	shipment, err := u.repo.GetShipment(ctx, input.ShipmentUID)
+	if err != nil {
+		logger.Error("failed to fetch shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &domain.SubscribeResult{
+		Current: domain.NewShipmentEvent(shipment, u.clock.Now()),
+		Events:  events,
+	}, nil
+}
+
+func (u *usecase) Watch(ctx context.Context, input *domain.WatchInput) (*domain.WatchResult, error) {
+	logger := u.logger.With(slog.Any("usecase", "watch"), slog.Int("shipment_uids", len(input.ShipmentUIDs)))
+
+	if err := input.Validate(); err != nil {
+		logger.Error("input validation failed", slog.Any("error", err))
+		return nil, err
+	}
+
+	if u.events == nil {
+		logger.Error("shipment events are disabled")
+		return nil, errEventsDisabled
+	}
+
+	filter := input.ShipmentFilter
+	watch := domain.NewShipmentWatch(filter)
+
+	// watch before listing the shipments so no change falls in between
+	events := u.events.Subscribe(ctx, watch.Matches)
+
+	shipments, err := u.repo.ListShipments(ctx, filter, "", maxWatchSnapshot)
+	if err != nil {
+		logger.Error("failed to list shipments", slog.Any("error", err))
+		return nil, err
+	}
+
+	now := u.clock.Now()
+	current := make([]domain.ShipmentEvent, len(shipments))
+	for i := range shipments {
+		current[i] = domain.NewShipmentEvent(&shipments[i], now)
+	}
+	watch.Start(current)
+
+	return &domain.WatchResult{Current: current, Events: events}, nil
+}
+
+// publish streams the new state of shipment to its subscribers.
+// Failures are only logged since the change itself is already stored.
+func (u *usecase) publish(ctx context.Context, logger *slog.Logger, shipment *domain.Shipment) {
+	if u.events == nil {
+		return
+	}
+
+	event := domain.NewShipmentEvent(shipment, u.clock.Now())
+	if err := u.events.Publish(ctx, &event); err != nil {
+		logger.Error("failed to publish shipment event", slog.Any("error", err))
+	}
+}
+
+// validateRequest validates input and its delivery (see validateDelivery) collecting every violation.
+// When input carries a quote token, the price it was quoted with is returned.
+// A non validation error is returned when the zone lookup fails.
+func (u *usecase) validateRequest(ctx context.Context, input *domain.RequestInput, now time.Time) (*domain.Zone, *domain.Price, error) {
+	var v internal_error.Violations
+
+	v.Merge("", input.Validate())
+
+	zone, err := u.validateDelivery(ctx, &v, input.RoutingInfo, input.ScheduledDeliveryWindow, now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := u.validateAddress(ctx, &v, &input.UserInfo.Address, input.RoutingInfo.Destination); err != nil {
+		return nil, nil, err
+	}
+
+	var quoted *domain.Price
+	if input.QuoteToken != "" && input.RoutingInfo.Validate() == nil && input.ScheduledDeliveryWindow.Validate() == nil {
+		quoted, err = u.pricing.VerifyQuote(input.QuoteToken, input.RoutingInfo, input.ScheduledDeliveryWindow, now)
+		switch {
+		case errors.Is(err, pricing.ErrExpiredQuote):
+			v.Add("quote_token", internal_error.CodeExpired, "has expired")
+		case errors.Is(err, pricing.ErrQuoteMismatch):
+			v.Add("quote_token", internal_error.CodeQuoteMismatch, "was issued for another routing_info or scheduled_delivery_window")
+		case err != nil:
+			v.Add("quote_token", internal_error.CodeInvalid, "is invalid")
+		}
+	}
+
+	return zone, quoted, v.Err()
+}
+
+// validateDelivery locates the zone routingInfo is delivered in and, once the inputs are
+// well formed, checks them against the service area, the zone's window policy and the maximum distance.
+func (u *usecase) validateDelivery(
+	ctx context.Context,
+	v *internal_error.Violations,
+	routingInfo domain.RoutingInfo,
+	window domain.ScheduledDeliveryWindow,
+	now time.Time,
+) (*domain.Zone, error) {
+	var zone *domain.Zone
+	if u.zones != nil && routingInfo.Validate() == nil {
+		originZone, err := u.zones.Locate(ctx, routingInfo.Origin)
+		if err != nil {
+			return nil, err
+		}
+		if originZone == nil {
+			v.Add("routing_info.origin", internal_error.CodeOutOfService, "is outside of the service area")
+		}
+
+		zone, err = u.zones.Locate(ctx, routingInfo.Destination)
+		if err != nil {
+			return nil, err
+		}
+		if zone == nil {
+			v.Add("routing_info.destination", internal_error.CodeOutOfService, "is outside of the service area")
+		}
+	}
+
+	if window.Validate() == nil {
+		policy := u.windowPolicy(zone)
+		v.Merge("scheduled_delivery_window", policy.Validate(window, now))
+	}
+
+	if u.config.MaxDistanceMeters > 0 && routingInfo.Validate() == nil {
+		if distance := geo.Distance(routingInfo.Origin, routingInfo.Destination); distance > u.config.MaxDistanceMeters {
+			v.Add("routing_info", internal_error.CodeTooFar, fmt.Sprintf("distance must be at most %.0f meters", u.config.MaxDistanceMeters))
+		}
+	}
+
+	return zone, nil
+}
+
+// validateAddress flags addresses geocoded too far from the destination they are delivered to.
+// Addresses the geocoder cannot resolve down to their street are not flagged: a postal code or
+// city match says nothing about where in it they are.
+func (u *usecase) validateAddress(
+	ctx context.Context,
+	v *internal_error.Violations,
+	address *domain.Address,
+	destination domain.Location,
+) error {
+	if u.geocoder == nil || u.config.MaxAddressMismatchMeters <= 0 || address.Validate() != nil || destination.Validate() != nil {
+		return nil
+	}
+
+	geocode, err := u.geocoder.Geocode(ctx, address)
+	if err != nil {
+		return err
+	}
+	if geocode == nil || geocode.Precision != domain.GeocodePrecisionStreet {
+		return nil
+	}
+
+	if geo.Distance(geocode.Location, destination) > u.config.MaxAddressMismatchMeters {
+		v.Add("user_info.address", internal_error.CodeAddressMismatch, fmt.Sprintf("must be within %.0f meters of routing_info.destination", u.config.MaxAddressMismatchMeters))
+	}
+
+	return nil
+}
+
+// zoneLocation returns the timezone of zone, falling back to the window policy's one outside of zones.
+func (u *usecase) zoneLocation(zone *domain.Zone) *time.Location {
+	switch {
+	case zone != nil:
+		return zone.Timezone
+	case u.config.WindowPolicy.Location != nil:
+		return u.config.WindowPolicy.Location
+	default:
+		return time.Local
+	}
+}
+
+// windowPolicy returns the window policy evaluated in the zone's timezone.
+func (u *usecase) windowPolicy(zone *domain.Zone) *domain.WindowPolicy {
+	policy := u.config.WindowPolicy
+	if zone != nil {
+		policy.Location = zone.Timezone
+	}
+	return &policy
+}
+
+// zone returns the zone with zoneID or nil when zones are disabled or the shipment is not in any zone.
+func (u *usecase) zone(ctx context.Context, zoneID string) (*domain.Zone, error) {
+	if u.zones == nil || zoneID == "" {
+		return nil, nil
+	}
+	return u.zones.Get(ctx, zoneID)
+}
+
+// shipmentZone returns the zone a stored shipment was booked in, nil once the zone was removed from
+// the configuration: the defaults then apply as they do to shipments outside of any zone.
+func (u *usecase) shipmentZone(ctx context.Context, logger *slog.Logger, zoneID string) (*domain.Zone, error) {
+	zone, err := u.zone(ctx, zoneID)
+
+	var notFoundErr internal_error.NotFoundError
+	if errors.As(err, &notFoundErr) {
+		logger.Warn("shipment zone is not configured anymore: using the defaults", slog.String("zone_id", zoneID))
+		return nil, nil
+	}
+
+	return zone, err
+}
+
+// encodePageToken hides the last uid of a page so clients do not build tokens themselves.
+func encodePageToken(lastUID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(lastUID))
+}
+
+func decodePageToken(token string) (string, error) {
+	lastUID, err := base64.RawURLEncoding.DecodeString(token)
+	return string(lastUID), err
+}
+
+func preferred3PL(zone *domain.Zone) string {
+	if zone == nil {
+		return ""
+	}
+	return zone.Preferred3PL
+}
+
+// tightestHour returns the capacity of the hour with the least remaining, unused hours having
+// defaultCapacity.
+func tightestHour(capacities []domain.SlotCapacity, hours []domain.ScheduledDeliveryWindow, defaultCapacity int) domain.SlotCapacity {
+	var tightest domain.SlotCapacity
+	for i, hour := range hours {
+		capacity := domain.SlotCapacity{StartTime: hour.StartTime, EndTime: hour.EndTime, Capacity: defaultCapacity}
+		for _, c := range capacities {
+			if c.StartTime.Equal(hour.StartTime) && c.EndTime.Equal(hour.EndTime) {
+				capacity = c
+				break
+			}
+		}
+
+		if i == 0 || capacity.Capacity-capacity.Reserved < tightest.Capacity-tightest.Reserved {
+			tightest = capacity
+		}
+	}
+	return tightest
+}
+
+// reserveSlot takes one unit of the capacity of the window's hours in the zone or fails with a
+// slot full conflict.
+func (u *usecase) reserveSlot(ctx context.Context, zone *domain.Zone, window domain.ScheduledDeliveryWindow) error {
+	if u.config.DefaultSlotCapacity <= 0 {
+		return nil
+	}
+
+	var zoneID string
+	if zone != nil {
+		zoneID = zone.ID
+	}
+
+	reserved, err := u.repo.ReserveSlot(ctx, zoneID, window.Hours(u.zoneLocation(zone)), u.config.DefaultSlotCapacity)
+	if err != nil {
+		return err
+	}
+
+	if !reserved {
+		return errSlotFull
+	}
+
+	return nil
+}
+
+// releaseSlot gives back a reserved unit of the window's capacity.
+// Failures are only logged since the booking itself has already been decided.
+func (u *usecase) releaseSlot(ctx context.Context, logger *slog.Logger, zoneID string, window domain.ScheduledDeliveryWindow) {
+	if u.config.DefaultSlotCapacity <= 0 {
+		return
+	}
+
+	// the hours are those of the zone's timezone the window was reserved in
+	zone, err := u.shipmentZone(ctx, logger, zoneID)
+	if err != nil {
+		logger.Error("failed to fetch zone", slog.String("zone_id", zoneID), slog.Any("error", err))
+		return
+	}
+
+	if err := u.repo.ReleaseSlot(ctx, zoneID, window.Hours(u.zoneLocation(zone))); err != nil {
+		logger.Error("failed to release slot", slog.Any("error", err))
+	}
+}
+
+// releaseSlots releases the slots reserved for shipments, skipping the ones in keep.
+func (u *usecase) releaseSlots(ctx context.Context, logger *slog.Logger, shipments []*domain.Shipment, keep map[string]struct{}) {
+	for _, shipment := range shipments {
+		if _, ok := keep[shipment.UID]; ok {
+			continue
+		}
+
+		u.releaseSlot(ctx, logger, shipment.ZoneID, domain.ScheduledDeliveryWindow{
+			StartTime: shipment.ScheduledDeliveryMinTime,
+			EndTime:   shipment.ScheduledDeliveryMaxTime,
+		})
+	}
+}
+
+func (u *usecase) newShipment(input *domain.RequestInput, zone *domain.Zone, price domain.Price, status string) *domain.Shipment {
+	var zoneID string
+	if zone != nil {
+		zoneID = zone.ID
+	}
+
+	distance := geo.Distance(input.RoutingInfo.Origin, input.RoutingInfo.Destination)
+
+	return &domain.Shipment{
+		UID:                      input.ShipmentUID,
+		UserUID:                  input.UserInfo.UserUID,
+		UserAddr:                 input.UserInfo.Address.String(),
+		UserAddress:              input.UserInfo.Address,
+		OriginPoint:              input.RoutingInfo.Origin,
+		DestinationPoint:         input.RoutingInfo.Destination,
+		ScheduledDeliveryMinTime: input.ScheduledDeliveryWindow.StartTime,
+		ScheduledDeliveryMaxTime: input.ScheduledDeliveryWindow.EndTime,
+		Status:                   status,
+		DistanceMeters:           distance,
+		ETASeconds:               int(geo.ETA(distance, u.config.AverageSpeedKmh).Seconds()),
+		ZoneID:                   zoneID,
+		PriceAmount:              price.Amount,
+		PriceCurrency:            price.Currency,
+	}
+}
@@ -0,0 +1,1092 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	internal_error "github.com/aria3ppp/delivery-service-simulator/internal/delivery/error"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/events"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/geocoder"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/repo"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/zones"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+)
+
+type fakeCore struct{}
+
+func (fakeCore) Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error) {
+	return &domain.CoreWebhookResult{}, nil
+}
+
+type recordingCore struct {
+	mu     sync.Mutex
+	inputs []domain.CoreWebhookInput
+}
+
+func (c *recordingCore) Webhook(ctx context.Context, input *domain.CoreWebhookInput) (*domain.CoreWebhookResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.inputs = append(c.inputs, *input)
+	return &domain.CoreWebhookResult{}, nil
+}
+
+type fake3PL struct {
+	mu        sync.Mutex
+	requested []string
+}
+
+func (f *fake3PL) RequestDeliveryGuy(ctx context.Context, input *domain.ThirdPartyLogisticsRequestDeliveryGuyInput) (*domain.ThirdPartyLogisticsRequestDeliveryGuyResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requested = append(f.requested, input.ShipmentUID)
+	return &domain.ThirdPartyLogisticsRequestDeliveryGuyResult{}, nil
+}
+
+func (f *fake3PL) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.requested)
+}
+
+func TestWebhookNotFoundCutoff(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	morning := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(morning)
+	_3pl := &fake3PL{}
+
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		fakeCore{},
+		_3pl,
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	if _, err := uc.Request(ctx, &domain.RequestInput{
+		ShipmentUID: "shipment",
+		UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+			Destination: domain.Location{Lat: 35.72, Long: 51.41},
+		},
+		// the window has started: a delivery guy is requested right away
+		ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+			StartTime: morning.Add(-time.Hour),
+			EndTime:   morning.Add(2 * time.Hour),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name       string
+		at         time.Time
+		rerequests bool
+	}{
+		{"before cutoff", time.Date(2026, 1, 5, 22, 59, 0, 0, time.UTC), true},
+		{"after cutoff", time.Date(2026, 1, 5, 23, 0, 0, 0, time.UTC), false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			fakeClock.Set(tt.at)
+
+			// the delivery guy requested before is searched for first
+			if _, err := uc.Webhook(ctx, &domain.WebhookInput{ShipmentUID: "shipment", Status: "searching"}); err != nil {
+				t.Fatal(err)
+			}
+
+			before := _3pl.count()
+			if _, err := uc.Webhook(ctx, &domain.WebhookInput{ShipmentUID: "shipment", Status: "not_found"}); err != nil {
+				t.Fatal(err)
+			}
+
+			if rerequested := _3pl.count() > before; rerequested != tt.rerequests {
+				t.Errorf("re-requested a delivery guy = %v, want %v", rerequested, tt.rerequests)
+			}
+		})
+	}
+}
+
+func TestRemovedZone(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	morning := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(morning)
+	_3pl := &fake3PL{}
+	memoryRepo := repo.NewMemoryRepo(fakeClock, logger)
+
+	// the shipments were booked in a zone the configuration does not have anymore
+	uc := usecase.NewUseCase(
+		&usecase.Config{DefaultSlotCapacity: 1, WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		fakeCore{},
+		_3pl,
+		memoryRepo,
+		zones.NewZones(nil, logger),
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	window := domain.ScheduledDeliveryWindow{StartTime: morning.Add(2 * time.Hour), EndTime: morning.Add(3 * time.Hour)}
+	for uid, status := range map[string]string{"searching": "searching", "queued": "queued"} {
+		if err := memoryRepo.InsertShipment(ctx, &domain.Shipment{
+			UID:                      uid,
+			ZoneID:                   "removed",
+			ScheduledDeliveryMinTime: window.StartTime,
+			ScheduledDeliveryMaxTime: window.EndTime,
+			Status:                   status,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := memoryRepo.ReserveSlot(ctx, "removed", window.Hours(time.UTC), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// the default cutoff applies
+	if _, err := uc.Webhook(ctx, &domain.WebhookInput{ShipmentUID: "searching", Status: "not_found"}); err != nil {
+		t.Fatal(err)
+	}
+	if _3pl.count() != 1 {
+		t.Errorf("requested %d delivery guys, want one before the default cutoff", _3pl.count())
+	}
+
+	// the slot is released in the default timezone
+	if _, err := uc.Cancel(ctx, &domain.CancelInput{ShipmentUID: "queued"}); err != nil {
+		t.Fatal(err)
+	}
+
+	capacities, err := memoryRepo.ListSlotCapacities(ctx, "removed", window.StartTime, window.EndTime)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, capacity := range capacities {
+		if capacity.Reserved != 0 {
+			t.Errorf("%s is still reserved %d times after the cancel", capacity.StartTime, capacity.Reserved)
+		}
+	}
+}
+
+func TestWebhookNotFoundTwice(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	morning := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	fakeClock := clock.NewFake(morning)
+	core := &recordingCore{}
+	_3pl := &fake3PL{}
+
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		core,
+		_3pl,
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	request := newTestRequest("shipment", domain.ScheduledDeliveryWindow{StartTime: morning.Add(-time.Hour), EndTime: morning.Add(time.Hour)})
+	if _, err := uc.Request(ctx, &request); err != nil {
+		t.Fatal(err)
+	}
+
+	before := _3pl.count()
+
+	// the 3pl delivered the same webhook twice
+	for range 2 {
+		if _, err := uc.Webhook(ctx, &domain.WebhookInput{ShipmentUID: "shipment", Status: "not_found"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if rerequested := _3pl.count() - before; rerequested != 1 {
+		t.Errorf("re-requested %d delivery guys, want 1", rerequested)
+	}
+
+	core.mu.Lock()
+	defer core.mu.Unlock()
+
+	if len(core.inputs) != 1 {
+		t.Errorf("forwarded %d webhooks to core, want 1", len(core.inputs))
+	}
+}
+
+func TestWebhookTracking(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+	core := &recordingCore{}
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		core,
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	if _, err := uc.Request(ctx, &domain.RequestInput{
+		ShipmentUID: "shipment",
+		UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+			Destination: domain.Location{Lat: 35.72, Long: 51.41},
+		},
+		// the window has started: a delivery guy is requested right away
+		ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+			StartTime: now.Add(-time.Hour),
+			EndTime:   now.Add(2 * time.Hour),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	courier := &domain.Courier{ID: "courier", Name: "Ali", Phone: "+98 912 000 0000", Vehicle: "motorbike"}
+	eta := now.Add(30 * time.Minute)
+	proof := &domain.ProofOfDelivery{DeliveredAt: now.Add(40 * time.Minute), RecipientName: "Sara", PhotoRef: "pod/shipment.jpg"}
+
+	for _, input := range []*domain.WebhookInput{
+		{Version: 2, ShipmentUID: "shipment", Status: "found", Tracking: domain.Tracking{Courier: courier, DropoffETA: &eta}},
+		// version 1 payloads only carry the status
+		{ShipmentUID: "shipment", Status: "picked_up", Tracking: domain.Tracking{Courier: &domain.Courier{ID: "ignored"}}},
+		{Version: 2, ShipmentUID: "shipment", Status: "delivered", Tracking: domain.Tracking{ProofOfDelivery: proof}},
+	} {
+		if _, err := uc.Webhook(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := uc.Get(ctx, &domain.GetInput{ShipmentUID: "shipment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tracking := result.Shipment.Tracking
+	if tracking.Courier == nil || *tracking.Courier != *courier || tracking.DropoffETA == nil || tracking.ProofOfDelivery == nil || *tracking.ProofOfDelivery != *proof {
+		t.Errorf("tracking = %+v, want the courier, dropoff eta and proof of delivery reported", tracking)
+	}
+
+	core.mu.Lock()
+	defer core.mu.Unlock()
+
+	if len(core.inputs) != 3 {
+		t.Fatalf("forwarded %d webhooks to core, want 3", len(core.inputs))
+	}
+	if forwarded := core.inputs[0]; forwarded.Version != 2 || forwarded.Courier == nil || *forwarded.Courier != *courier {
+		t.Errorf("forwarded %+v, want the version 2 tracking", forwarded)
+	}
+	if forwarded := core.inputs[1]; forwarded.Version != 1 || !forwarded.Tracking.IsZero() {
+		t.Errorf("forwarded %+v, want a version 1 payload without tracking", forwarded)
+	}
+}
+
+func TestWebhookStale(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		started  bool
+		cancel   bool
+		webhooks []string
+		want     string
+		forwards int
+	}{
+		{
+			name:     "in order",
+			started:  true,
+			webhooks: []string{"searching", "found", "picked_up", "in_transit", "delivered"},
+			want:     "delivered",
+			forwards: 5,
+		},
+		{
+			name:     "skipping lost statuses",
+			started:  true,
+			webhooks: []string{"found", "delivered"},
+			want:     "delivered",
+			forwards: 2,
+		},
+		{
+			name:     "in transit after delivered",
+			started:  true,
+			webhooks: []string{"found", "delivered", "in_transit"},
+			want:     "delivered",
+			forwards: 2,
+		},
+		{
+			name:     "searching after found",
+			started:  true,
+			webhooks: []string{"found", "searching"},
+			want:     "found",
+			forwards: 1,
+		},
+		{
+			name:     "after returned",
+			started:  true,
+			webhooks: []string{"delivery_failed", "returned", "delivery_failed", "delivered"},
+			want:     "returned",
+			forwards: 2,
+		},
+		{
+			name:     "after cancelled",
+			cancel:   true,
+			webhooks: []string{"found", "delivered"},
+			want:     "cancelled",
+			// only the cancellation itself
+			forwards: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := &recordingCore{}
+
+			fakeClock := clock.NewFake(now)
+			uc := usecase.NewUseCase(
+				&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+				core,
+				&fake3PL{},
+				repo.NewMemoryRepo(fakeClock, logger),
+				nil,
+				nil,
+				nil,
+				fakeClock,
+				logger,
+			)
+
+			window := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+			if tt.started {
+				window.StartTime = now.Add(-time.Hour)
+			}
+
+			request := newTestRequest("shipment", window)
+			if _, err := uc.Request(ctx, &request); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.cancel {
+				if _, err := uc.Cancel(ctx, &domain.CancelInput{ShipmentUID: "shipment"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			for _, status := range tt.webhooks {
+				if _, err := uc.Webhook(ctx, &domain.WebhookInput{ShipmentUID: "shipment", Status: status}); err != nil {
+					t.Fatalf("webhook %s: %v", status, err)
+				}
+			}
+
+			result, err := uc.Get(ctx, &domain.GetInput{ShipmentUID: "shipment"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Shipment.Status != tt.want {
+				t.Errorf("status = %q, want %q", result.Shipment.Status, tt.want)
+			}
+
+			core.mu.Lock()
+			defer core.mu.Unlock()
+
+			// stale webhooks are neither stored nor forwarded to core
+			if len(core.inputs) != tt.forwards {
+				t.Errorf("forwarded %d webhooks to core, want %d", len(core.inputs), tt.forwards)
+			}
+		})
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		events.NewMemoryBus(logger),
+		fakeClock,
+		logger,
+	)
+
+	if _, err := uc.Subscribe(ctx, &domain.SubscribeInput{ShipmentUID: "unknown"}); !errors.Is(err, internal_error.ErrShipmentNotFound) {
+		t.Errorf("subscribing to an unknown shipment: %v, want not found", err)
+	}
+
+	if _, err := uc.Request(ctx, &domain.RequestInput{
+		ShipmentUID: "shipment",
+		UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+			Destination: domain.Location{Lat: 35.72, Long: 51.41},
+		},
+		// the window has started: a delivery guy is requested right away
+		ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+			StartTime: now.Add(-time.Hour),
+			EndTime:   now.Add(2 * time.Hour),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := uc.Subscribe(ctx, &domain.SubscribeInput{ShipmentUID: "shipment"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Current.Status != "requested" {
+		t.Errorf("current status = %q, want requested", result.Current.Status)
+	}
+
+	location := domain.Location{Lat: 35.71, Long: 51.405}
+	for _, input := range []*domain.WebhookInput{
+		{Version: 2, ShipmentUID: "shipment", Status: "found", Tracking: domain.Tracking{Courier: &domain.Courier{ID: "courier"}}},
+		{Version: 2, ShipmentUID: "shipment", Status: "found", Tracking: domain.Tracking{CourierLocation: &location}},
+	} {
+		if _, err := uc.Webhook(ctx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, located := <-result.Events, <-result.Events
+	if found.Status != "found" || found.Tracking == nil || found.Tracking.Courier == nil {
+		t.Errorf("first event = %+v, want found with its courier", found)
+	}
+	// events carry the whole tracking, not only what the webhook changed
+	if tracking := located.Tracking; tracking == nil || tracking.Courier == nil || tracking.CourierLocation == nil || *tracking.CourierLocation != location {
+		t.Errorf("second event = %+v, want the courier at %v", located, location)
+	}
+
+	cancel()
+
+	if _, ok := <-result.Events; ok {
+		t.Error("received an event after the subscription ended")
+	}
+}
+
+func TestWatchStatuses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		events.NewMemoryBus(logger),
+		fakeClock,
+		logger,
+	)
+
+	if _, err := uc.Request(ctx, &domain.RequestInput{
+		ShipmentUID: "shipment",
+		UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+			Destination: domain.Location{Lat: 35.72, Long: 51.41},
+		},
+		ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+			StartTime: now.Add(-time.Hour),
+			EndTime:   now.Add(2 * time.Hour),
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := uc.Watch(ctx, &domain.WatchInput{ShipmentFilter: domain.ShipmentFilter{Statuses: []string{"requested", "searching"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Current) != 1 || result.Current[0].Status != "requested" {
+		t.Fatalf("current = %+v, want the requested shipment", result.Current)
+	}
+
+	for _, status := range []string{"searching", "found", "picked_up"} {
+		if _, err := uc.Webhook(ctx, &domain.WebhookInput{ShipmentUID: "shipment", Status: status}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the shipment is seen leaving the watched statuses, not afterwards
+	for _, want := range []string{"searching", "found"} {
+		select {
+		case event := <-result.Events:
+			if event.Status != want {
+				t.Errorf("event = %+v, want %s", event, want)
+			}
+		default:
+			t.Fatalf("no %s event", want)
+		}
+	}
+
+	select {
+	case event := <-result.Events:
+		t.Errorf("received %+v once the shipment left the watched statuses", event)
+	default:
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{WindowPolicy: domain.WindowPolicy{Location: time.UTC}},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	for i := range 5 {
+		if _, err := uc.Request(ctx, &domain.RequestInput{
+			ShipmentUID: fmt.Sprintf("shipment_%d", i),
+			UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+			RoutingInfo: domain.RoutingInfo{
+				Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+				Destination: domain.Location{Lat: 35.72, Long: 51.41},
+			},
+			ScheduledDeliveryWindow: domain.ScheduledDeliveryWindow{
+				StartTime: now.Add(time.Hour),
+				EndTime:   now.Add(2 * time.Hour),
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// pages follow each other in uid order until the last one, which has no next token
+	var (
+		listed []string
+		token  string
+		pages  int
+	)
+	for {
+		result, err := uc.List(ctx, &domain.ListInput{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages++
+
+		for _, shipment := range result.Shipments {
+			listed = append(listed, shipment.UID)
+		}
+
+		if token = result.NextPageToken; token == "" {
+			break
+		}
+	}
+
+	if got, want := strings.Join(listed, ","), "shipment_0,shipment_1,shipment_2,shipment_3,shipment_4"; got != want || pages != 3 {
+		t.Errorf("listed %s in %d pages, want %s in 3", got, pages, want)
+	}
+
+	var validationErr internal_error.ValidationError
+	if _, err := uc.List(ctx, &domain.ListInput{PageToken: "not a token"}); !errors.As(err, &validationErr) {
+		t.Errorf("listing from an invalid token: %v, want a validation error", err)
+	}
+}
+
+func TestRequestBatch(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	r := repo.NewMemoryRepo(fakeClock, logger)
+	_3pl := &fake3PL{}
+
+	uc := usecase.NewUseCase(
+		&usecase.Config{
+			MaxBatchSize: 6,
+			WindowPolicy: domain.WindowPolicy{Location: time.UTC},
+		},
+		fakeCore{},
+		_3pl,
+		r,
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	window := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+	started := domain.ScheduledDeliveryWindow{StartTime: now.Add(-time.Hour), EndTime: now.Add(time.Hour)}
+
+	existing := newTestRequest("existing", window)
+	if _, err := uc.Request(ctx, &existing); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := newTestRequest("invalid", window)
+	invalid.UserInfo.UserUID = ""
+
+	// a later item with an invalid first occurrence of its uid is still created
+	invalidFirst := newTestRequest("retried", window)
+	invalidFirst.RoutingInfo.Origin = domain.Location{Lat: 91, Long: 51.4}
+
+	result, err := uc.RequestBatch(ctx, &domain.BatchRequestInput{Items: []domain.RequestInput{
+		newTestRequest("created", window),
+		invalid,
+		newTestRequest("created", window),
+		newTestRequest("existing", window),
+		invalidFirst,
+		newTestRequest("retried", started),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		uid    string
+		status string
+		code   string
+	}{
+		{"created", domain.BatchItemStatusCreated, ""},
+		{"invalid", domain.BatchItemStatusInvalid, internal_error.CodeRequired},
+		{"created", domain.BatchItemStatusDuplicate, ""},
+		{"existing", domain.BatchItemStatusDuplicate, ""},
+		{"retried", domain.BatchItemStatusInvalid, internal_error.CodeOutOfRange},
+		{"retried", domain.BatchItemStatusCreated, ""},
+	}
+
+	if len(result.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(result.Results), len(want))
+	}
+	for i, got := range result.Results {
+		if got.ShipmentUID != want[i].uid || got.Status != want[i].status {
+			t.Errorf("item %d = %s %s, want %s %s", i, got.ShipmentUID, got.Status, want[i].uid, want[i].status)
+		}
+		if want[i].code != "" && (len(got.Violations) == 0 || got.Violations[0].Code != want[i].code) {
+			t.Errorf("item %d violations = %+v, want %s", i, got.Violations, want[i].code)
+		}
+	}
+
+	// shipments within their window are left pending to the shipping worker
+	for uid, status := range map[string]string{"created": "queued", "retried": "pending"} {
+		shipment, err := r.GetShipment(ctx, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shipment.Status != status {
+			t.Errorf("%s is %q, want %q", uid, shipment.Status, status)
+		}
+	}
+	if _3pl.count() != 0 {
+		t.Errorf("requested %d delivery guys inline, want none", _3pl.count())
+	}
+
+	for _, tt := range []struct {
+		name  string
+		items int
+		code  string
+	}{
+		{"empty", 0, internal_error.CodeRequired},
+		{"too large", 7, internal_error.CodeTooMany},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			items := make([]domain.RequestInput, tt.items)
+			for i := range items {
+				items[i] = newTestRequest(fmt.Sprintf("%s_%d", tt.name, i), window)
+			}
+
+			_, err := uc.RequestBatch(ctx, &domain.BatchRequestInput{Items: items})
+
+			var validationErr internal_error.ValidationError
+			if !errors.As(err, &validationErr) || len(validationErr.Violations) != 1 || validationErr.Violations[0].Code != tt.code {
+				t.Fatalf("got %v, want a %s violation", err, tt.code)
+			}
+			if validationErr.Violations[0].Field != "items" {
+				t.Errorf("violation field = %q, want items", validationErr.Violations[0].Field)
+			}
+		})
+	}
+
+	// a refused batch creates none of its items
+	if _, err := r.GetShipment(ctx, "too large_0"); !errors.Is(err, internal_error.ErrShipmentNotFound) {
+		t.Errorf("getting an item of a refused batch: %v, want %v", err, internal_error.ErrShipmentNotFound)
+	}
+}
+
+func TestSlots(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{
+			WindowPolicy: domain.WindowPolicy{
+				AllowedDurations: []time.Duration{time.Hour, 2 * time.Hour},
+				AlignToHour:      true,
+				MinLeadTime:      2 * time.Hour,
+				Location:         time.UTC,
+			},
+		},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	// the lead time leaves the windows starting from 10:00
+	result, err := uc.Slots(ctx, &domain.SlotsInput{From: now, To: now.Add(5 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct{ start, end time.Duration }{
+		{2 * time.Hour, 3 * time.Hour},
+		{2 * time.Hour, 4 * time.Hour},
+		{3 * time.Hour, 4 * time.Hour},
+		{3 * time.Hour, 5 * time.Hour},
+		{4 * time.Hour, 5 * time.Hour},
+	}
+
+	if len(result.Slots) != len(want) {
+		t.Fatalf("got %d slots, want %d", len(result.Slots), len(want))
+	}
+	for i, slot := range result.Slots {
+		if !slot.StartTime.Equal(now.Add(want[i].start)) || !slot.EndTime.Equal(now.Add(want[i].end)) {
+			t.Errorf("slot %d = %v-%v, want %v-%v", i, slot.StartTime, slot.EndTime, now.Add(want[i].start), now.Add(want[i].end))
+		}
+		// without a slot capacity slots are unlimited
+		if slot.Capacity != nil || slot.Remaining != nil {
+			t.Errorf("slot %d has a capacity of %v with %v remaining, want none", i, slot.Capacity, slot.Remaining)
+		}
+	}
+
+	for _, tt := range []struct {
+		name     string
+		from, to time.Time
+		code     string
+	}{
+		{"to before from", now.Add(2 * time.Hour), now.Add(time.Hour), internal_error.CodeInvalid},
+		{"beyond a week", now, now.AddDate(0, 0, 8), internal_error.CodeTooFar},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.Slots(ctx, &domain.SlotsInput{From: tt.from, To: tt.to})
+
+			var validationErr internal_error.ValidationError
+			if !errors.As(err, &validationErr) || len(validationErr.Violations) != 1 ||
+				validationErr.Violations[0].Field != "to" || validationErr.Violations[0].Code != tt.code {
+				t.Errorf("got %v, want a %s violation of to", err, tt.code)
+			}
+		})
+	}
+}
+
+func TestSlotsShareHours(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{
+			DefaultSlotCapacity: 2,
+			WindowPolicy: domain.WindowPolicy{
+				AllowedDurations: []time.Duration{time.Hour, 2 * time.Hour},
+				AlignToHour:      true,
+				Location:         time.UTC,
+			},
+		},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	nine := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+	nineToEleven := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(3 * time.Hour)}
+
+	for _, uid := range []string{"first", "second"} {
+		request := newTestRequest(uid, nine)
+		if _, err := uc.Request(ctx, &request); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the 2h window covers the full 9:00 hour
+	var conflictErr internal_error.ConflictError
+	overlapping := newTestRequest("overlapping", nineToEleven)
+	if _, err := uc.Request(ctx, &overlapping); !errors.As(err, &conflictErr) || conflictErr.Code != internal_error.CodeSlotFull {
+		t.Errorf("booking a window over a full hour: %v, want a %s conflict", err, internal_error.CodeSlotFull)
+	}
+
+	result, err := uc.Slots(ctx, &domain.SlotsInput{From: now.Add(time.Hour), To: now.Add(3 * time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		start, end time.Duration
+		remaining  int
+	}{
+		{time.Hour, 2 * time.Hour, 0},
+		{time.Hour, 3 * time.Hour, 0},
+		{2 * time.Hour, 3 * time.Hour, 2},
+	}
+
+	if len(result.Slots) != len(want) {
+		t.Fatalf("got %d slots, want %d", len(result.Slots), len(want))
+	}
+	for i, slot := range result.Slots {
+		if !slot.StartTime.Equal(now.Add(want[i].start)) || !slot.EndTime.Equal(now.Add(want[i].end)) ||
+			slot.Remaining == nil || *slot.Remaining != want[i].remaining {
+			t.Errorf("slot %d = %v-%v with %v remaining, want %d", i, slot.StartTime, slot.EndTime, slot.Remaining, want[i].remaining)
+		}
+	}
+}
+
+// TestSlotsShareHoursOffTheHour books windows starting a few minutes apart without AlignToHour,
+// they all cover the same hours.
+func TestSlotsShareHoursOffTheHour(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{
+			DefaultSlotCapacity: 1,
+			WindowPolicy:        domain.WindowPolicy{Location: time.UTC},
+		},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	window := func(offset time.Duration) domain.ScheduledDeliveryWindow {
+		start := now.Add(2*time.Hour + offset)
+		return domain.ScheduledDeliveryWindow{StartTime: start, EndTime: start.Add(time.Hour)}
+	}
+
+	first := newTestRequest("first", window(0))
+	if _, err := uc.Request(ctx, &first); err != nil {
+		t.Fatal(err)
+	}
+
+	for minute := 1; minute <= 4; minute++ {
+		var conflictErr internal_error.ConflictError
+		request := newTestRequest(fmt.Sprintf("offset_%d", minute), window(time.Duration(minute)*time.Minute))
+		if _, err := uc.Request(ctx, &request); !errors.As(err, &conflictErr) || conflictErr.Code != internal_error.CodeSlotFull {
+			t.Errorf("booking 10:%02d: %v, want a %s conflict", minute, err, internal_error.CodeSlotFull)
+		}
+	}
+
+	// cancelling gives back the hours of the window, whatever minute another one starts at
+	if _, err := uc.Cancel(ctx, &domain.CancelInput{ShipmentUID: "first"}); err != nil {
+		t.Fatal(err)
+	}
+
+	offset := newTestRequest("offset", window(30*time.Minute))
+	if _, err := uc.Request(ctx, &offset); err != nil {
+		t.Errorf("booking the released hours: %v", err)
+	}
+}
+
+func TestRequestBatchReleasesSlots(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{
+			MaxBatchSize:        10,
+			DefaultSlotCapacity: 3,
+			WindowPolicy:        domain.WindowPolicy{Location: time.UTC},
+		},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		nil,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	window := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+
+	existing := newTestRequest("existing", window)
+	if _, err := uc.Request(ctx, &existing); err != nil {
+		t.Fatal(err)
+	}
+
+	// existing holds one unit of the slot, created and the reservation of existing the other two:
+	// full is refused before existing turns out to be a duplicate and gives its unit back
+	result, err := uc.RequestBatch(ctx, &domain.BatchRequestInput{Items: []domain.RequestInput{
+		newTestRequest("created", window),
+		newTestRequest("existing", window),
+		newTestRequest("full", window),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		uid    string
+		status string
+	}{
+		{"created", domain.BatchItemStatusCreated},
+		{"existing", domain.BatchItemStatusDuplicate},
+		{"full", domain.BatchItemStatusInvalid},
+	}
+
+	if len(result.Results) != len(want) {
+		t.Fatalf("got %d results, want %d", len(result.Results), len(want))
+	}
+	for i, got := range result.Results {
+		if got.ShipmentUID != want[i].uid || got.Status != want[i].status {
+			t.Errorf("item %d = %s %s, want %s %s", i, got.ShipmentUID, got.Status, want[i].uid, want[i].status)
+		}
+	}
+	if full := result.Results[2]; len(full.Violations) == 0 || full.Violations[0].Code != internal_error.CodeSlotFull {
+		t.Errorf("full violations = %+v, want %s", full.Violations, internal_error.CodeSlotFull)
+	}
+
+	// the unit existing reserved again was released: exactly one is left
+	retry := newTestRequest("retry", window)
+	if _, err := uc.Request(ctx, &retry); err != nil {
+		t.Errorf("booking the released unit: %v", err)
+	}
+
+	var conflictErr internal_error.ConflictError
+	overbooked := newTestRequest("overbooked", window)
+	if _, err := uc.Request(ctx, &overbooked); !errors.As(err, &conflictErr) || conflictErr.Code != internal_error.CodeSlotFull {
+		t.Errorf("booking a full slot: %v, want a %s conflict", err, internal_error.CodeSlotFull)
+	}
+}
+
+func TestRequestAddressMismatch(t *testing.T) {
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	now := time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)
+
+	// the only known address is far from the destinations of test requests
+	g := geocoder.NewGeocoder([]geocoder.Entry{{
+		Address:  domain.Address{Street: "1 Azadi Sq", City: "Tehran", Country: "IR"},
+		Location: domain.Location{Lat: 35.6997, Long: 51.3380},
+	}}, logger)
+
+	fakeClock := clock.NewFake(now)
+	uc := usecase.NewUseCase(
+		&usecase.Config{
+			MaxAddressMismatchMeters: 2_000,
+			WindowPolicy:             domain.WindowPolicy{Location: time.UTC},
+		},
+		fakeCore{},
+		&fake3PL{},
+		repo.NewMemoryRepo(fakeClock, logger),
+		nil,
+		g,
+		nil,
+		fakeClock,
+		logger,
+	)
+
+	window := domain.ScheduledDeliveryWindow{StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)}
+
+	for _, tt := range []struct {
+		name     string
+		address  domain.Address
+		mismatch bool
+	}{
+		{"street match", domain.Address{Street: "1 Azadi Sq", City: "Tehran", Country: "IR"}, true},
+		{"city match", domain.Address{Street: "99 Unknown St", City: "Tehran", Country: "IR"}, false},
+		{"unknown", domain.Address{Street: "99 Unknown St", City: "Shiraz", Country: "IR"}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			request := newTestRequest(tt.name, window)
+			request.UserInfo.Address = tt.address
+
+			_, err := uc.Request(ctx, &request)
+
+			var validationErr internal_error.ValidationError
+			mismatch := errors.As(err, &validationErr) && slices.ContainsFunc(validationErr.Violations, func(v internal_error.FieldViolation) bool {
+				return v.Code == internal_error.CodeAddressMismatch
+			})
+			if mismatch != tt.mismatch {
+				t.Errorf("request returned %v, want an address mismatch: %v", err, tt.mismatch)
+			}
+		})
+	}
+}
+
+func newTestRequest(uid string, window domain.ScheduledDeliveryWindow) domain.RequestInput {
+	return domain.RequestInput{
+		ShipmentUID: uid,
+		UserInfo:    domain.UserInfo{UserUID: "user", Address: domain.Address{Street: "12 Valiasr St"}},
+		RoutingInfo: domain.RoutingInfo{
+			Origin:      domain.Location{Lat: 35.7, Long: 51.4},
+			Destination: domain.Location{Lat: 35.72, Long: 51.41},
+		},
+		ScheduledDeliveryWindow: window,
+	}
+}
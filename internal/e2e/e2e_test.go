@@ -0,0 +1,685 @@
+package e2e_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	deliveryv1 "github.com/aria3ppp/delivery-service-simulator/api/delivery/v1"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	"github.com/aria3ppp/delivery-service-simulator/internal/e2e"
+	"github.com/aria3ppp/delivery-service-simulator/internal/threepl"
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestLifecycle(t *testing.T) {
+	for _, backend := range []struct {
+		name        string
+		databaseURL string
+	}{
+		{"memory", ""},
+		{"postgres", os.Getenv("DATABASE_URL")},
+	} {
+		t.Run(backend.name, func(t *testing.T) {
+			if backend.name == "postgres" && backend.databaseURL == "" {
+				t.Skip("DATABASE_URL is not set")
+			}
+
+			ctx := context.Background()
+
+			h := e2e.New(t, &e2e.Config{
+				Start:         time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+				Step:          5 * time.Minute,
+				DatabaseURL:   backend.databaseURL,
+				MigrationsURL: "file://../../migrations",
+			})
+
+			uids, err := h.Seed(ctx, fmt.Sprintf("e2e_%d_", time.Now().UnixNano()), 50, 12)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			statuses, err := h.RunUntil(ctx, uids, []string{"delivered"}, 14*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for uid, status := range statuses {
+				if status != "delivered" {
+					t.Errorf("%s ended %q, want delivered", uid, status)
+				}
+			}
+		})
+	}
+}
+
+func TestLifecycleUnderShortage(t *testing.T) {
+	ctx := context.Background()
+
+	scenario, err := threepl.LoadScenario("../../scenarios/peak_hour_shortage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := e2e.New(t, &e2e.Config{
+		Start:    time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:     time.Minute,
+		Scenario: scenario,
+	})
+
+	uids, err := h.Seed(ctx, "shortage_", 50, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// searches ending without a delivery guy are requested again until one is found
+	statuses, err := h.RunUntil(ctx, uids, []string{"delivered"}, 14*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for uid, status := range statuses {
+		if status != "delivered" {
+			t.Errorf("%s ended %q, want delivered", uid, status)
+		}
+	}
+}
+
+func TestLifecycleUnderFaults(t *testing.T) {
+	ctx := context.Background()
+
+	faults := threepl.Faults{
+		Seed:                  1,
+		RequestErrorRate:      0.3,
+		WebhookDuplicateRate:  0.2,
+		WebhookOutOfOrderRate: 0.2,
+		UnknownWebhookRate:    0.2,
+	}
+
+	h := e2e.New(t, &e2e.Config{
+		Start:  time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:   5 * time.Minute,
+		Faults: &faults,
+	})
+
+	uids, err := h.Seed(ctx, "faults_", 50, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// while the 3pl is down a cycle requests each due shipment once and leaves it pending
+	if err := h.SetFaults(threepl.Faults{Seed: 1, RequestErrorRate: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	statuses, err := h.Statuses(ctx, uids)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending := 0
+	for uid, status := range statuses {
+		switch status {
+		case "pending":
+			pending++
+		case "queued":
+		default:
+			t.Errorf("%s is %q while the 3pl is down, want pending or queued", uid, status)
+		}
+	}
+	if pending == 0 || h.Requests() != pending {
+		t.Errorf("%d requests for %d pending shipments, want one each", h.Requests(), pending)
+	}
+
+	if err := h.SetFaults(faults); err != nil {
+		t.Fatal(err)
+	}
+
+	// failed requests are dispatched again, duplicates, stale statuses and unknown shipments are absorbed
+	statuses, err = h.RunUntil(ctx, uids, []string{"delivered"}, 14*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for uid, status := range statuses {
+		if status != "delivered" {
+			t.Errorf("%s ended %q, want delivered", uid, status)
+		}
+	}
+}
+
+func TestLifecycleWithFleet(t *testing.T) {
+	ctx := context.Background()
+
+	scenario, err := threepl.LoadScenario("../../scenarios/tehran_fleet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := e2e.New(t, &e2e.Config{
+		Start:    time.Date(2026, 1, 5, 5, 0, 0, 0, time.UTC),
+		Step:     time.Minute,
+		Scenario: scenario,
+	})
+
+	uids, err := h.Seed(ctx, "fleet_", 50, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shipments nobody can take yet are requested again until a courier is free
+	if _, err := h.RunUntil(ctx, uids, []string{"delivered"}, 14*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, uid := range uids {
+		shipment, err := h.Shipment(ctx, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if tracking := shipment.Tracking; tracking.Courier == nil || tracking.CourierLocation == nil || tracking.ProofOfDelivery == nil {
+			t.Errorf("%s: tracking = %+v, want its courier, location and proof of delivery", uid, tracking)
+		}
+	}
+}
+
+func TestLifecycleWithFailedDeliveries(t *testing.T) {
+	ctx := context.Background()
+
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+		Scenario: &threepl.Scenario{
+			Seed:                       1,
+			DeliveryFailureProbability: 0.3,
+		},
+	})
+
+	uids, err := h.Seed(ctx, "failed_", 50, 12)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// shipments the courier could not hand over are brought back to the origin
+	statuses, err := h.RunUntil(ctx, uids, []string{"delivered", "returned"}, 14*time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var returned int
+	for uid, status := range statuses {
+		if status != "returned" {
+			continue
+		}
+		returned++
+
+		shipment, err := h.Shipment(ctx, uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if shipment.Tracking.FailureReason == "" || shipment.Tracking.ProofOfDelivery != nil {
+			t.Errorf("%s: tracking = %+v, want a failure reason and no proof of delivery", uid, shipment.Tracking)
+		}
+	}
+
+	if returned == 0 || returned == len(uids) {
+		t.Errorf("%d of %d shipments returned, want some", returned, len(uids))
+	}
+}
+
+func TestShipmentEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+	})
+
+	uids, err := h.Seed(ctx, "events_", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := h.Events(ctx, uids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the stream starts with the current state, then follows every change
+	var statuses []string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+
+		for event := range events {
+			if len(statuses) == 0 || statuses[len(statuses)-1] != event.Status {
+				statuses = append(statuses, event.Status)
+			}
+			if event.Status == "delivered" {
+				return
+			}
+		}
+	}()
+
+	if _, err := h.RunUntil(ctx, uids, []string{"delivered"}, 14*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivered event was not streamed")
+	}
+
+	if got, want := strings.Join(statuses, ","), "queued,pending,requested,searching,found,picked_up,in_transit,delivered"; got != want {
+		t.Errorf("streamed %s, want %s", got, want)
+	}
+}
+
+func TestWatchShipments(t *testing.T) {
+	ctx := context.Background()
+
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+	})
+
+	uids, err := h.Seed(ctx, "watch_", 20, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, "ws"+strings.TrimPrefix(h.DeliveryURL(), "http")+"/v1/shipments/watch", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	type message struct {
+		Type       string                 `json:"type"`
+		ID         string                 `json:"id"`
+		Events     []domain.ShipmentEvent `json:"events"`
+		Violations []json.RawMessage      `json:"violations"`
+	}
+
+	// every subscription is answered before the next one is sent
+	for _, subscription := range []struct {
+		request any
+		want    string
+	}{
+		{map[string]any{"type": "subscribe", "id": "invalid", "statuses": []string{"lost"}}, "error"},
+		{map[string]any{"type": "subscribe", "id": "mine", "shipment_uids": uids[:5]}, "subscribed"},
+		{map[string]any{"type": "subscribe", "id": "delivered", "statuses": []string{"delivered"}}, "subscribed"},
+	} {
+		if err := conn.WriteJSON(subscription.request); err != nil {
+			t.Fatal(err)
+		}
+
+		var reply message
+		if err := conn.ReadJSON(&reply); err != nil {
+			t.Fatal(err)
+		}
+		if reply.Type != subscription.want {
+			t.Fatalf("%v answered %+v, want %s", subscription.request, reply, subscription.want)
+		}
+		if reply.ID == "invalid" && len(reply.Violations) == 0 {
+			t.Errorf("invalid subscription answered %+v, want its violations", reply)
+		}
+		if reply.ID == "mine" && len(reply.Events) != 5 {
+			t.Errorf("subscription to 5 shipments started from %d", len(reply.Events))
+		}
+	}
+
+	delivered := make(map[string]bool)
+	done := make(chan error, 1)
+	go func() {
+		for len(delivered) < len(uids) {
+			var batch message
+			if err := conn.ReadJSON(&batch); err != nil {
+				done <- err
+				return
+			}
+			if batch.Type != "events" {
+				done <- fmt.Errorf("received %+v, want events", batch)
+				return
+			}
+
+			for _, event := range batch.Events {
+				if event.Status == "delivered" {
+					delivered[event.ShipmentUID] = true
+				}
+			}
+		}
+		done <- nil
+	}()
+
+	if _, err := h.RunUntil(ctx, uids, []string{"delivered"}, 14*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("not every delivery was streamed")
+	}
+
+	// close cleanly so the server is done with the connection before the test ends
+	if err := conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+				t.Errorf("connection ended with %v, want a normal closure", err)
+			}
+			break
+		}
+	}
+}
+
+func TestGRPC(t *testing.T) {
+	ctx := context.Background()
+
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+	})
+	client := h.GRPC()
+
+	start := h.Now().Truncate(time.Hour)
+	input := &deliveryv1.RequestInput{
+		ShipmentUid: "grpc_shipment",
+		UserInfo: &deliveryv1.UserInfo{
+			UserUid: "grpc_user",
+			Address: &deliveryv1.Address{Street: "1 Valiasr St", City: "Tehran", Country: "IR"},
+		},
+		RoutingInfo: &deliveryv1.RoutingInfo{
+			Origin:      &deliveryv1.Location{Lat: 35.70, Long: 51.40},
+			Destination: &deliveryv1.Location{Lat: 35.72, Long: 51.41},
+		},
+		ScheduledDeliveryWindow: &deliveryv1.ScheduledDeliveryWindow{
+			StartTime: timestamppb.New(start),
+			EndTime:   timestamppb.New(start.Add(time.Hour)),
+		},
+	}
+
+	// validation errors carry their field violations
+	_, err := client.Request(ctx, &deliveryv1.RequestInput{ShipmentUid: "grpc_invalid"})
+	if code := status.Code(err); code != codes.InvalidArgument {
+		t.Fatalf("invalid request failed with %v, want %s", err, codes.InvalidArgument)
+	}
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			violations = append(violations, badRequest.GetFieldViolations()...)
+		}
+	}
+	if len(violations) == 0 {
+		t.Errorf("invalid request failed with %v, want its field violations", err)
+	}
+
+	if _, err := client.Request(ctx, input); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Request(ctx, input); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("duplicate request failed with %v, want %s", err, codes.AlreadyExists)
+	}
+
+	if _, err := client.Get(ctx, &deliveryv1.GetInput{ShipmentUid: "grpc_missing"}); status.Code(err) != codes.NotFound {
+		t.Errorf("get of a missing shipment failed with %v, want %s", err, codes.NotFound)
+	}
+
+	got, err := client.Get(ctx, &deliveryv1.GetInput{ShipmentUid: input.ShipmentUid})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shipment := got.GetShipment(); shipment.GetStatus() != "queued" || !shipment.GetScheduledDeliveryMinTime().AsTime().Equal(start) {
+		t.Errorf("got %v, want a queued shipment starting at %s", shipment, start)
+	}
+
+	list, err := client.List(ctx, &deliveryv1.ListInput{Filter: &deliveryv1.ShipmentFilter{Statuses: []string{"queued"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetShipments()) != 1 || list.GetShipments()[0].GetUid() != input.ShipmentUid {
+		t.Errorf("listed %v, want %s only", list.GetShipments(), input.ShipmentUid)
+	}
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	defer cancelWatch()
+
+	stream, err := client.Watch(watchCtx, &deliveryv1.WatchInput{
+		Filter: &deliveryv1.ShipmentFilter{ShipmentUids: []string{input.ShipmentUid}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the stream starts with the current state, then follows every change
+	var statuses []string
+	done := make(chan error, 1)
+	go func() {
+		for {
+			event, err := stream.Recv()
+			if err != nil {
+				done <- err
+				return
+			}
+			if len(statuses) == 0 || statuses[len(statuses)-1] != event.GetStatus() {
+				statuses = append(statuses, event.GetStatus())
+			}
+			if event.GetStatus() == "delivered" {
+				done <- nil
+				return
+			}
+		}
+	}()
+
+	if _, err := h.RunUntil(ctx, []string{input.ShipmentUid}, []string{"delivered"}, 14*time.Hour); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("delivered event was not streamed")
+	}
+
+	if got, want := strings.Join(statuses, ","), "queued,pending,requested,searching,found,picked_up,in_transit,delivered"; got != want {
+		t.Errorf("streamed %s, want %s", got, want)
+	}
+
+	// delivered shipments are past cancelling
+	if _, err := client.Cancel(ctx, &deliveryv1.CancelInput{ShipmentUid: input.ShipmentUid}); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("cancel of a delivered shipment failed with %v, want %s", err, codes.FailedPrecondition)
+	}
+}
+
+func TestRequestValidation(t *testing.T) {
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+	})
+
+	resp, err := http.Get(h.DeliveryURL() + "/v1/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /v1/openapi.json: status code %d", resp.StatusCode)
+	}
+
+	// what cmd/seeder used to send: routing info without origin and destination
+	resp, err = http.Post(h.DeliveryURL()+"/v1/request", "application/json", strings.NewReader(`{
+		"shipment_uid": "drifted",
+		"user_info": {"user_uid": "user", "address": {"street": "1 Valiasr St"}},
+		"routing_info": {"lat": 0, "long": 0},
+		"scheduled_delivery_window": {"start_time": "2026-01-05T09:00:00Z", "end_time": "2026-01-05T10:00:00Z"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		Violations []struct {
+			Field string `json:"field"`
+			Code  string `json:"code"`
+		} `json:"violations"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status code %d, want %d", resp.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	var fields []string
+	for _, violation := range body.Violations {
+		fields = append(fields, violation.Field+":"+violation.Code)
+	}
+	for _, want := range []string{"routing_info.origin:required", "routing_info.destination:required"} {
+		if !slices.Contains(fields, want) {
+			t.Errorf("violations %v, want %s", fields, want)
+		}
+	}
+}
+
+func TestIdempotencyKey(t *testing.T) {
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	start := h.Now().Truncate(time.Hour)
+	input := &client.RequestInput{
+		ShipmentUID: "idempotent",
+		UserInfo: client.UserInfo{
+			UserUID: "user",
+			Address: client.Address{Street: "1 Valiasr St", City: "Tehran", Country: "IR"},
+		},
+		RoutingInfo: client.RoutingInfo{
+			Origin:      client.Location{Lat: 35.70, Long: 51.40},
+			Destination: client.Location{Lat: 35.72, Long: 51.41},
+		},
+		ScheduledDeliveryWindow: client.ScheduledDeliveryWindow{StartTime: start, EndTime: start.Add(time.Hour)},
+	}
+
+	// a retry of a request the service already handled is answered like the request, not as a duplicate
+	keyCtx := client.WithIdempotencyKey(ctx, "request-idempotent")
+	for range 2 {
+		if err := h.Client().Request(keyCtx, input); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var apiErr *client.Error
+	if err := h.Client().Request(ctx, input); !errors.As(err, &apiErr) || apiErr.Code != client.CodeDuplicate {
+		t.Errorf("request without the key failed with %v, want code %s", err, client.CodeDuplicate)
+	}
+
+	other := *input
+	other.ShipmentUID = "other"
+	if err := h.Client().Request(keyCtx, &other); !errors.As(err, &apiErr) ||
+		apiErr.StatusCode != http.StatusUnprocessableEntity || apiErr.Code != client.CodeIdempotencyKeyReused {
+		t.Errorf("request reusing the key failed with %v, want code %s", err, client.CodeIdempotencyKeyReused)
+	}
+
+	if _, err := h.Client().Get(ctx, "other"); !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("get of the rejected shipment failed with %v, want status code %d", err, http.StatusNotFound)
+	}
+
+	shipment, err := h.Client().Get(ctx, input.ShipmentUID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shipment.UserAddress.Street != input.UserInfo.Address.Street {
+		t.Errorf("booked shipment %+v", shipment)
+	}
+}
+
+func TestAPIVersions(t *testing.T) {
+	h := e2e.New(t, &e2e.Config{
+		Start: time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC),
+		Step:  5 * time.Minute,
+	})
+
+	for _, test := range []struct {
+		name       string
+		path       string
+		version    string
+		statusCode int
+		deprecated bool
+	}{
+		{name: "prefixed", path: "/v1/shipments/unknown", statusCode: http.StatusNotFound},
+		{name: "prefixed ignores the header", path: "/v1/shipments/unknown", version: "9", statusCode: http.StatusNotFound},
+		{name: "negotiated", path: "/shipments/unknown", version: "1", statusCode: http.StatusNotFound},
+		{name: "unversioned", path: "/shipments/unknown", statusCode: http.StatusNotFound, deprecated: true},
+		{name: "unsupported", path: "/shipments/unknown", version: "9", statusCode: http.StatusBadRequest},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, h.DeliveryURL()+test.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.version != "" {
+				req.Header.Set("API-Version", test.version)
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != test.statusCode {
+				t.Errorf("status code %d, want %d", resp.StatusCode, test.statusCode)
+			}
+			if resp.StatusCode != http.StatusBadRequest && resp.Header.Get("API-Version") != "1" {
+				t.Errorf("served by api version %q, want 1", resp.Header.Get("API-Version"))
+			}
+
+			deprecated := resp.Header.Get("Deprecation") != ""
+			if deprecated != test.deprecated {
+				t.Errorf("Deprecation header %q, want deprecated %t", resp.Header.Get("Deprecation"), test.deprecated)
+			}
+			if deprecated {
+				if _, err := http.ParseTime(resp.Header.Get("Sunset")); err != nil {
+					t.Errorf("Sunset header: %v", err)
+				}
+				if link := resp.Header.Get("Link"); link != `</v1/shipments/unknown>; rel="successor-version"` {
+					t.Errorf("Link header %q", link)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,399 @@
+// Package e2e runs the delivery service, the 3pl simulator and a seeder in-process against
+// a simulated clock, so a whole lifecycle can be driven from a go test.
+package e2e
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	deliveryv1 "github.com/aria3ppp/delivery-service-simulator/api/delivery/v1"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/app/config"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/domain"
+	_3pl "github.com/aria3ppp/delivery-service-simulator/internal/delivery/infras/3pl"
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+	"github.com/aria3ppp/delivery-service-simulator/internal/threepl"
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	_ "github.com/lib/pq"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+type Config struct {
+	// Start is the simulated time the harness starts at.
+	Start time.Time
+	// Step is how far the simulated clock moves between two ticks.
+	Step time.Duration
+	// DatabaseURL is the postgres the delivery service runs against. The in-memory repo is used when empty.
+	DatabaseURL string
+	// MigrationsURL is where migrations are applied from when DatabaseURL is set, e.g. "file://../../migrations".
+	MigrationsURL string
+	// UseCaseConfig configures the delivery service, DefaultUseCaseConfig is used when nil.
+	UseCaseConfig *usecase.Config
+	// Scenario drives the 3pl simulator, every search finds a delivery guy when nil.
+	Scenario *threepl.Scenario
+	// Faults is injected by the 3pl simulator from the start, see SetFaults to change it later.
+	Faults *threepl.Faults
+}
+
+// DefaultUseCaseConfig mirrors cmd/delivery without its window policy restrictions.
+func DefaultUseCaseConfig() *usecase.Config {
+	return &usecase.Config{
+		MaxBatchSize:        1000,
+		WindowPolicy:        domain.WindowPolicy{Location: time.UTC},
+		DefaultSlotCapacity: 500,
+		MaxDistanceMeters:   50_000,
+		AverageSpeedKmh:     25,
+	}
+}
+
+// deliveryService is the part of the delivery app the harness drives.
+type deliveryService interface {
+	Handler() http.Handler
+	GRPCServer() *grpc.Server
+	RunOnce(ctx context.Context) error
+}
+
+type Harness struct {
+	config *Config
+	clock  interface {
+		usecase.Clock
+		Advance(d time.Duration)
+	}
+	delivery deliveryService
+	threePL  *threepl.Simulator
+	seeder   *seeder
+	// requests counts the delivery guys requested from the 3pl simulator
+	requests atomic.Int64
+
+	deliveryServer *httptest.Server
+	threePLServer  *httptest.Server
+	grpcClient     deliveryv1.DeliveryServiceClient
+	client         *client.Client
+	logger         *slog.Logger
+}
+
+// New boots the harness. Everything is torn down at the end of the test.
+func New(t testing.TB, cfg *Config) *Harness {
+	t.Helper()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	if testing.Verbose() {
+		logger = slog.New(slog.NewTextHandler(&testWriter{t}, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	}
+
+	useCaseConfig := cfg.UseCaseConfig
+	if useCaseConfig == nil {
+		useCaseConfig = DefaultUseCaseConfig()
+	}
+
+	h := &Harness{
+		config: cfg,
+		clock:  clock.NewFake(cfg.Start),
+		logger: logger,
+	}
+
+	var sqlDB *sql.DB
+	if cfg.DatabaseURL != "" {
+		sqlDB = openDB(t, cfg.DatabaseURL, cfg.MigrationsURL)
+	}
+
+	// the delivery service and the 3pl call each other: serve the delivery service lazily so
+	// its url is known before the app is built
+	h.deliveryServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		h.delivery.Handler().ServeHTTP(w, req)
+	}))
+	t.Cleanup(h.deliveryServer.Close)
+
+	var threePLRepo threepl.Repo = threepl.NewMemoryRepo()
+	if sqlDB != nil {
+		threePLRepo = threepl.NewRepo(sqlDB, logger)
+	}
+
+	h.threePL = threepl.NewSimulator(
+		&threepl.Config{BatchSize: 100, StepDelay: 5 * time.Minute, Scenario: cfg.Scenario, Faults: cfg.Faults},
+		threePLRepo,
+		threepl.NewWebhookClient(client.NewClient(h.deliveryServer.URL, nil)),
+		h.clock,
+		logger,
+	)
+	threePLHandler := h.threePL.Handler()
+	h.threePLServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method == http.MethodPost && req.URL.Path == "/request" {
+			h.requests.Add(1)
+		}
+		threePLHandler.ServeHTTP(w, req)
+	}))
+	t.Cleanup(h.threePLServer.Close)
+
+	// the events listener, if any, stops with the test
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	delivery, err := app.New(
+		ctx,
+		&config.Config{
+			WorkerConfig: config.WorkerConfig{
+				PendingIntervalInSeconds:    int64(time.Hour.Seconds()),
+				PendingWorkerBatchSize:      100,
+				ShipmentWorkerBatchSize:     100,
+				DispatchRetryDelayInSeconds: int64(time.Minute.Seconds()),
+			},
+			UseCaseConfig:             *useCaseConfig,
+			ThirdPartyLogisticsConfig: _3pl.Config{URL: h.threePLServer.URL + "/request"},
+			EventsConfig:              config.EventsConfig{DatabaseURL: cfg.DatabaseURL},
+			RouterConfig:              config.Default().RouterConfig,
+		},
+		sqlDB,
+		h.clock,
+		logger,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.delivery = delivery
+
+	grpcListener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go delivery.GRPCServer().Serve(grpcListener)
+	t.Cleanup(delivery.GRPCServer().Stop)
+
+	grpcConn, err := grpc.NewClient(grpcListener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { grpcConn.Close() })
+	h.grpcClient = deliveryv1.NewDeliveryServiceClient(grpcConn)
+
+	h.client = client.NewClient(h.deliveryServer.URL, nil)
+	h.seeder = &seeder{client: h.client, clock: h.clock}
+
+	return h
+}
+
+// Now returns the simulated time.
+func (h *Harness) Now() time.Time {
+	return h.clock.Now()
+}
+
+// DeliveryURL is the base url of the delivery service.
+func (h *Harness) DeliveryURL() string {
+	return h.deliveryServer.URL
+}
+
+// GRPC is a client of the grpc api of the delivery service.
+func (h *Harness) GRPC() deliveryv1.DeliveryServiceClient {
+	return h.grpcClient
+}
+
+// Client is a client of the http api of the delivery service.
+func (h *Harness) Client() *client.Client {
+	return h.client
+}
+
+// SetFaults changes the faults the 3pl simulator injects.
+func (h *Harness) SetFaults(faults threepl.Faults) error {
+	return h.threePL.SetFaults(faults)
+}
+
+// Requests is how many delivery guys were requested from the 3pl simulator, failed requests included.
+func (h *Harness) Requests() int {
+	return int(h.requests.Load())
+}
+
+// Seed books n shipments with one hour windows spread over the next spreadHours hours.
+// Shipment uids are prefixed with prefix so runs sharing a database do not collide.
+func (h *Harness) Seed(ctx context.Context, prefix string, n, spreadHours int) ([]string, error) {
+	return h.seeder.seed(ctx, prefix, n, spreadHours)
+}
+
+// Tick runs one cycle of the delivery workers and of the 3pl simulator, then advances the clock by Step.
+func (h *Harness) Tick(ctx context.Context) error {
+	if err := h.delivery.RunOnce(ctx); err != nil {
+		return fmt.Errorf("delivery workers: %w", err)
+	}
+
+	if err := h.threePL.Tick(ctx); err != nil {
+		return fmt.Errorf("3pl workers: %w", err)
+	}
+
+	h.clock.Advance(h.config.Step)
+
+	return nil
+}
+
+// RunUntil ticks until every shipment reaches one of terminalStatuses or budget of simulated time runs out.
+// It returns the last known status of every shipment.
+func (h *Harness) RunUntil(ctx context.Context, uids []string, terminalStatuses []string, budget time.Duration) (map[string]string, error) {
+	deadline := h.Now().Add(budget)
+
+	for {
+		statuses, err := h.Statuses(ctx, uids)
+		if err != nil {
+			return nil, err
+		}
+
+		var pending []string
+		for _, uid := range uids {
+			if !slices.Contains(terminalStatuses, statuses[uid]) {
+				pending = append(pending, uid)
+			}
+		}
+
+		if len(pending) == 0 {
+			return statuses, nil
+		}
+
+		if h.Now().After(deadline) {
+			return statuses, fmt.Errorf("%d shipments did not reach %v within %s, e.g. %s is %q",
+				len(pending), terminalStatuses, budget, pending[0], statuses[pending[0]])
+		}
+
+		if err := h.Tick(ctx); err != nil {
+			return statuses, err
+		}
+	}
+}
+
+// Statuses fetches the status of every shipment from the delivery service.
+func (h *Harness) Statuses(ctx context.Context, uids []string) (map[string]string, error) {
+	statuses := make(map[string]string, len(uids))
+	for _, uid := range uids {
+		shipment, err := h.Shipment(ctx, uid)
+		if err != nil {
+			return nil, err
+		}
+
+		statuses[uid] = shipment.Status
+	}
+
+	return statuses, nil
+}
+
+// Shipment fetches a shipment from the delivery service.
+func (h *Harness) Shipment(ctx context.Context, uid string) (*domain.Shipment, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.deliveryServer.URL+"/v1/shipments/"+uid, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get shipment %s: status code %d", uid, resp.StatusCode)
+	}
+
+	var result domain.GetResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result.Shipment, nil
+}
+
+// Events streams the server-sent events of a shipment until ctx is done or the stream ends.
+func (h *Harness) Events(ctx context.Context, uid string) (<-chan domain.ShipmentEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.deliveryServer.URL+"/v1/shipments/"+uid+"/events", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("stream shipment %s events: status code %d", uid, resp.StatusCode)
+	}
+
+	events := make(chan domain.ShipmentEvent)
+	go func() {
+		defer close(events)
+		defer resp.Body.Close()
+
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+			if !ok {
+				continue
+			}
+
+			var event domain.ShipmentEvent
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				h.logger.Error("failed to decode event", slog.Any("error", err))
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+func openDB(t testing.TB, databaseURL, migrationsURL string) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := migrate.NewWithDatabaseInstance(migrationsURL, "postgres", driver)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// testWriter sends logs to the test output.
+type testWriter struct {
+	t testing.TB
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.t.Log(string(bytes.TrimRight(p, "\n")))
+	return len(p), nil
+}
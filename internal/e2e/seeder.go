@@ -0,0 +1,52 @@
+package e2e
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/usecase"
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
+)
+
+// seeder books shipments like cmd/seeder, with windows spread from the current hour on.
+type seeder struct {
+	client *client.Client
+	clock  usecase.Clock
+}
+
+func (s *seeder) seed(ctx context.Context, prefix string, n, spreadHours int) ([]string, error) {
+	if spreadHours < 1 {
+		spreadHours = 1
+	}
+
+	hour := s.clock.Now().Truncate(time.Hour)
+
+	uids := make([]string, 0, n)
+	for i := range n {
+		uid := fmt.Sprintf("%sshipment_%d", prefix, i)
+		start := hour.Add(time.Duration(i%spreadHours) * time.Hour)
+
+		if err := s.client.Request(ctx, &client.RequestInput{
+			ShipmentUID: uid,
+			UserInfo: client.UserInfo{
+				UserUID: fmt.Sprintf("%suser_%d", prefix, i),
+				Address: client.Address{Street: fmt.Sprintf("%d Valiasr St", i+1), City: "Tehran", Country: "IR"},
+			},
+			RoutingInfo: client.RoutingInfo{
+				Origin:      client.Location{Lat: 35.70 + float64(i%10)*0.001, Long: 51.40},
+				Destination: client.Location{Lat: 35.72, Long: 51.41 + float64(i%10)*0.001},
+			},
+			ScheduledDeliveryWindow: client.ScheduledDeliveryWindow{
+				StartTime: start,
+				EndTime:   start.Add(time.Hour),
+			},
+		}); err != nil {
+			return uids, fmt.Errorf("request %s: %w", uid, err)
+		}
+
+		uids = append(uids, uid)
+	}
+
+	return uids, nil
+}
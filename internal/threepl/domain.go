@@ -0,0 +1,77 @@
+package threepl
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrShipmentNotFound = errors.New("shipment not found")
+
+type Location struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+// IsZero reports whether the location was left out, e.g. by requests without routing info.
+func (l Location) IsZero() bool {
+	return l.Lat == 0 && l.Long == 0
+}
+
+type RoutingInfo struct {
+	Origin      Location `json:"origin"`
+	Destination Location `json:"destination"`
+}
+
+type Shipment struct {
+	ShipmentUID string
+	ZoneID      string
+	RoutingInfo RoutingInfo
+	// StartTime is when the shipment is due to move to its next status.
+	StartTime time.Time
+	// Retries counts the requests of the shipment, the first one included.
+	Retries int
+	Status  string
+	// ReportedStatus is the last status the delivery service acknowledged.
+	// A shipment only moves on once its status is reported.
+	ReportedStatus string
+	// CourierID is the courier of the fleet delivering the shipment, if any.
+	CourierID string
+}
+
+type RequestInput struct {
+	ShipmentUID string      `json:"shipment_uid"`
+	ZoneID      string      `json:"zone_id"`
+	RoutingInfo RoutingInfo `json:"routing_info"`
+}
+
+// WebhookVersion is the version of the webhook payloads the simulator sends.
+const WebhookVersion = 2
+
+type CourierInfo struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Vehicle string `json:"vehicle"`
+}
+
+type ProofOfDelivery struct {
+	DeliveredAt   time.Time `json:"delivered_at"`
+	RecipientName string    `json:"recipient_name"`
+	PhotoRef      string    `json:"photo_ref"`
+}
+
+type WebhookInput struct {
+	Version     int    `json:"version"`
+	ShipmentUID string `json:"shipment_uid"`
+	Status      string `json:"status"`
+	// Courier and CourierLocation are reported once a courier of the fleet is assigned.
+	Courier         *CourierInfo `json:"courier,omitempty"`
+	CourierLocation *Location    `json:"courier_location,omitempty"`
+	// PickupETA is reported until the courier picks the shipment up, DropoffETA until it is delivered.
+	PickupETA  *time.Time `json:"pickup_eta,omitempty"`
+	DropoffETA *time.Time `json:"dropoff_eta,omitempty"`
+	// ProofOfDelivery is reported once delivered.
+	ProofOfDelivery *ProofOfDelivery `json:"proof_of_delivery,omitempty"`
+	// FailureReason is reported once the delivery failed.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
@@ -0,0 +1,159 @@
+package threepl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Faults makes the simulator misbehave, to check the delivery service survives a faulty provider.
+// Rates are probabilities between 0 and 1, the zero value injecting no fault.
+type Faults struct {
+	// Seed makes injected faults reproducible.
+	Seed uint64 `json:"seed"`
+	// RequestErrorRate is the share of requests answered with RequestErrorStatus.
+	RequestErrorRate float64 `json:"request_error_rate"`
+	// RequestErrorStatus is a 5xx or 429 status code, 503 by default.
+	RequestErrorStatus int `json:"request_error_status"`
+	// RequestLatency delays every request, none by default.
+	RequestLatency Distribution `json:"request_latency"`
+	// WebhookDropRate is the share of statuses never reported.
+	WebhookDropRate float64 `json:"webhook_drop_rate"`
+	// WebhookDuplicateRate is the share of webhooks sent twice.
+	WebhookDuplicateRate float64 `json:"webhook_duplicate_rate"`
+	// WebhookOutOfOrderRate is the share of webhooks followed by a stale one, e.g. searching after found.
+	WebhookOutOfOrderRate float64 `json:"webhook_out_of_order_rate"`
+	// UnknownWebhookRate is the share of webhooks followed by one for a shipment that was never requested.
+	UnknownWebhookRate float64 `json:"unknown_webhook_rate"`
+}
+
+// LoadFaults reads a json faults file.
+func LoadFaults(path string) (*Faults, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var faults Faults
+	if err := json.Unmarshal(data, &faults); err != nil {
+		return nil, err
+	}
+
+	if err := faults.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &faults, nil
+}
+
+func (f *Faults) Validate() error {
+	rates := map[string]float64{
+		"request_error_rate":        f.RequestErrorRate,
+		"webhook_drop_rate":         f.WebhookDropRate,
+		"webhook_duplicate_rate":    f.WebhookDuplicateRate,
+		"webhook_out_of_order_rate": f.WebhookOutOfOrderRate,
+		"unknown_webhook_rate":      f.UnknownWebhookRate,
+	}
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+	}
+
+	if status := f.RequestErrorStatus; status != 0 && status != http.StatusTooManyRequests && (status < 500 || status > 599) {
+		return fmt.Errorf("request_error_status must be 429 or 5xx")
+	}
+
+	if err := f.RequestLatency.validate(); err != nil {
+		return fmt.Errorf("request_latency: %w", err)
+	}
+
+	return nil
+}
+
+// staleStatuses is the status reported before each status, sent again by out of order webhooks.
+var staleStatuses = map[string]string{
+	"found":           "searching",
+	"not_found":       "searching",
+	"picked_up":       "found",
+	"in_transit":      "picked_up",
+	"delivered":       "in_transit",
+	"delivery_failed": "in_transit",
+	"returned":        "delivery_failed",
+}
+
+// faultInjector makes the faults' random decisions, faults may be replaced at runtime.
+type faultInjector struct {
+	mu     sync.Mutex
+	faults Faults
+	rng    *rand.Rand
+}
+
+func newFaultInjector(faults *Faults) *faultInjector {
+	i := &faultInjector{}
+	if faults == nil {
+		faults = &Faults{}
+	}
+	i.set(*faults)
+	return i
+}
+
+func (i *faultInjector) get() Faults {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.faults
+}
+
+// set replaces the faults, reseeding the random decisions.
+func (i *faultInjector) set(faults Faults) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.faults = faults
+	i.rng = rand.New(rand.NewPCG(faults.Seed, faults.Seed))
+}
+
+// chance returns true with the probability rate picks out of the current faults.
+func (i *faultInjector) chance(rate func(f *Faults) float64) bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	r := rate(&i.faults)
+	return r > 0 && i.rng.Float64() < r
+}
+
+// requestError returns the status code to fail a request with, zero to let it through.
+func (i *faultInjector) requestError() int {
+	if !i.chance(func(f *Faults) float64 { return f.RequestErrorRate }) {
+		return 0
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	if i.faults.RequestErrorStatus == 0 {
+		return http.StatusServiceUnavailable
+	}
+	return i.faults.RequestErrorStatus
+}
+
+func (i *faultInjector) requestLatency() time.Duration {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return i.faults.RequestLatency.sample(i.rng, 0)
+}
+
+// unknownShipmentUID returns a shipment uid the delivery service never requested.
+func (i *faultInjector) unknownShipmentUID() string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	return "unknown_" + strconv.FormatUint(i.rng.Uint64(), 36)
+}
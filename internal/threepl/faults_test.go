@@ -0,0 +1,217 @@
+package threepl_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/threepl"
+)
+
+func TestLoadFaults(t *testing.T) {
+	faults, err := threepl.LoadFaults("../../scenarios/flaky_provider_faults.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if faults.RequestErrorStatus != http.StatusServiceUnavailable || time.Duration(faults.RequestLatency.Mean) != 200*time.Millisecond {
+		t.Errorf("faults = %+v", faults)
+	}
+
+	invalid := []threepl.Faults{
+		{WebhookDropRate: -0.1},
+		{RequestErrorStatus: http.StatusNotFound},
+		{RequestLatency: threepl.Distribution{Kind: "pareto"}},
+	}
+	for _, faults := range invalid {
+		if err := faults.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", faults)
+		}
+	}
+}
+
+func TestFaultsEndpoint(t *testing.T) {
+	simulator := threepl.NewSimulator(
+		&threepl.Config{BatchSize: 10, StepDelay: time.Minute},
+		threepl.NewMemoryRepo(),
+		&recordingWebhook{statuses: make(map[string][]string), failing: make(map[string]bool)},
+		clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC)),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	server := httptest.NewServer(simulator.Handler())
+	defer server.Close()
+
+	put := func(body string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/faults", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	request := func() int {
+		t.Helper()
+
+		resp, err := http.Post(server.URL+"/request", "application/json", strings.NewReader(`{"shipment_uid":"shipment"}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if status := request(); status != http.StatusOK {
+		t.Fatalf("without faults: status code %d", status)
+	}
+
+	if resp := put(`{"request_error_rate":2}`); resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("invalid faults: status code %d, want 422", resp.StatusCode)
+	}
+
+	if resp := put(`{"request_error_rate":1,"request_error_status":429}`); resp.StatusCode != http.StatusOK {
+		t.Fatalf("put faults: status code %d", resp.StatusCode)
+	}
+	if status := request(); status != http.StatusTooManyRequests {
+		t.Errorf("status code %d, want 429", status)
+	}
+
+	resp, err := http.Get(server.URL + "/faults")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var faults threepl.Faults
+	if err := json.NewDecoder(resp.Body).Decode(&faults); err != nil {
+		t.Fatal(err)
+	}
+	if faults.RequestErrorRate != 1 || faults.RequestErrorStatus != http.StatusTooManyRequests {
+		t.Errorf("get faults = %+v", faults)
+	}
+
+	put(`{}`)
+	if status := request(); status != http.StatusOK {
+		t.Errorf("faults cleared: status code %d", status)
+	}
+}
+
+func TestWebhookFaults(t *testing.T) {
+	tests := []struct {
+		name   string
+		faults threepl.Faults
+		want   map[string]string
+	}{
+		{
+			name:   "drop",
+			faults: threepl.Faults{WebhookDropRate: 1},
+			want:   map[string]string{"shipment": ""},
+		},
+		{
+			name:   "duplicate",
+			faults: threepl.Faults{WebhookDuplicateRate: 1},
+			want: map[string]string{
+				"shipment": "searching,searching,found,found,picked_up,picked_up,in_transit,in_transit,delivered,delivered",
+			},
+		},
+		{
+			name:   "out of order",
+			faults: threepl.Faults{WebhookOutOfOrderRate: 1},
+			want: map[string]string{
+				"shipment": "searching,found,searching,picked_up,found,in_transit,picked_up,delivered,in_transit",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+			webhook := &recordingWebhook{statuses: make(map[string][]string), failing: make(map[string]bool)}
+			repo := threepl.NewMemoryRepo()
+
+			simulator := threepl.NewSimulator(
+				&threepl.Config{BatchSize: 10, StepDelay: time.Minute, Faults: &tt.faults},
+				repo,
+				webhook,
+				fakeClock,
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+			)
+
+			if err := repo.Request(ctx, &threepl.RequestInput{ShipmentUID: "shipment", ZoneID: "zone"}, fakeClock.Now()); err != nil {
+				t.Fatal(err)
+			}
+
+			for range 5 {
+				if err := simulator.Tick(ctx); err != nil {
+					t.Fatal(err)
+				}
+				fakeClock.Advance(time.Minute)
+			}
+
+			for uid, want := range tt.want {
+				if sent := webhook.sent(uid); sent != want {
+					t.Errorf("%s: sent %q, want %q", uid, sent, want)
+				}
+			}
+
+			// faults never hold shipments back
+			shipment, err := repo.GetShipment(ctx, "shipment")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shipment.Status != "delivered" {
+				t.Errorf("status = %q, want delivered", shipment.Status)
+			}
+		})
+	}
+}
+
+func TestUnknownWebhookFault(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	webhook := &recordingWebhook{statuses: make(map[string][]string), failing: make(map[string]bool)}
+	repo := threepl.NewMemoryRepo()
+
+	simulator := threepl.NewSimulator(
+		&threepl.Config{BatchSize: 10, StepDelay: time.Minute, Faults: &threepl.Faults{UnknownWebhookRate: 1}},
+		repo,
+		webhook,
+		fakeClock,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	if err := repo.Request(ctx, &threepl.RequestInput{ShipmentUID: "shipment", ZoneID: "zone"}, fakeClock.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := simulator.Tick(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	webhook.mu.Lock()
+	defer webhook.mu.Unlock()
+
+	var unknown int
+	for uid, statuses := range webhook.statuses {
+		if strings.HasPrefix(uid, "unknown_") && strings.Join(statuses, ",") == "searching" {
+			unknown++
+		}
+	}
+	if unknown != 1 {
+		t.Errorf("sent %d webhooks for unknown shipments, want 1: %v", unknown, webhook.statuses)
+	}
+}
@@ -0,0 +1,352 @@
+package threepl
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	earthRadiusMeters = 6371008.8
+
+	defaultCourierSpeedKmh = 25
+)
+
+// Courier of the simulated fleet.
+type Courier struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Phone   string `json:"phone"`
+	Vehicle string `json:"vehicle"`
+	// Position is where the courier waits before its first shipment.
+	Position Location `json:"position"`
+	// ShiftStart and ShiftEnd are the hours of the day, in the scenario timezone, the courier
+	// takes shipments in: [ShiftStart, ShiftEnd). A zero ShiftEnd means the whole day.
+	ShiftStart int `json:"shift_start"`
+	ShiftEnd   int `json:"shift_end"`
+	// Capacity is the number of shipments the courier carries at once, 1 by default.
+	Capacity int `json:"capacity"`
+}
+
+func (c *Courier) validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+
+	if c.ShiftEnd != 0 && (c.ShiftStart < 0 || c.ShiftEnd > 24 || c.ShiftStart >= c.ShiftEnd) {
+		return fmt.Errorf("courier %q: invalid shift %d-%d", c.ID, c.ShiftStart, c.ShiftEnd)
+	}
+
+	if c.Capacity < 0 {
+		return fmt.Errorf("courier %q: capacity must not be negative", c.ID)
+	}
+
+	return nil
+}
+
+func (c *Courier) onShift(hour int) bool {
+	return c.ShiftEnd == 0 || hour >= c.ShiftStart && hour < c.ShiftEnd
+}
+
+// CourierStatus is a courier of the fleet as served by the couriers endpoint.
+type CourierStatus struct {
+	ID           string   `json:"id"`
+	Location     Location `json:"location"`
+	ShipmentUIDs []string `json:"shipment_uids"`
+}
+
+// trip is a courier travelling from where it is to the origin of a shipment then to its destination,
+// and back to the origin when the delivery failed.
+type trip struct {
+	shipmentUID string
+
+	from, pickup, dropoff Location
+
+	start, pickupAt, dropoffAt time.Time
+	// returnAt is when the courier is back at the origin, zero unless the delivery failed.
+	returnAt time.Time
+}
+
+// end returns where and when the trip is over.
+func (t *trip) end() (Location, time.Time) {
+	if !t.returnAt.IsZero() {
+		return t.pickup, t.returnAt
+	}
+	return t.dropoff, t.dropoffAt
+}
+
+type courierState struct {
+	Courier
+	// position is where the courier stands once its trips are over.
+	position Location
+	trips    []trip
+}
+
+// free returns where and when the courier is done with its current trips.
+func (c *courierState) free(now time.Time) (Location, time.Time) {
+	if len(c.trips) == 0 {
+		return c.position, now
+	}
+
+	location, at := c.trips[len(c.trips)-1].end()
+	if at.Before(now) {
+		return location, now
+	}
+	return location, at
+}
+
+// fleet assigns shipments to couriers and tracks where couriers are. Its state lives in memory:
+// shipments assigned before the simulator restarts are delivered without holding a courier.
+type fleet struct {
+	mu       sync.Mutex
+	couriers []*courierState
+	speedKmh float64
+	location *time.Location
+}
+
+func newFleet(couriers []Courier, speedKmh float64, location *time.Location) *fleet {
+	if speedKmh <= 0 {
+		speedKmh = defaultCourierSpeedKmh
+	}
+
+	f := &fleet{speedKmh: speedKmh, location: location}
+	for _, courier := range couriers {
+		if courier.Capacity == 0 {
+			courier.Capacity = 1
+		}
+		f.couriers = append(f.couriers, &courierState{Courier: courier, position: courier.Position})
+	}
+
+	return f
+}
+
+// assign hands shipment to the nearest idle courier on shift, or the nearest with room left when
+// none is idle. It returns the courier and when the shipment is picked up, false when every courier
+// is busy or off shift. Shipments without routing info take pickup then delivery to deliver.
+func (f *fleet) assign(shipment Shipment, now time.Time, pickup, delivery time.Duration) (string, time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.remove(shipment.ShipmentUID)
+
+	origin, destination := shipment.RoutingInfo.Origin, shipment.RoutingInfo.Destination
+	hour := now.In(f.location).Hour()
+
+	var (
+		best         *courierState
+		bestIdle     bool
+		bestDistance float64
+	)
+	for _, courier := range f.couriers {
+		if !courier.onShift(hour) || len(courier.trips) >= courier.Capacity {
+			continue
+		}
+
+		idle := len(courier.trips) == 0
+		position, _ := courier.free(now)
+
+		var d float64
+		if !origin.IsZero() {
+			d = distance(position, origin)
+		}
+
+		if best == nil || idle && !bestIdle || idle == bestIdle && d < bestDistance {
+			best, bestIdle, bestDistance = courier, idle, d
+		}
+	}
+
+	if best == nil {
+		return "", time.Time{}, false
+	}
+
+	from, start := best.free(now)
+	t := trip{shipmentUID: shipment.ShipmentUID, from: from, start: start}
+
+	if origin.IsZero() || destination.IsZero() {
+		t.pickup, t.dropoff = from, from
+		t.pickupAt = start.Add(pickup)
+		t.dropoffAt = t.pickupAt.Add(delivery)
+	} else {
+		t.pickup, t.dropoff = origin, destination
+		t.pickupAt = start.Add(f.travelTime(from, origin))
+		t.dropoffAt = t.pickupAt.Add(f.travelTime(origin, destination))
+	}
+
+	best.trips = append(best.trips, t)
+
+	return best.ID, t.pickupAt, true
+}
+
+// dropoffAt returns when the courier of shipmentUID reaches its destination.
+func (f *fleet) dropoffAt(shipmentUID string) (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t := f.find(shipmentUID); t != nil {
+		return t.dropoffAt, true
+	}
+	return time.Time{}, false
+}
+
+// fail sends the courier of shipmentUID back to the origin from now and returns when it gets there.
+// Shipments without routing info take fallback to come back.
+func (f *fleet) fail(shipmentUID string, now time.Time, fallback time.Duration) (time.Time, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := f.find(shipmentUID)
+	if t == nil {
+		return time.Time{}, false
+	}
+
+	if t.dropoff == t.pickup {
+		t.returnAt = now.Add(fallback)
+	} else {
+		t.returnAt = now.Add(f.travelTime(t.dropoff, t.pickup))
+	}
+
+	return t.returnAt, true
+}
+
+// release frees the courier of shipmentUID, leaving it where the trip ends when arrived.
+func (f *fleet) release(shipmentUID string, arrived bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if t, courier := f.remove(shipmentUID); courier != nil && arrived {
+		courier.position, _ = t.end()
+	}
+}
+
+// track fills input with the courier of its shipment, where the courier is at now and, while the
+// courier still carries the shipment, the pickup and dropoff etas.
+func (f *fleet) track(input *WebhookInput, courierID string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, courier := range f.couriers {
+		if courier.ID != courierID {
+			continue
+		}
+
+		location := courier.positionAt(now)
+		input.Courier = &CourierInfo{ID: courier.ID, Name: courier.Name, Phone: courier.Phone, Vehicle: courier.Vehicle}
+		input.CourierLocation = &location
+
+		for _, t := range courier.trips {
+			if t.shipmentUID != input.ShipmentUID {
+				continue
+			}
+			if now.Before(t.pickupAt) {
+				input.PickupETA = &t.pickupAt
+			}
+			if t.returnAt.IsZero() {
+				input.DropoffETA = &t.dropoffAt
+			}
+		}
+
+		return
+	}
+}
+
+func (f *fleet) statuses(now time.Time) []CourierStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	statuses := make([]CourierStatus, 0, len(f.couriers))
+	for _, courier := range f.couriers {
+		status := CourierStatus{ID: courier.ID, Location: courier.positionAt(now), ShipmentUIDs: []string{}}
+		for _, t := range courier.trips {
+			status.ShipmentUIDs = append(status.ShipmentUIDs, t.shipmentUID)
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// find returns the trip of shipmentUID, f.mu must be held.
+func (f *fleet) find(shipmentUID string) *trip {
+	for _, courier := range f.couriers {
+		for i := range courier.trips {
+			if courier.trips[i].shipmentUID == shipmentUID {
+				return &courier.trips[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// remove drops the trip of shipmentUID, f.mu must be held.
+func (f *fleet) remove(shipmentUID string) (trip, *courierState) {
+	for _, courier := range f.couriers {
+		for i, t := range courier.trips {
+			if t.shipmentUID == shipmentUID {
+				courier.trips = slices.Delete(courier.trips, i, i+1)
+				return t, courier
+			}
+		}
+	}
+
+	return trip{}, nil
+}
+
+func (f *fleet) travelTime(from, to Location) time.Duration {
+	hours := distance(from, to) / 1000 / f.speedKmh
+	return time.Duration(hours * float64(time.Hour)).Round(time.Second)
+}
+
+func (c *courierState) positionAt(now time.Time) Location {
+	for _, t := range c.trips {
+		switch {
+		case now.Before(t.start):
+			return t.from
+		case now.Before(t.pickupAt):
+			return interpolate(t.from, t.pickup, progress(t.start, t.pickupAt, now))
+		case now.Before(t.dropoffAt):
+			return interpolate(t.pickup, t.dropoff, progress(t.pickupAt, t.dropoffAt, now))
+		case now.Before(t.returnAt):
+			return interpolate(t.dropoff, t.pickup, progress(t.dropoffAt, t.returnAt, now))
+		}
+	}
+
+	if len(c.trips) > 0 {
+		location, _ := c.trips[len(c.trips)-1].end()
+		return location
+	}
+	return c.position
+}
+
+func progress(from, to, now time.Time) float64 {
+	if !to.After(from) {
+		return 1
+	}
+	return float64(now.Sub(from)) / float64(to.Sub(from))
+}
+
+// interpolate moves a share of the way from a to b, which is close enough to the great circle in a city.
+func interpolate(a, b Location, share float64) Location {
+	return Location{
+		Lat:  a.Lat + (b.Lat-a.Lat)*share,
+		Long: a.Long + (b.Long-a.Long)*share,
+	}
+}
+
+// distance returns the great-circle distance in meters between a and b.
+func distance(a, b Location) float64 {
+	lat1 := radians(a.Lat)
+	lat2 := radians(b.Lat)
+	dLat := radians(b.Lat - a.Lat)
+	dLong := radians(b.Long - a.Long)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLong/2), 2)
+
+	return 2 * earthRadiusMeters * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
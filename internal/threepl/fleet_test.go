@@ -0,0 +1,245 @@
+package threepl_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/threepl"
+)
+
+var (
+	// about 2.5km north of origin, 6 minutes away at 25km/h
+	origin      = threepl.Location{Lat: 35.7000, Long: 51.4000}
+	destination = threepl.Location{Lat: 35.7225, Long: 51.4000}
+)
+
+type fleetTest struct {
+	t     *testing.T
+	clock interface {
+		threepl.Clock
+		Advance(d time.Duration)
+	}
+	now       func() time.Time
+	repo      threepl.Repo
+	webhook   *recordingWebhook
+	simulator *threepl.Simulator
+}
+
+func newFleetTest(t *testing.T, fleet []threepl.Courier) *fleetTest {
+	fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	webhook := &recordingWebhook{
+		statuses: make(map[string][]string),
+		inputs:   make(map[string][]threepl.WebhookInput),
+		failing:  make(map[string]bool),
+	}
+	repo := threepl.NewMemoryRepo()
+
+	return &fleetTest{
+		t:       t,
+		clock:   fakeClock,
+		now:     fakeClock.Now,
+		repo:    repo,
+		webhook: webhook,
+		simulator: threepl.NewSimulator(
+			&threepl.Config{BatchSize: 10, StepDelay: time.Minute, Scenario: &threepl.Scenario{Fleet: fleet}},
+			repo,
+			webhook,
+			fakeClock,
+			slog.New(slog.NewTextHandler(io.Discard, nil)),
+		),
+	}
+}
+
+func (f *fleetTest) request(uid string) {
+	f.t.Helper()
+
+	input := &threepl.RequestInput{ShipmentUID: uid, RoutingInfo: threepl.RoutingInfo{Origin: origin, Destination: destination}}
+	if err := f.repo.Request(context.Background(), input, f.now()); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *fleetTest) tick(n int) {
+	f.t.Helper()
+
+	for range n {
+		if err := f.simulator.Tick(context.Background()); err != nil {
+			f.t.Fatal(err)
+		}
+		f.clock.Advance(time.Minute)
+	}
+}
+
+func (f *fleetTest) shipment(uid string) *threepl.Shipment {
+	f.t.Helper()
+
+	shipment, err := f.repo.GetShipment(context.Background(), uid)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return shipment
+}
+
+func TestFleetAssignsNearestCourier(t *testing.T) {
+	near := threepl.Location{Lat: 35.701, Long: 51.40}
+	f := newFleetTest(t, []threepl.Courier{
+		{ID: "far", Position: threepl.Location{Lat: 35.80, Long: 51.40}},
+		{ID: "near", Name: "Near", Phone: "+98 912 000 0000", Vehicle: "motorbike", Position: near},
+	})
+
+	f.request("shipment")
+
+	// searching then found
+	f.tick(2)
+
+	shipment := f.shipment("shipment")
+	if shipment.Status != "found" || shipment.CourierID != "near" {
+		t.Fatalf("status = %q, courier = %q, want found by near", shipment.Status, shipment.CourierID)
+	}
+
+	// the courier reaches the origin 111m away in about 16s
+	foundAt := f.now().Add(-time.Minute)
+	if travel := shipment.StartTime.Sub(foundAt); travel < 10*time.Second || travel > 20*time.Second {
+		t.Errorf("travel time to the origin = %s, want about 16s", travel)
+	}
+
+	f.tick(7)
+
+	inputs := f.webhook.inputs["shipment"]
+	if sent, want := f.webhook.sent("shipment"), "searching,found,picked_up,in_transit,delivered"; sent != want {
+		t.Fatalf("sent %q, want %q", sent, want)
+	}
+
+	found, inTransit, delivered := inputs[1], inputs[3], inputs[4]
+	if found.Version != threepl.WebhookVersion || found.Courier == nil || found.Courier.ID != "near" || found.Courier.Name != "Near" {
+		t.Errorf("found webhook = %+v, want version 2 with the near courier", found)
+	}
+	if found.CourierLocation == nil || *found.CourierLocation != near {
+		t.Errorf("found webhook located the courier at %v, want %v", found.CourierLocation, near)
+	}
+
+	// then travels 2.5km to the destination in about 6 minutes
+	if found.PickupETA == nil || !found.PickupETA.Equal(shipment.StartTime) || found.DropoffETA == nil {
+		t.Fatalf("found webhook etas = %v and %v, want pickup at %v", found.PickupETA, found.DropoffETA, shipment.StartTime)
+	}
+	if travel := found.DropoffETA.Sub(*found.PickupETA); travel < 6*time.Minute || travel > 6*time.Minute+time.Second {
+		t.Errorf("travel time to the destination = %s, want about 6m", travel)
+	}
+
+	if inTransit.PickupETA != nil || inTransit.DropoffETA == nil || !inTransit.DropoffETA.Equal(*found.DropoffETA) {
+		t.Errorf("in_transit webhook etas = %v and %v, want only the dropoff at %v", inTransit.PickupETA, inTransit.DropoffETA, found.DropoffETA)
+	}
+
+	if delivered.Courier == nil || delivered.Courier.ID != "near" || delivered.CourierLocation == nil || *delivered.CourierLocation != destination {
+		t.Errorf("delivered webhook = %+v, want near at the destination", delivered)
+	}
+	if pod := delivered.ProofOfDelivery; pod == nil || !pod.DeliveredAt.Equal(*found.DropoffETA) || pod.RecipientName == "" || pod.PhotoRef == "" {
+		t.Errorf("delivered webhook proof of delivery = %+v, want one delivered at %v", pod, found.DropoffETA)
+	}
+}
+
+func TestFleetReturnsFailedDeliveries(t *testing.T) {
+	f := newFleetTest(t, []threepl.Courier{{ID: "courier", Position: origin}})
+	f.simulator = threepl.NewSimulator(
+		&threepl.Config{
+			BatchSize: 10,
+			StepDelay: time.Minute,
+			Scenario: &threepl.Scenario{
+				DeliveryFailureProbability: 1,
+				Fleet:                      []threepl.Courier{{ID: "courier", Position: origin}},
+			},
+		},
+		f.repo,
+		f.webhook,
+		f.clock.(threepl.Clock),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	f.request("shipment")
+
+	// found, picked up, in transit for 6 minutes, failed then back to the origin 6 minutes later
+	f.tick(16)
+
+	if sent, want := f.webhook.sent("shipment"), "searching,found,picked_up,in_transit,delivery_failed,returned"; sent != want {
+		t.Fatalf("sent %q, want %q", sent, want)
+	}
+
+	inputs := f.webhook.inputs["shipment"]
+	failed, returned := inputs[4], inputs[5]
+	if failed.FailureReason == "" || failed.ProofOfDelivery != nil || failed.DropoffETA != nil {
+		t.Errorf("delivery_failed webhook = %+v, want a failure reason only", failed)
+	}
+	if returned.CourierLocation == nil || *returned.CourierLocation != origin {
+		t.Errorf("returned webhook located the courier at %v, want back at the origin %v", returned.CourierLocation, origin)
+	}
+
+	// and is free to take the next shipment from there
+	f.request("next")
+	f.tick(2)
+
+	if shipment := f.shipment("next"); shipment.Status != "found" || shipment.CourierID != "courier" {
+		t.Errorf("next: status = %q, courier = %q, want found by courier", shipment.Status, shipment.CourierID)
+	}
+}
+
+func TestFleetAvailability(t *testing.T) {
+	f := newFleetTest(t, []threepl.Courier{
+		{ID: "busy", Position: origin, Capacity: 1},
+		{ID: "night", Position: origin, ShiftStart: 20, ShiftEnd: 24},
+	})
+
+	f.request("first")
+	f.request("second")
+	f.tick(2)
+
+	statuses := map[string]string{"first": f.shipment("first").Status, "second": f.shipment("second").Status}
+	if statuses["first"] != "found" || statuses["second"] != "not_found" {
+		t.Errorf("statuses = %v, want the only courier on shift to take first", statuses)
+	}
+
+	// once delivered, the courier takes the next shipment
+	f.tick(7)
+	f.request("second")
+	f.tick(2)
+
+	if shipment := f.shipment("second"); shipment.Status != "found" || shipment.CourierID != "busy" {
+		t.Errorf("second: status = %q, courier = %q, want found by busy", shipment.Status, shipment.CourierID)
+	}
+}
+
+func TestCouriersEndpoint(t *testing.T) {
+	f := newFleetTest(t, []threepl.Courier{{ID: "courier", Position: origin}})
+
+	f.request("shipment")
+	f.tick(2)
+
+	server := httptest.NewServer(f.simulator.Handler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/couriers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var couriers []threepl.CourierStatus
+	if err := json.NewDecoder(resp.Body).Decode(&couriers); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(couriers) != 1 || len(couriers[0].ShipmentUIDs) != 1 || couriers[0].ShipmentUIDs[0] != "shipment" {
+		t.Fatalf("couriers = %+v, want courier carrying shipment", couriers)
+	}
+
+	// a minute into a 6 minutes trip from the origin
+	if lat := couriers[0].Location.Lat; lat <= origin.Lat || lat >= destination.Lat {
+		t.Errorf("courier at %v, want between origin and destination", couriers[0].Location)
+	}
+}
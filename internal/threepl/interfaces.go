@@ -0,0 +1,35 @@
+package threepl
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	Clock interface {
+		Now() time.Time
+	}
+
+	// WebhookClient reports shipment statuses back to the delivery service.
+	WebhookClient interface {
+		Send(ctx context.Context, input *WebhookInput) error
+	}
+
+	Repo interface {
+		// Request records a delivery guy request due at startTime. Requesting a known shipment again
+		// restarts its search, releases its courier and counts a retry.
+		Request(ctx context.Context, input *RequestInput, startTime time.Time) error
+		GetShipment(ctx context.Context, shipmentUID string) (*Shipment, error)
+		// Claim locks up to limit reported shipments in status due by before that no other claim holds
+		// and hands them to advance. The start time, retries, status and courier of the shipments advance
+		// returns are saved, the others are left untouched.
+		// It returns the number of claimed shipments.
+		Claim(ctx context.Context, status string, before time.Time, limit int, advance func(shipments []Shipment) []Shipment) (int, error)
+		// ListUnreported returns up to limit shipments whose status is not reported yet.
+		ListUnreported(ctx context.Context, limit int) ([]Shipment, error)
+		// MarkReported records status as reported unless the shipment moved on meanwhile, and reports whether it did.
+		MarkReported(ctx context.Context, shipmentUID string, status string) (bool, error)
+		// CountByStatus returns the number of shipments of a zone in one of statuses.
+		CountByStatus(ctx context.Context, zoneID string, statuses []string) (int, error)
+	}
+)
@@ -0,0 +1,157 @@
+package threepl
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// memoryRepo keeps shipments in memory, for tests and runs embedded in a single process.
+type memoryRepo struct {
+	mu        sync.Mutex
+	shipments map[string]*Shipment
+	claimed   map[string]bool
+}
+
+var _ Repo = (*memoryRepo)(nil)
+
+func NewMemoryRepo() *memoryRepo {
+	return &memoryRepo{
+		shipments: make(map[string]*Shipment),
+		claimed:   make(map[string]bool),
+	}
+}
+
+func (r *memoryRepo) Request(ctx context.Context, input *RequestInput, startTime time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	retries := 1
+	if shipment, ok := r.shipments[input.ShipmentUID]; ok {
+		retries = shipment.Retries + 1
+	}
+
+	r.shipments[input.ShipmentUID] = &Shipment{
+		ShipmentUID:    input.ShipmentUID,
+		ZoneID:         input.ZoneID,
+		RoutingInfo:    input.RoutingInfo,
+		StartTime:      startTime,
+		Retries:        retries,
+		Status:         "requested",
+		ReportedStatus: "requested",
+	}
+
+	return nil
+}
+
+func (r *memoryRepo) GetShipment(ctx context.Context, shipmentUID string) (*Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shipment, ok := r.shipments[shipmentUID]
+	if !ok {
+		return nil, ErrShipmentNotFound
+	}
+
+	copied := *shipment
+	return &copied, nil
+}
+
+func (r *memoryRepo) Claim(ctx context.Context, status string, before time.Time, limit int, advance func(shipments []Shipment) []Shipment) (int, error) {
+	r.mu.Lock()
+
+	shipments := r.filter(func(shipment *Shipment) bool {
+		return shipment.Status == status &&
+			shipment.ReportedStatus == status &&
+			!shipment.StartTime.After(before) &&
+			!r.claimed[shipment.ShipmentUID]
+	}, limit)
+
+	for _, shipment := range shipments {
+		r.claimed[shipment.ShipmentUID] = true
+	}
+
+	r.mu.Unlock()
+
+	if len(shipments) == 0 {
+		return 0, nil
+	}
+
+	updated := advance(shipments)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, shipment := range updated {
+		if stored, ok := r.shipments[shipment.ShipmentUID]; ok {
+			stored.StartTime = shipment.StartTime
+			stored.Retries = shipment.Retries
+			stored.Status = shipment.Status
+			stored.CourierID = shipment.CourierID
+		}
+	}
+
+	for _, shipment := range shipments {
+		delete(r.claimed, shipment.ShipmentUID)
+	}
+
+	return len(shipments), nil
+}
+
+func (r *memoryRepo) ListUnreported(ctx context.Context, limit int) ([]Shipment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.filter(func(shipment *Shipment) bool {
+		return shipment.Status != shipment.ReportedStatus
+	}, limit), nil
+}
+
+func (r *memoryRepo) MarkReported(ctx context.Context, shipmentUID string, status string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	shipment, ok := r.shipments[shipmentUID]
+	if !ok || shipment.Status != status {
+		return false, nil
+	}
+
+	shipment.ReportedStatus = status
+
+	return true, nil
+}
+
+func (r *memoryRepo) CountByStatus(ctx context.Context, zoneID string, statuses []string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var count int
+	for _, shipment := range r.shipments {
+		if shipment.ZoneID == zoneID && slices.Contains(statuses, shipment.Status) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// filter returns copies of up to limit matching shipments ordered like the postgres repo, r.mu must be held.
+func (r *memoryRepo) filter(match func(shipment *Shipment) bool, limit int) []Shipment {
+	var shipments []Shipment
+	for _, shipment := range r.shipments {
+		if match(shipment) {
+			shipments = append(shipments, *shipment)
+		}
+	}
+
+	sort.Slice(shipments, func(i, j int) bool {
+		if !shipments[i].StartTime.Equal(shipments[j].StartTime) {
+			return shipments[i].StartTime.Before(shipments[j].StartTime)
+		}
+		return shipments[i].ShipmentUID < shipments[j].ShipmentUID
+	})
+
+	return shipments[:min(limit, len(shipments))]
+}
@@ -0,0 +1,250 @@
+package threepl
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// repo stores shipments in the shipments_3pl table.
+type repo struct {
+	sqlDB  *sql.DB
+	logger *slog.Logger
+}
+
+const shipmentColumns = `shipment_uid, zone_id, origin_lat, origin_long, destination_lat, destination_long,
+	start_time, retries, status, reported_status, courier_id`
+
+var _ Repo = (*repo)(nil)
+
+func NewRepo(sqlDB *sql.DB, logger *slog.Logger) *repo {
+	return &repo{sqlDB: sqlDB, logger: logger}
+}
+
+func (r *repo) Request(ctx context.Context, input *RequestInput, startTime time.Time) error {
+	logger := r.logger.With(slog.String("infra", "3pl repo"), slog.String("method", "request"))
+
+	upsertStmt := `
+	INSERT INTO shipments_3pl (
+		shipment_uid, zone_id, origin_lat, origin_long, destination_lat, destination_long,
+		start_time, retries, status, reported_status
+	)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, 1, 'requested', 'requested')
+	ON CONFLICT (shipment_uid) DO UPDATE
+	SET retries = shipments_3pl.retries + 1,
+	    zone_id = EXCLUDED.zone_id,
+	    origin_lat = EXCLUDED.origin_lat,
+	    origin_long = EXCLUDED.origin_long,
+	    destination_lat = EXCLUDED.destination_lat,
+	    destination_long = EXCLUDED.destination_long,
+	    status = 'requested',
+	    reported_status = 'requested',
+	    courier_id = '',
+	    start_time = EXCLUDED.start_time;
+	`
+
+	if _, err := r.sqlDB.ExecContext(
+		ctx,
+		upsertStmt,
+		input.ShipmentUID,
+		input.ZoneID,
+		input.RoutingInfo.Origin.Lat,
+		input.RoutingInfo.Origin.Long,
+		input.RoutingInfo.Destination.Lat,
+		input.RoutingInfo.Destination.Long,
+		startTime,
+	); err != nil {
+		logger.Error("failed to upsert shipment", slog.String("shipment_uid", input.ShipmentUID), slog.Any("error", err))
+		return err
+	}
+
+	return nil
+}
+
+func (r *repo) GetShipment(ctx context.Context, shipmentUID string) (*Shipment, error) {
+	logger := r.logger.With(slog.String("infra", "3pl repo"), slog.String("method", "get_shipment"))
+
+	row := r.sqlDB.QueryRowContext(ctx, `SELECT `+shipmentColumns+` FROM shipments_3pl WHERE shipment_uid = $1`, shipmentUID)
+
+	var shipment Shipment
+	if err := scanShipment(row, &shipment); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrShipmentNotFound
+		}
+
+		logger.Error("error scanning shipment", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &shipment, nil
+}
+
+func (r *repo) Claim(ctx context.Context, status string, before time.Time, limit int, advance func(shipments []Shipment) []Shipment) (int, error) {
+	logger := r.logger.With(slog.String("infra", "3pl repo"), slog.String("method", "claim"), slog.String("status", status))
+
+	tx, err := r.sqlDB.BeginTx(ctx, nil)
+	if err != nil {
+		logger.Error("failed to begin transaction", slog.Any("error", err))
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	queryStmt := `
+	SELECT ` + shipmentColumns + `
+	FROM shipments_3pl
+	WHERE status = $1
+	  AND reported_status = status
+	  AND start_time <= $2
+	ORDER BY start_time, shipment_uid
+	LIMIT $3
+	FOR UPDATE SKIP LOCKED;
+	`
+
+	shipments, err := r.queryShipments(ctx, tx, queryStmt, status, before, limit)
+	if err != nil {
+		logger.Error("failed to query shipments", slog.Any("error", err))
+		return 0, err
+	}
+
+	if len(shipments) == 0 {
+		return 0, tx.Commit()
+	}
+
+	updated := advance(shipments)
+
+	var (
+		uids       = make([]string, len(updated))
+		startTimes = make([]string, len(updated))
+		retries    = make([]int64, len(updated))
+		statuses   = make([]string, len(updated))
+		courierIDs = make([]string, len(updated))
+	)
+	for i, shipment := range updated {
+		uids[i] = shipment.ShipmentUID
+		startTimes[i] = shipment.StartTime.Format(time.RFC3339Nano)
+		retries[i] = int64(shipment.Retries)
+		statuses[i] = shipment.Status
+		courierIDs[i] = shipment.CourierID
+	}
+
+	updateStmt := `
+	UPDATE shipments_3pl AS s
+	SET start_time = t.start_time, retries = t.retries, status = t.status, courier_id = t.courier_id
+	FROM unnest($1::text[], $2::timestamptz[], $3::integer[], $4::text[], $5::text[])
+		AS t(shipment_uid, start_time, retries, status, courier_id)
+	WHERE s.shipment_uid = t.shipment_uid;
+	`
+
+	if _, err := tx.ExecContext(ctx, updateStmt, pq.Array(uids), pq.Array(startTimes), pq.Array(retries), pq.Array(statuses), pq.Array(courierIDs)); err != nil {
+		logger.Error("failed to execute batch update", slog.Any("error", err))
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		logger.Error("transaction commit failed", slog.Any("error", err))
+		return 0, err
+	}
+
+	return len(shipments), nil
+}
+
+func (r *repo) ListUnreported(ctx context.Context, limit int) ([]Shipment, error) {
+	logger := r.logger.With(slog.String("infra", "3pl repo"), slog.String("method", "list_unreported"))
+
+	queryStmt := `
+	SELECT ` + shipmentColumns + `
+	FROM shipments_3pl
+	WHERE status <> reported_status
+	ORDER BY start_time, shipment_uid
+	LIMIT $1;
+	`
+
+	shipments, err := r.queryShipments(ctx, r.sqlDB, queryStmt, limit)
+	if err != nil {
+		logger.Error("failed to query shipments", slog.Any("error", err))
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+func (r *repo) MarkReported(ctx context.Context, shipmentUID string, status string) (bool, error) {
+	logger := r.logger.With(slog.String("infra", "3pl repo"), slog.String("method", "mark_reported"))
+
+	result, err := r.sqlDB.ExecContext(
+		ctx,
+		`UPDATE shipments_3pl SET reported_status = $2 WHERE shipment_uid = $1 AND status = $2`,
+		shipmentUID,
+		status,
+	)
+	if err != nil {
+		logger.Error("failed to mark reported", slog.String("shipment_uid", shipmentUID), slog.Any("error", err))
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		logger.Error("failed to get affected rows", slog.Any("error", err))
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
+func (r *repo) CountByStatus(ctx context.Context, zoneID string, statuses []string) (int, error) {
+	logger := r.logger.With(slog.String("infra", "3pl repo"), slog.String("method", "count_by_status"))
+
+	var count int
+	if err := r.sqlDB.QueryRowContext(
+		ctx,
+		`SELECT count(*) FROM shipments_3pl WHERE zone_id = $1 AND status = ANY($2)`,
+		zoneID,
+		pq.Array(statuses),
+	).Scan(&count); err != nil {
+		logger.Error("failed to count shipments", slog.Any("error", err))
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *repo) queryShipments(ctx context.Context, db interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}, query string, args ...any) ([]Shipment, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []Shipment
+	for rows.Next() {
+		var shipment Shipment
+		if err := scanShipment(rows, &shipment); err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+	}
+
+	return shipments, rows.Err()
+}
+
+func scanShipment(row interface{ Scan(dest ...any) error }, shipment *Shipment) error {
+	return row.Scan(
+		&shipment.ShipmentUID,
+		&shipment.ZoneID,
+		&shipment.RoutingInfo.Origin.Lat,
+		&shipment.RoutingInfo.Origin.Long,
+		&shipment.RoutingInfo.Destination.Lat,
+		&shipment.RoutingInfo.Destination.Long,
+		&shipment.StartTime,
+		&shipment.Retries,
+		&shipment.Status,
+		&shipment.ReportedStatus,
+		&shipment.CourierID,
+	)
+}
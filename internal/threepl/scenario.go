@@ -0,0 +1,285 @@
+package threepl
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"sync"
+	"time"
+)
+
+// Scenario describes how the simulated provider behaves, e.g. to reproduce peak-hour shortages.
+// The zero value always finds a delivery guy, always delivers and moves shipments on every Config.StepDelay.
+type Scenario struct {
+	// Seed makes runs reproducible.
+	Seed uint64 `json:"seed"`
+	// NotFoundProbability is the chance a search ends without a delivery guy, between 0 and 1.
+	NotFoundProbability float64 `json:"not_found_probability"`
+	// MaxAttempts is the number of requests after which a delivery guy is always found. Zero means never.
+	MaxAttempts int `json:"max_attempts"`
+	// DeliveryFailureProbability is the chance the courier cannot hand a shipment over, between 0 and 1.
+	// Failed deliveries are brought back to their origin.
+	DeliveryFailureProbability float64 `json:"delivery_failure_probability"`
+	// SearchDuration, PickupDuration, DeliveryDuration and ReturnDuration are how long shipments stay
+	// searching, found, in_transit and delivery_failed. They default to Config.StepDelay.
+	SearchDuration   Distribution `json:"search_duration"`
+	PickupDuration   Distribution `json:"pickup_duration"`
+	DeliveryDuration Distribution `json:"delivery_duration"`
+	ReturnDuration   Distribution `json:"return_duration"`
+	// Supply limits the couriers delivering at once by zone id, "*" applying to zones not listed.
+	// Zones without supply have unlimited couriers.
+	Supply map[string]Supply `json:"supply"`
+	// Fleet makes searches assign the nearest available courier, travel times then follow distances
+	// instead of PickupDuration, DeliveryDuration and ReturnDuration. Searches always find a delivery
+	// guy when empty.
+	Fleet []Courier `json:"fleet"`
+	// CourierSpeedKmh is the average speed of the fleet, 25 by default.
+	CourierSpeedKmh float64 `json:"courier_speed_kmh"`
+	// Timezone is the one supply hours and shifts are in, UTC by default.
+	Timezone string `json:"timezone"`
+}
+
+type Supply struct {
+	Couriers int `json:"couriers"`
+	// Hours overrides Couriers for some hours of the day.
+	Hours []HourlySupply `json:"hours"`
+}
+
+// HourlySupply applies to hours in [From, To).
+type HourlySupply struct {
+	From     int `json:"from"`
+	To       int `json:"to"`
+	Couriers int `json:"couriers"`
+}
+
+// Distribution of durations, Kind is one of "fixed" (Value), "uniform" (Min to Max),
+// "normal" (Mean and StdDev, never below Min) or "exponential" (Mean).
+type Distribution struct {
+	Kind   string   `json:"kind"`
+	Value  Duration `json:"value"`
+	Min    Duration `json:"min"`
+	Max    Duration `json:"max"`
+	Mean   Duration `json:"mean"`
+	StdDev Duration `json:"stddev"`
+}
+
+// Duration is a time.Duration written as a string like "5m" in scenario files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %w", err)
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// LoadScenario reads a json scenario file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, err
+	}
+
+	if err := scenario.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &scenario, nil
+}
+
+func (s *Scenario) Validate() error {
+	if s.NotFoundProbability < 0 || s.NotFoundProbability > 1 {
+		return fmt.Errorf("not_found_probability must be between 0 and 1")
+	}
+
+	if s.DeliveryFailureProbability < 0 || s.DeliveryFailureProbability > 1 {
+		return fmt.Errorf("delivery_failure_probability must be between 0 and 1")
+	}
+
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("timezone: %w", err)
+	}
+
+	distributions := map[string]Distribution{
+		"search_duration":   s.SearchDuration,
+		"pickup_duration":   s.PickupDuration,
+		"delivery_duration": s.DeliveryDuration,
+		"return_duration":   s.ReturnDuration,
+	}
+	for name, distribution := range distributions {
+		if err := distribution.validate(); err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	ids := make(map[string]bool, len(s.Fleet))
+	for _, courier := range s.Fleet {
+		if err := courier.validate(); err != nil {
+			return fmt.Errorf("fleet: %w", err)
+		}
+		if ids[courier.ID] {
+			return fmt.Errorf("fleet: duplicate courier %q", courier.ID)
+		}
+		ids[courier.ID] = true
+	}
+
+	for zoneID, supply := range s.Supply {
+		for _, hours := range supply.Hours {
+			if hours.From < 0 || hours.To > 24 || hours.From >= hours.To {
+				return fmt.Errorf("supply of %q: invalid hours %d-%d", zoneID, hours.From, hours.To)
+			}
+		}
+	}
+
+	return nil
+}
+
+// behavior makes the scenario's random decisions from its seed.
+type behavior struct {
+	mu        sync.Mutex
+	scenario  *Scenario
+	stepDelay time.Duration
+	rng       *rand.Rand
+	location  *time.Location
+}
+
+func newBehavior(scenario *Scenario, stepDelay time.Duration) *behavior {
+	if scenario == nil {
+		scenario = &Scenario{}
+	}
+
+	location, err := time.LoadLocation(scenario.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+
+	return &behavior{
+		scenario:  scenario,
+		stepDelay: stepDelay,
+		rng:       rand.New(rand.NewPCG(scenario.Seed, scenario.Seed)),
+		location:  location,
+	}
+}
+
+func (b *behavior) searchDuration() time.Duration {
+	return b.sample(b.scenario.SearchDuration)
+}
+
+func (b *behavior) pickupDuration() time.Duration {
+	return b.sample(b.scenario.PickupDuration)
+}
+
+func (b *behavior) deliveryDuration() time.Duration {
+	return b.sample(b.scenario.DeliveryDuration)
+}
+
+func (b *behavior) returnDuration() time.Duration {
+	return b.sample(b.scenario.ReturnDuration)
+}
+
+// deliveryFailed decides whether the courier fails to hand a shipment over.
+func (b *behavior) deliveryFailed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.scenario.DeliveryFailureProbability > 0 && b.rng.Float64() < b.scenario.DeliveryFailureProbability
+}
+
+// fleet returns the scenario's fleet, nil without couriers.
+func (b *behavior) fleet() *fleet {
+	if len(b.scenario.Fleet) == 0 {
+		return nil
+	}
+	return newFleet(b.scenario.Fleet, b.scenario.CourierSpeedKmh, b.location)
+}
+
+// found decides whether the search of a shipment requested attempts times finds a delivery guy.
+func (b *behavior) found(attempts int) bool {
+	if b.scenario.MaxAttempts > 0 && attempts >= b.scenario.MaxAttempts {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.rng.Float64() >= b.scenario.NotFoundProbability
+}
+
+// couriers returns how many couriers deliver at once in zoneID at now, false meaning unlimited.
+func (b *behavior) couriers(zoneID string, now time.Time) (int, bool) {
+	supply, ok := b.scenario.Supply[zoneID]
+	if !ok {
+		supply, ok = b.scenario.Supply["*"]
+	}
+	if !ok {
+		return 0, false
+	}
+
+	hour := now.In(b.location).Hour()
+	for _, hours := range supply.Hours {
+		if hour >= hours.From && hour < hours.To {
+			return hours.Couriers, true
+		}
+	}
+
+	return supply.Couriers, true
+}
+
+func (b *behavior) sample(distribution Distribution) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return distribution.sample(b.rng, b.stepDelay)
+}
+
+// sample draws a duration from rng, returning fallback when no kind is set.
+func (d Distribution) sample(rng *rand.Rand, fallback time.Duration) time.Duration {
+	var sampled float64
+	switch d.Kind {
+	case "fixed":
+		sampled = float64(d.Value)
+	case "uniform":
+		sampled = float64(d.Min) + rng.Float64()*float64(d.Max-d.Min)
+	case "normal":
+		sampled = math.Max(float64(d.Min), float64(d.Mean)+rng.NormFloat64()*float64(d.StdDev))
+	case "exponential":
+		sampled = rng.ExpFloat64() * float64(d.Mean)
+	default:
+		return fallback
+	}
+
+	return time.Duration(math.Max(sampled, 0)).Round(time.Millisecond)
+}
+
+func (d Distribution) validate() error {
+	switch d.Kind {
+	case "", "fixed", "normal", "exponential":
+	case "uniform":
+		if d.Max < d.Min {
+			return fmt.Errorf("max must not be less than min")
+		}
+	default:
+		return fmt.Errorf("unknown kind %q", d.Kind)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package threepl_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/threepl"
+)
+
+func TestLoadScenario(t *testing.T) {
+	fleet, err := threepl.LoadScenario("../../scenarios/tehran_fleet.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fleet.Fleet) != 10 {
+		t.Errorf("fleet of %d couriers, want 10", len(fleet.Fleet))
+	}
+
+	scenario, err := threepl.LoadScenario("../../scenarios/peak_hour_shortage.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if scenario.SearchDuration.Kind != "uniform" || time.Duration(scenario.SearchDuration.Max) != 8*time.Minute {
+		t.Errorf("search_duration = %+v", scenario.SearchDuration)
+	}
+
+	if supply := scenario.Supply["*"]; supply.Couriers != 40 || len(supply.Hours) != 2 {
+		t.Errorf("supply = %+v", supply)
+	}
+
+	invalid := []threepl.Scenario{
+		{NotFoundProbability: 1.5},
+		{Timezone: "Mars/Olympus"},
+		{SearchDuration: threepl.Distribution{Kind: "poisson"}},
+		{DeliveryDuration: threepl.Distribution{Kind: "uniform", Min: threepl.Duration(time.Hour)}},
+		{Supply: map[string]threepl.Supply{"zone": {Hours: []threepl.HourlySupply{{From: 20, To: 10}}}}},
+		{Fleet: []threepl.Courier{{ID: "courier"}, {ID: "courier"}}},
+		{Fleet: []threepl.Courier{{ID: "courier", ShiftStart: 22, ShiftEnd: 6}}},
+	}
+	for _, scenario := range invalid {
+		if err := scenario.Validate(); err == nil {
+			t.Errorf("%+v: expected an error", scenario)
+		}
+	}
+}
+
+// runScenario requests n shipments in zoneID and ticks the simulator for the given simulated time,
+// returning the webhooks sent for every shipment.
+func runScenario(t *testing.T, scenario *threepl.Scenario, zoneID string, n int, duration time.Duration) map[string]string {
+	t.Helper()
+
+	ctx := context.Background()
+	fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	webhook := &recordingWebhook{statuses: make(map[string][]string), failing: make(map[string]bool)}
+
+	simulator := threepl.NewSimulator(
+		&threepl.Config{BatchSize: 100, StepDelay: 5 * time.Minute, Scenario: scenario},
+		threepl.NewMemoryRepo(),
+		webhook,
+		fakeClock,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	server := httptest.NewServer(simulator.Handler())
+	defer server.Close()
+
+	for i := range n {
+		body := fmt.Sprintf(`{"shipment_uid":"shipment_%d","zone_id":%q}`, i, zoneID)
+		resp, err := http.Post(server.URL+"/request", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	for end := fakeClock.Now().Add(duration); fakeClock.Now().Before(end); fakeClock.Advance(time.Minute) {
+		if err := simulator.Tick(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sent := make(map[string]string, n)
+	for i := range n {
+		uid := fmt.Sprintf("shipment_%d", i)
+		sent[uid] = webhook.sent(uid)
+	}
+
+	return sent
+}
+
+func TestScenarioNotFound(t *testing.T) {
+	sent := runScenario(t, &threepl.Scenario{NotFoundProbability: 1}, "zone", 5, time.Hour)
+
+	for uid, statuses := range sent {
+		if statuses != "searching,not_found" {
+			t.Errorf("%s: sent %q, want searching,not_found", uid, statuses)
+		}
+	}
+}
+
+func TestScenarioSeedIsDeterministic(t *testing.T) {
+	scenario := func(seed uint64) *threepl.Scenario {
+		return &threepl.Scenario{
+			Seed:                seed,
+			NotFoundProbability: 0.5,
+			SearchDuration:      threepl.Distribution{Kind: "exponential", Mean: threepl.Duration(10 * time.Minute)},
+			DeliveryDuration:    threepl.Distribution{Kind: "uniform", Min: threepl.Duration(5 * time.Minute), Max: threepl.Duration(30 * time.Minute)},
+		}
+	}
+
+	first := runScenario(t, scenario(7), "zone", 30, 2*time.Hour)
+	second := runScenario(t, scenario(7), "zone", 30, 2*time.Hour)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("same seed gave different runs:\n%v\n%v", first, second)
+	}
+
+	var notFound int
+	for _, statuses := range first {
+		if statuses == "searching,not_found" {
+			notFound++
+		}
+	}
+	if notFound == 0 || notFound == len(first) {
+		t.Errorf("%d of %d shipments not found with a probability of 0.5", notFound, len(first))
+	}
+}
+
+func TestScenarioSupply(t *testing.T) {
+	scenario := &threepl.Scenario{
+		DeliveryDuration: threepl.Distribution{Kind: "fixed", Value: threepl.Duration(time.Hour)},
+		Supply: map[string]threepl.Supply{
+			"busy": {Couriers: 10, Hours: []threepl.HourlySupply{{From: 8, To: 10, Couriers: 2}}},
+		},
+	}
+
+	// only 2 couriers deliver from 8 to 10 in the busy zone, the rest is not found
+	sent := runScenario(t, scenario, "busy", 5, 30*time.Minute)
+
+	var found, notFound int
+	for _, statuses := range sent {
+		switch {
+		case strings.HasPrefix(statuses, "searching,found"):
+			found++
+		case statuses == "searching,not_found":
+			notFound++
+		}
+	}
+	if found != 2 || notFound != 3 {
+		t.Errorf("found %d and not found %d, want 2 and 3: %v", found, notFound, sent)
+	}
+
+	// zones without supply have unlimited couriers
+	for uid, statuses := range runScenario(t, scenario, "quiet", 5, 30*time.Minute) {
+		if !strings.HasPrefix(statuses, "searching,found") {
+			t.Errorf("%s: sent %q, want searching then found", uid, statuses)
+		}
+	}
+}
+
+func TestScenarioMaxAttempts(t *testing.T) {
+	ctx := context.Background()
+	fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+	repo := threepl.NewMemoryRepo()
+
+	simulator := threepl.NewSimulator(
+		&threepl.Config{BatchSize: 10, StepDelay: time.Minute, Scenario: &threepl.Scenario{NotFoundProbability: 1, MaxAttempts: 3}},
+		repo,
+		&recordingWebhook{statuses: make(map[string][]string), failing: make(map[string]bool)},
+		fakeClock,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := repo.Request(ctx, &threepl.RequestInput{ShipmentUID: "shipment", ZoneID: "zone"}, fakeClock.Now()); err != nil {
+			t.Fatal(err)
+		}
+
+		for range 3 {
+			if err := simulator.Tick(ctx); err != nil {
+				t.Fatal(err)
+			}
+			fakeClock.Advance(time.Minute)
+		}
+
+		shipment, err := repo.GetShipment(ctx, "shipment")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if notFound := shipment.Status == "not_found"; notFound != (attempt < 3) {
+			t.Errorf("attempt %d: status = %q, want not_found only before the third attempt", attempt, shipment.Status)
+		}
+	}
+}
@@ -0,0 +1,522 @@
+package threepl
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	goccy_json "github.com/goccy/go-json"
+)
+
+type Config struct {
+	// Addr is the address Run serves the request endpoint on.
+	Addr string
+	// WorkerInterval is how often the workers run.
+	WorkerInterval time.Duration
+	// BatchSize is the number of shipments a worker claims at once.
+	BatchSize int
+	// StepDelay is how long a shipment stays requested, searching and found before moving on.
+	StepDelay time.Duration
+	// Scenario drives searches and deliveries, every search finds a delivery guy when nil.
+	Scenario *Scenario
+	// Faults is injected from the start, it can be changed at runtime through the faults endpoint.
+	Faults *Faults
+}
+
+// Simulator is a third party logistics provider: it accepts delivery guy requests and walks every
+// shipment through searching then either not_found or found, picked_up, in_transit and finally
+// delivered, or delivery_failed and returned to the origin. Each status is reported by webhook.
+type Simulator struct {
+	config   *Config
+	repo     Repo
+	webhook  WebhookClient
+	clock    Clock
+	behavior *behavior
+	// fleet is nil without couriers in the scenario
+	fleet  *fleet
+	faults *faultInjector
+	logger *slog.Logger
+}
+
+func NewSimulator(
+	config *Config,
+	repo Repo,
+	webhook WebhookClient,
+	clock Clock,
+	logger *slog.Logger,
+) *Simulator {
+	behavior := newBehavior(config.Scenario, config.StepDelay)
+
+	return &Simulator{
+		config:   config,
+		repo:     repo,
+		webhook:  webhook,
+		clock:    clock,
+		behavior: behavior,
+		fleet:    behavior.fleet(),
+		faults:   newFaultInjector(config.Faults),
+		logger:   logger.With(slog.String("service", "3pl")),
+	}
+}
+
+// Handler returns the request and faults endpoints, to serve them from elsewhere than Run.
+func (s *Simulator) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /request", s.injectRequestFaults(s.request))
+	mux.HandleFunc("GET /faults", s.getFaults)
+	mux.HandleFunc("PUT /faults", s.putFaults)
+	mux.HandleFunc("GET /couriers", s.getCouriers)
+	return mux
+}
+
+// Faults returns the faults currently injected.
+func (s *Simulator) Faults() Faults {
+	return s.faults.get()
+}
+
+// SetFaults replaces the faults injected.
+func (s *Simulator) SetFaults(faults Faults) error {
+	if err := faults.Validate(); err != nil {
+		return err
+	}
+
+	s.faults.set(faults)
+	s.logger.Warn("faults changed", slog.Any("faults", faults))
+
+	return nil
+}
+
+// Run serves the request endpoint and runs the workers until ctx is done.
+func (s *Simulator) Run(ctx context.Context) error {
+	server := &http.Server{Addr: s.config.Addr, Handler: s.Handler()}
+
+	errCh := make(chan error, 1)
+	go func() {
+		s.logger.Info("listening", slog.String("addr", s.config.Addr))
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			errCh <- err
+		}
+	}()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.runWorkers(ctx)
+	}()
+
+	var err error
+	select {
+	case <-ctx.Done():
+	case err = <-errCh:
+		s.logger.Error("failed to listen and serve", slog.Any("error", err))
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+
+	wg.Wait()
+	return err
+}
+
+// Tick runs a single cycle of every worker then reports the new statuses. It is meant for
+// simulations driving the clock themselves.
+func (s *Simulator) Tick(ctx context.Context) error {
+	return errors.Join(
+		s.runSearchingWorker(ctx),
+		s.runFindingWorker(ctx),
+		s.runPickupWorker(ctx),
+		s.runDepartingWorker(ctx),
+		s.runDeliveringWorker(ctx),
+		s.runReturningWorker(ctx),
+		s.report(ctx),
+	)
+}
+
+func (s *Simulator) runWorkers(ctx context.Context) {
+	ticker := time.NewTicker(s.config.WorkerInterval)
+	defer ticker.Stop()
+
+	for {
+		// failed cycles are retried on the next tick rather than stopping the simulator
+		if err := s.Tick(ctx); err != nil && ctx.Err() == nil {
+			s.logger.Error("workers cycle failed", slog.Any("error", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("workers stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// injectRequestFaults delays and fails requests as the faults dictate.
+func (s *Simulator) injectRequestFaults(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		if latency := s.faults.requestLatency(); latency > 0 {
+			select {
+			case <-req.Context().Done():
+				return
+			case <-time.After(latency):
+			}
+		}
+
+		if status := s.faults.requestError(); status != 0 {
+			s.logger.Warn("injecting request fault", slog.Int("status_code", status))
+			if status == http.StatusTooManyRequests {
+				w.Header().Set("Retry-After", "1")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+
+		next(w, req)
+	}
+}
+
+func (s *Simulator) getFaults(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	goccy_json.NewEncoder(w).Encode(s.Faults())
+}
+
+func (s *Simulator) putFaults(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var faults Faults
+	if err := goccy_json.NewDecoder(req.Body).Decode(&faults); err != nil {
+		http.Error(w, "failed to decode body as json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.SetFaults(faults); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	s.getFaults(w, req)
+}
+
+func (s *Simulator) getCouriers(w http.ResponseWriter, req *http.Request) {
+	statuses := []CourierStatus{}
+	if s.fleet != nil {
+		statuses = s.fleet.statuses(s.clock.Now())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	goccy_json.NewEncoder(w).Encode(statuses)
+}
+
+func (s *Simulator) request(w http.ResponseWriter, req *http.Request) {
+	defer req.Body.Close()
+
+	var input RequestInput
+	if err := goccy_json.NewDecoder(req.Body).Decode(&input); err != nil {
+		http.Error(w, "failed to decode body as json: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if input.ShipmentUID == "" {
+		http.Error(w, "shipment_uid is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := s.repo.Request(req.Context(), &input, s.clock.Now().Add(s.config.StepDelay)); err != nil {
+		s.logger.Error("failed to record request", slog.String("shipment_uid", input.ShipmentUID), slog.Any("error", err))
+		http.Error(w, "failed to record request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// a shipment requested again searches for a new courier
+	if s.fleet != nil {
+		s.fleet.release(input.ShipmentUID, false)
+	}
+}
+
+// runSearchingWorker starts searching a delivery guy for requested shipments.
+func (s *Simulator) runSearchingWorker(ctx context.Context) error {
+	return s.advance(ctx, "searching", "requested", func(now time.Time, shipments []Shipment) []Shipment {
+		for i := range shipments {
+			shipments[i].Status = "searching"
+			shipments[i].StartTime = now.Add(s.behavior.searchDuration())
+		}
+		return shipments
+	})
+}
+
+// busyStatuses are the statuses a delivery guy is busy with a shipment in.
+var busyStatuses = []string{"found", "picked_up", "in_transit", "delivery_failed"}
+
+// runFindingWorker ends searches, finding a delivery guy as the scenario and the couriers left allow.
+// With a fleet, the nearest available courier is assigned and travels to the origin to pick the
+// shipment up.
+func (s *Simulator) runFindingWorker(ctx context.Context) error {
+	logger := s.logger.With(slog.String("worker", "finding"))
+
+	return s.advance(ctx, "finding", "searching", func(now time.Time, shipments []Shipment) []Shipment {
+		// busy delivery guys by zone, counted once per batch then kept up to date locally
+		busy := make(map[string]int)
+
+		for i, shipment := range shipments {
+			found := s.behavior.found(shipment.Retries)
+
+			if couriers, limited := s.behavior.couriers(shipment.ZoneID, now); found && limited {
+				if _, ok := busy[shipment.ZoneID]; !ok {
+					count, err := s.repo.CountByStatus(ctx, shipment.ZoneID, busyStatuses)
+					if err != nil {
+						logger.Error("failed to count busy couriers", slog.String("zone_id", shipment.ZoneID), slog.Any("error", err))
+						count = couriers
+					}
+					busy[shipment.ZoneID] = count
+				}
+
+				found = busy[shipment.ZoneID] < couriers
+			}
+
+			pickupAt := now.Add(s.behavior.pickupDuration())
+			if found && s.fleet != nil {
+				shipments[i].CourierID, pickupAt, found = s.fleet.assign(shipment, now, pickupAt.Sub(now), s.behavior.deliveryDuration())
+			}
+
+			if found {
+				busy[shipment.ZoneID]++
+				shipments[i].Status = "found"
+				shipments[i].StartTime = pickupAt
+			} else {
+				shipments[i].Status = "not_found"
+			}
+		}
+
+		return shipments
+	})
+}
+
+// runPickupWorker picks shipments up once their delivery guy reached the origin.
+func (s *Simulator) runPickupWorker(ctx context.Context) error {
+	return s.advance(ctx, "pickup", "found", func(now time.Time, shipments []Shipment) []Shipment {
+		for i := range shipments {
+			shipments[i].Status = "picked_up"
+			shipments[i].StartTime = now
+		}
+		return shipments
+	})
+}
+
+// runDepartingWorker sets picked up shipments on their way to the destination.
+func (s *Simulator) runDepartingWorker(ctx context.Context) error {
+	return s.advance(ctx, "departing", "picked_up", func(now time.Time, shipments []Shipment) []Shipment {
+		for i, shipment := range shipments {
+			shipments[i].Status = "in_transit"
+			shipments[i].StartTime = now.Add(s.behavior.deliveryDuration())
+
+			if s.fleet != nil && shipment.CourierID != "" {
+				if dropoffAt, ok := s.fleet.dropoffAt(shipment.ShipmentUID); ok {
+					shipments[i].StartTime = dropoffAt
+				}
+			}
+		}
+		return shipments
+	})
+}
+
+// runDeliveringWorker hands shipments over at their destination. Deliveries failing as the scenario
+// dictates are brought back to the origin.
+func (s *Simulator) runDeliveringWorker(ctx context.Context) error {
+	return s.advance(ctx, "delivering", "in_transit", func(now time.Time, shipments []Shipment) []Shipment {
+		for i, shipment := range shipments {
+			hasCourier := s.fleet != nil && shipment.CourierID != ""
+
+			if !s.behavior.deliveryFailed() {
+				shipments[i].Status = "delivered"
+				if hasCourier {
+					s.fleet.release(shipment.ShipmentUID, true)
+				}
+				continue
+			}
+
+			returnDuration := s.behavior.returnDuration()
+
+			shipments[i].Status = "delivery_failed"
+			shipments[i].StartTime = now.Add(returnDuration)
+
+			if hasCourier {
+				if returnAt, ok := s.fleet.fail(shipment.ShipmentUID, now, returnDuration); ok {
+					shipments[i].StartTime = returnAt
+				}
+			}
+		}
+		return shipments
+	})
+}
+
+// runReturningWorker closes failed deliveries once they are back at the origin.
+func (s *Simulator) runReturningWorker(ctx context.Context) error {
+	return s.advance(ctx, "returning", "delivery_failed", func(now time.Time, shipments []Shipment) []Shipment {
+		for i, shipment := range shipments {
+			shipments[i].Status = "returned"
+			if s.fleet != nil && shipment.CourierID != "" {
+				s.fleet.release(shipment.ShipmentUID, true)
+			}
+		}
+		return shipments
+	})
+}
+
+// advance moves due shipments out of status from until none is left. Moved shipments are only
+// claimed again once their new status is reported.
+func (s *Simulator) advance(ctx context.Context, worker, from string, next func(now time.Time, shipments []Shipment) []Shipment) error {
+	logger := s.logger.With(slog.String("worker", worker))
+
+	for {
+		now := s.clock.Now()
+
+		claimed, err := s.repo.Claim(ctx, from, now, s.config.BatchSize, func(shipments []Shipment) []Shipment {
+			return next(now, shipments)
+		})
+		if err != nil {
+			logger.Error("failed to claim shipments", slog.Any("error", err))
+			return err
+		}
+
+		if claimed == 0 {
+			logger.Debug("no more shipments to update in this cycle")
+			return nil
+		}
+
+		logger.Info("updated batch of shipments", slog.Int("batch_length", claimed))
+	}
+}
+
+// report sends a webhook for every shipment whose status changed since it was last reported.
+// Webhooks are sent without holding any claim, since the delivery service may request the
+// shipment again while handling them. Failed webhooks are retried on the next cycle.
+func (s *Simulator) report(ctx context.Context) error {
+	logger := s.logger.With(slog.String("worker", "report"))
+
+	shipments, err := s.repo.ListUnreported(ctx, s.config.BatchSize)
+	if err != nil {
+		logger.Error("failed to list unreported shipments", slog.Any("error", err))
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for _, shipment := range shipments {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			logger := logger.With(slog.String("shipment_uid", shipment.ShipmentUID), slog.String("status", shipment.Status))
+
+			if err := s.sendWebhook(ctx, logger, shipment); err != nil {
+				logger.Error("failed to send webhook", slog.Any("error", err))
+				return
+			}
+
+			// a shipment requested again while its webhook was sent is not marked: its new status
+			// is reported instead
+			if _, err := s.repo.MarkReported(ctx, shipment.ShipmentUID, shipment.Status); err != nil {
+				logger.Error("failed to mark shipment reported", slog.Any("error", err))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(shipments) > 0 {
+		logger.Info("reported batch of shipments", slog.Int("batch_length", len(shipments)))
+	}
+
+	return nil
+}
+
+// sendWebhook reports the status of shipment, dropping, duplicating or following it with stale and
+// unknown webhooks as the faults dictate. Only the genuine webhook failing returns an error.
+func (s *Simulator) sendWebhook(ctx context.Context, logger *slog.Logger, shipment Shipment) error {
+	if s.faults.chance(func(f *Faults) float64 { return f.WebhookDropRate }) {
+		logger.Warn("injecting fault: dropping webhook")
+		return nil
+	}
+
+	input := s.webhookInput(shipment)
+
+	if err := s.webhook.Send(ctx, input); err != nil {
+		return err
+	}
+
+	var faulty []*WebhookInput
+
+	if s.faults.chance(func(f *Faults) float64 { return f.WebhookDuplicateRate }) {
+		faulty = append(faulty, input)
+	}
+
+	if stale, ok := staleStatuses[shipment.Status]; ok && s.faults.chance(func(f *Faults) float64 { return f.WebhookOutOfOrderRate }) {
+		faulty = append(faulty, &WebhookInput{Version: WebhookVersion, ShipmentUID: shipment.ShipmentUID, Status: stale})
+	}
+
+	if s.faults.chance(func(f *Faults) float64 { return f.UnknownWebhookRate }) {
+		faulty = append(faulty, &WebhookInput{Version: WebhookVersion, ShipmentUID: s.faults.unknownShipmentUID(), Status: shipment.Status})
+	}
+
+	// the delivery service is expected to reject or absorb faulty webhooks: their errors are only logged
+	for _, input := range faulty {
+		logger.Warn("injecting fault: sending webhook", slog.String("faulty_shipment_uid", input.ShipmentUID), slog.String("faulty_status", input.Status))
+		if err := s.webhook.Send(ctx, input); err != nil {
+			logger.Warn("faulty webhook failed", slog.Any("error", err))
+		}
+	}
+
+	return nil
+}
+
+// failureReasons are why couriers could not hand shipments over.
+var failureReasons = []string{"recipient_unavailable", "address_not_found", "refused"}
+
+// recipientNames are who proofs of delivery are signed by.
+var recipientNames = []string{"Sara Ahmadi", "Reza Karimi", "Maryam Hosseini", "Ali Rezaei", "Neda Moradi", "Front desk"}
+
+// webhookInput reports the status of shipment with its courier, etas, proof of delivery once
+// delivered and failure reason once the delivery failed.
+func (s *Simulator) webhookInput(shipment Shipment) *WebhookInput {
+	input := &WebhookInput{Version: WebhookVersion, ShipmentUID: shipment.ShipmentUID, Status: shipment.Status}
+
+	if s.fleet != nil && shipment.CourierID != "" {
+		s.fleet.track(input, shipment.CourierID, s.clock.Now())
+	}
+
+	// shipments move on at their start time, which stands for the etas without a fleet
+	eta := shipment.StartTime
+
+	switch shipment.Status {
+	case "found":
+		if input.PickupETA == nil {
+			input.PickupETA = &eta
+		}
+	case "in_transit":
+		if input.DropoffETA == nil {
+			input.DropoffETA = &eta
+		}
+	case "delivered":
+		input.ProofOfDelivery = &ProofOfDelivery{
+			DeliveredAt:   shipment.StartTime,
+			RecipientName: pick(recipientNames, shipment.ShipmentUID),
+			PhotoRef:      "pod/" + shipment.ShipmentUID + ".jpg",
+		}
+	case "delivery_failed":
+		input.FailureReason = pick(failureReasons, shipment.ShipmentUID)
+	}
+
+	return input
+}
+
+// pick returns one of values, always the same for a shipment.
+func pick(values []string, shipmentUID string) string {
+	h := fnv.New32a()
+	h.Write([]byte(shipmentUID))
+
+	return values[h.Sum32()%uint32(len(values))]
+}
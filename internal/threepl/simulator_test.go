@@ -0,0 +1,179 @@
+package threepl_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/internal/delivery/clock"
+	"github.com/aria3ppp/delivery-service-simulator/internal/threepl"
+
+	_ "github.com/lib/pq"
+)
+
+type recordingWebhook struct {
+	mu       sync.Mutex
+	statuses map[string][]string
+	inputs   map[string][]threepl.WebhookInput
+	failing  map[string]bool
+}
+
+func (r *recordingWebhook) Send(ctx context.Context, input *threepl.WebhookInput) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.failing[input.ShipmentUID] {
+		return errors.New("delivery service is down")
+	}
+
+	r.statuses[input.ShipmentUID] = append(r.statuses[input.ShipmentUID], input.Status)
+	if r.inputs != nil {
+		r.inputs[input.ShipmentUID] = append(r.inputs[input.ShipmentUID], *input)
+	}
+	return nil
+}
+
+func (r *recordingWebhook) sent(uid string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return strings.Join(r.statuses[uid], ",")
+}
+
+func (r *recordingWebhook) setFailing(uid string, failing bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.failing[uid] = failing
+}
+
+func TestSimulatorLifecycle(t *testing.T) {
+	repos := []struct {
+		name    string
+		newRepo func(t *testing.T) threepl.Repo
+	}{
+		{"memory", func(t *testing.T) threepl.Repo { return threepl.NewMemoryRepo() }},
+		{"postgres", func(t *testing.T) threepl.Repo {
+			databaseURL := os.Getenv("DATABASE_URL")
+			if databaseURL == "" {
+				t.Skip("DATABASE_URL is not set")
+			}
+
+			// shipments_3pl is created by the delivery service migrations
+			db, err := sql.Open("postgres", databaseURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				db.Exec(`DELETE FROM shipments_3pl WHERE shipment_uid LIKE 'threepl_%'`)
+				db.Close()
+			})
+
+			return threepl.NewRepo(db, slog.New(slog.NewTextHandler(io.Discard, nil)))
+		}},
+	}
+
+	for _, repo := range repos {
+		t.Run(repo.name, func(t *testing.T) {
+			ctx := context.Background()
+			prefix := fmt.Sprintf("threepl_%d_", time.Now().UnixNano())
+
+			fakeClock := clock.NewFake(time.Date(2026, 1, 5, 8, 0, 0, 0, time.UTC))
+			webhook := &recordingWebhook{statuses: make(map[string][]string), failing: make(map[string]bool)}
+			r := repo.newRepo(t)
+
+			simulator := threepl.NewSimulator(
+				&threepl.Config{BatchSize: 10, StepDelay: 5 * time.Minute},
+				r,
+				webhook,
+				fakeClock,
+				slog.New(slog.NewTextHandler(io.Discard, nil)),
+			)
+
+			server := httptest.NewServer(simulator.Handler())
+			defer server.Close()
+
+			healthy, flaky := prefix+"healthy", prefix+"flaky"
+			for _, uid := range []string{healthy, flaky} {
+				resp, err := http.Post(server.URL+"/request", "application/json", strings.NewReader(`{"shipment_uid":"`+uid+`"}`))
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				if resp.StatusCode != http.StatusOK {
+					t.Fatalf("request %s: status code %d", uid, resp.StatusCode)
+				}
+			}
+
+			webhook.setFailing(flaky, true)
+
+			tick := func() {
+				t.Helper()
+				if err := simulator.Tick(ctx); err != nil {
+					t.Fatal(err)
+				}
+				fakeClock.Advance(5 * time.Minute)
+			}
+
+			// nothing is due before the step delay
+			if err := simulator.Tick(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if sent := webhook.sent(healthy); sent != "" {
+				t.Fatalf("sent %q before the step delay", sent)
+			}
+
+			fakeClock.Advance(5 * time.Minute)
+			for range 5 {
+				tick()
+			}
+
+			if sent, want := webhook.sent(healthy), "searching,found,picked_up,in_transit,delivered"; sent != want {
+				t.Errorf("healthy: sent %q, want %q", sent, want)
+			}
+
+			// a shipment does not move on until its status gets reported
+			shipment, err := r.GetShipment(ctx, flaky)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shipment.Status != "searching" || shipment.ReportedStatus != "requested" {
+				t.Errorf("flaky: status = %q, reported = %q, want searching with requested reported", shipment.Status, shipment.ReportedStatus)
+			}
+
+			webhook.setFailing(flaky, false)
+			for range 5 {
+				tick()
+			}
+
+			if sent, want := webhook.sent(flaky), "searching,found,picked_up,in_transit,delivered"; sent != want {
+				t.Errorf("flaky: sent %q, want %q", sent, want)
+			}
+
+			// requesting again restarts the search and counts a retry
+			resp, err := http.Post(server.URL+"/request", "application/json", strings.NewReader(`{"shipment_uid":"`+healthy+`"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			shipment, err = r.GetShipment(ctx, healthy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if shipment.Status != "requested" || shipment.Retries != 2 {
+				t.Errorf("re-requested: status = %q, retries = %d, want requested with 2 retries", shipment.Status, shipment.Retries)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package threepl
+
+import (
+	"context"
+
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
+)
+
+type webhookClient struct {
+	client *client.Client
+}
+
+var _ WebhookClient = (*webhookClient)(nil)
+
+// NewWebhookClient reports statuses through the webhook endpoint of the delivery service client calls.
+func NewWebhookClient(client *client.Client) *webhookClient {
+	return &webhookClient{client: client}
+}
+
+func (c *webhookClient) Send(ctx context.Context, input *WebhookInput) error {
+	webhookInput := client.WebhookInput{
+		Version:     input.Version,
+		ShipmentUID: input.ShipmentUID,
+		Status:      input.Status,
+		Tracking: client.Tracking{
+			PickupETA:     input.PickupETA,
+			DropoffETA:    input.DropoffETA,
+			FailureReason: input.FailureReason,
+		},
+	}
+
+	if courier := input.Courier; courier != nil {
+		webhookInput.Courier = &client.Courier{
+			ID:      courier.ID,
+			Name:    courier.Name,
+			Phone:   courier.Phone,
+			Vehicle: courier.Vehicle,
+		}
+	}
+
+	if location := input.CourierLocation; location != nil {
+		webhookInput.CourierLocation = &client.Location{Lat: location.Lat, Long: location.Long}
+	}
+
+	if proof := input.ProofOfDelivery; proof != nil {
+		webhookInput.ProofOfDelivery = &client.ProofOfDelivery{
+			DeliveredAt:   proof.DeliveredAt,
+			RecipientName: proof.RecipientName,
+			PhotoRef:      proof.PhotoRef,
+		}
+	}
+
+	return c.client.Webhook(ctx, &webhookInput)
+}
@@ -0,0 +1,6 @@
+ALTER TABLE shipments DROP CONSTRAINT shipments_status_check;
+-- cancelled shipments are kept: the constraint only holds for rows written from now on
+ALTER TABLE shipments ADD CONSTRAINT shipments_status_check
+    CHECK (status IN ('queued','pending','requested','searching','found','not_found','shipped')) NOT VALID;
+
+DROP TABLE slot_capacities;
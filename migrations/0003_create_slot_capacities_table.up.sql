@@ -0,0 +1,13 @@
+-- a row is an hour of a zone, its capacity shared by the windows covering the hour
+CREATE TABLE slot_capacities (
+    zone_id    TEXT NOT NULL DEFAULT '',
+    start_time TIMESTAMPTZ NOT NULL,
+    end_time   TIMESTAMPTZ NOT NULL,
+    capacity   INTEGER NOT NULL CHECK (capacity >= 0),
+    reserved   INTEGER NOT NULL DEFAULT 0 CHECK (reserved >= 0),
+    PRIMARY KEY (zone_id, start_time, end_time)
+);
+
+ALTER TABLE shipments
+    DROP CONSTRAINT shipments_status_check,
+    ADD CONSTRAINT shipments_status_check CHECK (status IN ('queued','pending','requested','searching','found','not_found','shipped','cancelled'));
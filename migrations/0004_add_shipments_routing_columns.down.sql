@@ -0,0 +1,10 @@
+-- the postgis extension is left installed, other schemas may rely on it
+ALTER TABLE shipments
+    DROP COLUMN IF EXISTS origin_geog,
+    DROP COLUMN IF EXISTS destination_geog;
+
+DROP INDEX shipments_origin_point_idx;
+
+ALTER TABLE shipments
+    DROP COLUMN distance_meters,
+    DROP COLUMN eta_seconds;
@@ -0,0 +1,24 @@
+ALTER TABLE shipments
+    ADD COLUMN distance_meters DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN eta_seconds     INTEGER NOT NULL DEFAULT 0;
+
+-- points are stored as (long, lat), a gist index lets radius queries prefilter on a bounding box
+CREATE INDEX shipments_origin_point_idx ON shipments USING GIST (origin_point);
+
+-- PostGIS is optional: geography columns and their spatial index are only added when the extension is available
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM pg_available_extensions WHERE name = 'postgis') THEN
+        CREATE EXTENSION IF NOT EXISTS postgis;
+
+        ALTER TABLE shipments
+            ADD COLUMN origin_geog geography(Point, 4326)
+                GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(origin_point[0], origin_point[1]), 4326)::geography) STORED,
+            ADD COLUMN destination_geog geography(Point, 4326)
+                GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(destination_point[0], destination_point[1]), 4326)::geography) STORED;
+
+        CREATE INDEX shipments_origin_geog_idx ON shipments USING GIST (origin_geog);
+        CREATE INDEX shipments_destination_geog_idx ON shipments USING GIST (destination_geog);
+    END IF;
+END
+$$;
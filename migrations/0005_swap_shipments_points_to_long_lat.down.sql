@@ -0,0 +1,4 @@
+-- swapping is its own inverse
+UPDATE shipments
+SET origin_point      = point(origin_point[1], origin_point[0]),
+    destination_point = point(destination_point[1], destination_point[0]);
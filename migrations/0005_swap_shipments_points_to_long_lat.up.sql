@@ -0,0 +1,5 @@
+-- shipments used to be inserted as point(lat, long) while they are read back as (long, lat):
+-- swap the stored coordinates so every point follows the canonical (long, lat) encoding
+UPDATE shipments
+SET origin_point      = point(origin_point[1], origin_point[0]),
+    destination_point = point(destination_point[1], destination_point[0]);
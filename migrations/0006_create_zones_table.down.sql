@@ -0,0 +1,3 @@
+ALTER TABLE shipments DROP COLUMN zone_id;
+
+DROP TABLE zones;
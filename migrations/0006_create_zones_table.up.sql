@@ -0,0 +1,9 @@
+CREATE TABLE zones (
+    id            TEXT PRIMARY KEY,
+    geometry      JSONB NOT NULL, -- geojson Polygon or MultiPolygon, (long, lat) positions
+    timezone      TEXT NOT NULL DEFAULT 'UTC',
+    cutoff_hour   INTEGER NOT NULL DEFAULT 23 CHECK (cutoff_hour BETWEEN 0 AND 24),
+    preferred_3pl TEXT NOT NULL DEFAULT ''
+);
+
+ALTER TABLE shipments ADD COLUMN zone_id TEXT NOT NULL DEFAULT '';
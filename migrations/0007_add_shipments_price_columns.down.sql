@@ -0,0 +1,3 @@
+ALTER TABLE shipments
+    DROP COLUMN price_amount,
+    DROP COLUMN price_currency;
@@ -0,0 +1,3 @@
+ALTER TABLE shipments
+    ADD COLUMN price_amount   BIGINT NOT NULL DEFAULT 0, -- in the currency's minor unit
+    ADD COLUMN price_currency TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE shipments DROP COLUMN user_address;
@@ -0,0 +1,4 @@
+ALTER TABLE shipments ADD COLUMN user_address JSONB NOT NULL DEFAULT '{}';
+
+-- free text addresses booked so far are kept as the street
+UPDATE shipments SET user_address = jsonb_build_object('street', user_addr);
@@ -0,0 +1,5 @@
+DROP INDEX shipments_3pl_unreported_idx;
+
+ALTER TABLE shipments_3pl
+    DROP COLUMN zone_id,
+    DROP COLUMN reported_status;
@@ -0,0 +1,8 @@
+ALTER TABLE shipments_3pl
+    ADD COLUMN zone_id         TEXT NOT NULL DEFAULT '',
+    ADD COLUMN reported_status TEXT NOT NULL DEFAULT '';
+
+-- statuses so far were reported as they changed
+UPDATE shipments_3pl SET reported_status = status;
+
+CREATE INDEX shipments_3pl_unreported_idx ON shipments_3pl (shipment_uid) WHERE status <> reported_status;
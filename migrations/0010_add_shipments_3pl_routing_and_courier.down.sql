@@ -0,0 +1,6 @@
+ALTER TABLE shipments_3pl
+    DROP COLUMN origin_lat,
+    DROP COLUMN origin_long,
+    DROP COLUMN destination_lat,
+    DROP COLUMN destination_long,
+    DROP COLUMN courier_id;
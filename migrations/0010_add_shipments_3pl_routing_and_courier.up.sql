@@ -0,0 +1,6 @@
+ALTER TABLE shipments_3pl
+    ADD COLUMN origin_lat       DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN origin_long      DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN destination_lat  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN destination_long DOUBLE PRECISION NOT NULL DEFAULT 0,
+    ADD COLUMN courier_id       TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,2 @@
+-- latest courier, eta, location and proof of delivery reported by the 3pl webhooks
+ALTER TABLE shipments ADD COLUMN tracking JSONB NOT NULL DEFAULT '{}';
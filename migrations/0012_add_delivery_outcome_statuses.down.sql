@@ -0,0 +1,16 @@
+-- picked up shipments and their outcomes fold back into shipped
+ALTER TABLE shipments DROP CONSTRAINT shipments_status_check;
+UPDATE shipments SET status = 'shipped'
+WHERE status IN ('picked_up','in_transit','delivered','delivery_failed','returned');
+ALTER TABLE shipments ADD CONSTRAINT shipments_status_check CHECK (status IN (
+    'queued','pending','requested','searching','found','not_found','shipped','cancelled'
+));
+
+ALTER TABLE shipments_3pl DROP CONSTRAINT shipments_3pl_status_check;
+UPDATE shipments_3pl SET status = 'shipped'
+WHERE status IN ('picked_up','in_transit','delivered','delivery_failed','returned');
+UPDATE shipments_3pl SET reported_status = 'shipped'
+WHERE reported_status IN ('picked_up','in_transit','delivered','delivery_failed','returned');
+ALTER TABLE shipments_3pl ADD CONSTRAINT shipments_3pl_status_check CHECK (status IN (
+    'requested','searching','found','not_found','shipped'
+));
@@ -0,0 +1,16 @@
+-- shipped conflated pickup and delivery: it is split into picked_up, in_transit and delivered,
+-- failed deliveries go through delivery_failed then returned once back at the origin.
+ALTER TABLE shipments DROP CONSTRAINT shipments_status_check;
+UPDATE shipments SET status = 'delivered' WHERE status = 'shipped';
+ALTER TABLE shipments ADD CONSTRAINT shipments_status_check CHECK (status IN (
+    'queued','pending','requested','searching','found','not_found',
+    'picked_up','in_transit','delivered','delivery_failed','returned','cancelled'
+));
+
+ALTER TABLE shipments_3pl DROP CONSTRAINT shipments_3pl_status_check;
+UPDATE shipments_3pl SET status = 'delivered' WHERE status = 'shipped';
+UPDATE shipments_3pl SET reported_status = 'delivered' WHERE reported_status = 'shipped';
+ALTER TABLE shipments_3pl ADD CONSTRAINT shipments_3pl_status_check CHECK (status IN (
+    'requested','searching','found','not_found',
+    'picked_up','in_transit','delivered','delivery_failed','returned'
+));
@@ -0,0 +1,4 @@
+DROP TABLE shipment_status_history;
+
+-- drops the triggers along
+DROP FUNCTION record_shipment_status() CASCADE;
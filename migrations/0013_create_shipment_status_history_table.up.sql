@@ -0,0 +1,28 @@
+-- every status a shipment went through, recorded by trigger whatever changes it
+CREATE TABLE shipment_status_history (
+    id           BIGSERIAL PRIMARY KEY,
+    shipment_uid TEXT NOT NULL REFERENCES shipments (uid) ON DELETE CASCADE,
+    status       TEXT NOT NULL,
+    changed_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX shipment_status_history_shipment_uid_idx ON shipment_status_history (shipment_uid, id);
+
+CREATE FUNCTION record_shipment_status() RETURNS trigger AS $$
+BEGIN
+    INSERT INTO shipment_status_history (shipment_uid, status) VALUES (NEW.uid, NEW.status);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER shipments_status_inserted
+    AFTER INSERT ON shipments
+    FOR EACH ROW EXECUTE FUNCTION record_shipment_status();
+
+CREATE TRIGGER shipments_status_updated
+    AFTER UPDATE OF status ON shipments
+    FOR EACH ROW WHEN (OLD.status IS DISTINCT FROM NEW.status) EXECUTE FUNCTION record_shipment_status();
+
+-- the history of existing shipments starts with their current status
+INSERT INTO shipment_status_history (shipment_uid, status)
+SELECT uid, status FROM shipments;
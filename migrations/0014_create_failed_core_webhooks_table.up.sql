@@ -0,0 +1,14 @@
+-- core webhooks that failed, one per shipment status, until they are replayed
+CREATE TABLE failed_core_webhooks (
+    shipment_uid    TEXT NOT NULL,
+    status          TEXT NOT NULL,
+    version         INTEGER NOT NULL DEFAULT 0,
+    tracking        JSONB NOT NULL DEFAULT '{}',
+    error           TEXT NOT NULL,
+    attempts        INTEGER NOT NULL DEFAULT 1,
+    first_failed_at TIMESTAMPTZ NOT NULL,
+    last_failed_at  TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (shipment_uid, status)
+);
+
+CREATE INDEX failed_core_webhooks_first_failed_at_idx ON failed_core_webhooks (first_failed_at);
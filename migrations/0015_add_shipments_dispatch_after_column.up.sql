@@ -0,0 +1,2 @@
+-- pending shipments the 3pl could not be requested for are not claimed again before dispatch_after
+ALTER TABLE shipments ADD COLUMN dispatch_after TIMESTAMPTZ;
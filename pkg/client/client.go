@@ -0,0 +1,339 @@
+// Package client calls the http api of the delivery service with the typed payloads of its
+// OpenAPI document.
+//
+// Requests failing on the network or answered with 429, 502, 503 or 504 are retried with
+// exponential backoff. POST requests carry an Idempotency-Key header, the same on every attempt,
+// so the service answers retries of a request it already handled with its first response.
+package client
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxAttempts = 3
+	DefaultMinBackoff  = 100 * time.Millisecond
+	DefaultMaxBackoff  = 2 * time.Second
+
+	idempotencyKeyHeader = "Idempotency-Key"
+	// basePath is the version of the api the client speaks.
+	basePath = "/v1"
+)
+
+type Config struct {
+	// HTTPClient sends the requests, http.DefaultClient when nil.
+	HTTPClient *http.Client
+	// MaxAttempts is how many times a request is sent before giving up, DefaultMaxAttempts when
+	// zero. One disables retries.
+	MaxAttempts int
+	// MinBackoff is the wait before the first retry, doubling on every other one up to MaxBackoff.
+	// A Retry-After header of the service takes precedence.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+type Client struct {
+	baseURL string
+	config  Config
+}
+
+// NewClient calls the delivery service served at baseURL, e.g. "http://localhost:8080".
+// A nil config uses the defaults.
+func NewClient(baseURL string, config *Config) *Client {
+	c := &Client{baseURL: strings.TrimRight(baseURL, "/")}
+	if config != nil {
+		c.config = *config
+	}
+
+	if c.config.HTTPClient == nil {
+		c.config.HTTPClient = http.DefaultClient
+	}
+	if c.config.MaxAttempts <= 0 {
+		c.config.MaxAttempts = DefaultMaxAttempts
+	}
+	if c.config.MinBackoff <= 0 {
+		c.config.MinBackoff = DefaultMinBackoff
+	}
+	if c.config.MaxBackoff < c.config.MinBackoff {
+		c.config.MaxBackoff = max(DefaultMaxBackoff, c.config.MinBackoff)
+	}
+
+	return c
+}
+
+type idempotencyKeyCtxKey struct{}
+
+// WithIdempotencyKey makes the POST request sent with ctx carry key rather than a random one,
+// so a request can be retried safely across restarts of the caller.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyCtxKey{}, key)
+}
+
+// Request books the delivery of a shipment.
+func (c *Client) Request(ctx context.Context, input *RequestInput) error {
+	return c.do(ctx, http.MethodPost, "/request", nil, input, nil)
+}
+
+// RequestBatch books the delivery of many shipments at once, reporting the outcome of each.
+// Shipments whose window has started are pending until the service requests a delivery guy for
+// them, where Request has it requested before returning.
+func (c *Client) RequestBatch(ctx context.Context, input *BatchRequestInput) (*BatchRequestResult, error) {
+	var result BatchRequestResult
+	if err := c.do(ctx, http.MethodPost, "/requests:batch", nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Webhook reports a status change of a shipment.
+func (c *Client) Webhook(ctx context.Context, input *WebhookInput) error {
+	return c.do(ctx, http.MethodPost, "/webhook", nil, input, nil)
+}
+
+// Slots lists the bookable windows of a zone.
+func (c *Client) Slots(ctx context.Context, input *SlotsInput) (*SlotsResult, error) {
+	query := url.Values{}
+	if input.ZoneID != "" {
+		query.Set("zone", input.ZoneID)
+	}
+	if !input.From.IsZero() {
+		query.Set("from", input.From.Format(time.RFC3339))
+	}
+	if !input.To.IsZero() {
+		query.Set("to", input.To.Format(time.RFC3339))
+	}
+
+	var result SlotsResult
+	if err := c.do(ctx, http.MethodGet, "/slots", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Get returns a shipment, failing with a 404 *Error when it does not exist.
+func (c *Client) Get(ctx context.Context, shipmentUID string) (*Shipment, error) {
+	var result struct {
+		Shipment Shipment `json:"shipment"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/shipments/"+url.PathEscape(shipmentUID), nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result.Shipment, nil
+}
+
+// List returns a page of the shipments matching input in uid order.
+func (c *Client) List(ctx context.Context, input *ListInput) (*ListResult, error) {
+	query := url.Values{
+		"shipment_uid": input.ShipmentUIDs,
+		"zone_id":      input.ZoneIDs,
+		"status":       input.Statuses,
+	}
+	if input.PageSize != 0 {
+		query.Set("page_size", strconv.Itoa(input.PageSize))
+	}
+	if input.PageToken != "" {
+		query.Set("page_token", input.PageToken)
+	}
+
+	var result ListResult
+	if err := c.do(ctx, http.MethodGet, "/shipments", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Nearby lists the shipments destined around a location, nearest first.
+func (c *Client) Nearby(ctx context.Context, input *NearbyInput) (*NearbyResult, error) {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(input.Center.Lat, 'f', -1, 64))
+	query.Set("long", strconv.FormatFloat(input.Center.Long, 'f', -1, 64))
+	query.Set("radius_meters", strconv.FormatFloat(input.RadiusMeters, 'f', -1, 64))
+	if input.Status != "" {
+		query.Set("status", input.Status)
+	}
+	if input.Limit != 0 {
+		query.Set("limit", strconv.Itoa(input.Limit))
+	}
+
+	var result NearbyResult
+	if err := c.do(ctx, http.MethodGet, "/shipments/nearby", query, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Cancel cancels a shipment, failing with a 409 *Error of code not_cancellable once it is
+// neither queued nor pending, i.e. as soon as a delivery guy was requested for it.
+func (c *Client) Cancel(ctx context.Context, shipmentUID string) error {
+	return c.do(ctx, http.MethodPost, "/shipments/"+url.PathEscape(shipmentUID)+"/cancel", nil, nil, nil)
+}
+
+// Quote prices a delivery without booking it.
+func (c *Client) Quote(ctx context.Context, input *QuoteInput) (*QuoteResult, error) {
+	var result QuoteResult
+	if err := c.do(ctx, http.MethodPost, "/quotes", nil, input, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// do sends a request with input as its json body, decoding the response into output.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, input, output any) error {
+	resp, err := c.send(ctx, method, path, query, input)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if output == nil {
+		_, err := io.Copy(io.Discard, resp.Body)
+		return err
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(output); err != nil {
+		return fmt.Errorf("%s %s: decode response: %w", method, basePath+path, err)
+	}
+	return nil
+}
+
+// send sends a request until it is answered with 200 or fails for good, returning the response
+// for the caller to read and close.
+func (c *Client) send(ctx context.Context, method, path string, query url.Values, input any) (*http.Response, error) {
+	var body []byte
+	if input != nil {
+		var err error
+		if body, err = json.Marshal(input); err != nil {
+			return nil, err
+		}
+	}
+
+	target := c.baseURL + basePath + path
+	if encoded := query.Encode(); encoded != "" {
+		target += "?" + encoded
+	}
+
+	var idempotencyKey string
+	if method == http.MethodPost {
+		idempotencyKey, _ = ctx.Value(idempotencyKeyCtxKey{}).(string)
+		if idempotencyKey == "" {
+			idempotencyKey = newIdempotencyKey()
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, retryAfter, err := c.attempt(ctx, method, target, body, idempotencyKey)
+		if err == nil {
+			return resp, nil
+		}
+
+		if attempt >= c.config.MaxAttempts || !retryable(ctx, err) {
+			return nil, err
+		}
+
+		wait := retryAfter
+		if wait == 0 {
+			wait = c.backoff(attempt)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// attempt sends a request once, returning any Retry-After of a failed response.
+func (c *Client) attempt(ctx context.Context, method, target string, body []byte, idempotencyKey string) (*http.Response, time.Duration, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, 0, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set(idempotencyKeyHeader, idempotencyKey)
+	}
+
+	resp, err := c.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		return resp, 0, nil
+	}
+	defer resp.Body.Close()
+
+	return nil, parseRetryAfter(resp.Header.Get("Retry-After")), decodeError(method, req.URL.Path, resp)
+}
+
+// retryable reports whether a failed attempt may succeed when sent again: it failed on the network
+// or the service is overloaded, unavailable or still handling the same request.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		var urlErr *url.Error
+		return errors.As(err, &urlErr)
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		return apiErr.Code == CodeRequestInProgress
+	}
+	return false
+}
+
+// backoff is the wait before the retry following attempt, with jitter so that clients failing
+// together do not retry together.
+func (c *Client) backoff(attempt int) time.Duration {
+	wait := c.config.MaxBackoff
+	if shift := attempt - 1; shift < 32 {
+		wait = min(c.config.MinBackoff<<shift, c.config.MaxBackoff)
+	}
+	return wait/2 + rand.N(wait/2+1)
+}
+
+// parseRetryAfter parses a Retry-After header in seconds, returning zero when absent or a date.
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func newIdempotencyKey() string {
+	var key [16]byte
+	if _, err := crand.Read(key[:]); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(key[:])
+}
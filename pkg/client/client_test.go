@@ -0,0 +1,381 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aria3ppp/delivery-service-simulator/pkg/client"
+
+	"github.com/gorilla/websocket"
+)
+
+// newClient calls handler with retries that do not wait.
+func newClient(t *testing.T, handler http.HandlerFunc) *client.Client {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return client.NewClient(server.URL, &client.Config{
+		HTTPClient:  server.Client(),
+		MaxAttempts: 3,
+		MinBackoff:  time.Millisecond,
+		MaxBackoff:  time.Millisecond,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(body)
+}
+
+func TestRetriesWithTheSameIdempotencyKey(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+
+	c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		keys = append(keys, req.Header.Get("Idempotency-Key"))
+		attempt := len(keys)
+		mu.Unlock()
+
+		var input client.RequestInput
+		if err := json.NewDecoder(req.Body).Decode(&input); err != nil || input.ShipmentUID != "shipment_1" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("unexpected body: %v", err)})
+			return
+		}
+
+		if attempt < 3 {
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "unavailable"})
+			return
+		}
+		writeJSON(w, http.StatusOK, struct{}{})
+	})
+
+	if err := c.Request(context.Background(), &client.RequestInput{ShipmentUID: "shipment_1"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 3 {
+		t.Fatalf("sent %d attempts, want 3", len(keys))
+	}
+	if keys[0] == "" || keys[1] != keys[0] || keys[2] != keys[0] {
+		t.Errorf("idempotency keys %q, want the same one on every attempt", keys)
+	}
+
+	// another request has another key
+	if err := c.Request(context.Background(), &client.RequestInput{ShipmentUID: "shipment_1"}); err != nil {
+		t.Fatal(err)
+	}
+	if keys[3] == keys[0] {
+		t.Errorf("idempotency key %q reused by another request", keys[3])
+	}
+}
+
+func TestWithIdempotencyKey(t *testing.T) {
+	var key string
+	c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+		key = req.Header.Get("Idempotency-Key")
+		writeJSON(w, http.StatusOK, struct{}{})
+	})
+
+	if err := c.Cancel(client.WithIdempotencyKey(context.Background(), "cancel-shipment_1"), "shipment_1"); err != nil {
+		t.Fatal(err)
+	}
+	if key != "cancel-shipment_1" {
+		t.Errorf("idempotency key %q, want cancel-shipment_1", key)
+	}
+}
+
+func TestGiveUpAfterMaxAttempts(t *testing.T) {
+	var attempts atomic.Int32
+	c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+		attempts.Add(1)
+		if req.Header.Get("Idempotency-Key") != "" {
+			t.Error("GET sent with an idempotency key")
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		io.WriteString(w, "<html>bad gateway</html>")
+	})
+
+	_, err := c.Get(context.Background(), "shipment_1")
+
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("got %v, want a *client.Error", err)
+	}
+	if apiErr.StatusCode != http.StatusBadGateway || apiErr.Message != "<html>bad gateway</html>" {
+		t.Errorf("got %+v", apiErr)
+	}
+	if attempts.Load() != 3 {
+		t.Errorf("sent %d attempts, want 3", attempts.Load())
+	}
+}
+
+func TestErrorDecoding(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		status   int
+		body     any
+		want     client.Error
+		attempts int32
+	}{
+		{
+			name:   "validation",
+			status: http.StatusUnprocessableEntity,
+			body: map[string]any{
+				"error":      "validation failed",
+				"violations": []map[string]string{{"field": "shipment_uid", "code": "required", "message": "is required"}},
+			},
+			want: client.Error{
+				StatusCode: http.StatusUnprocessableEntity,
+				Message:    "validation failed",
+				Violations: []client.FieldViolation{{Field: "shipment_uid", Code: "required", Message: "is required"}},
+			},
+			attempts: 1,
+		},
+		{
+			name:     "conflict",
+			status:   http.StatusConflict,
+			body:     map[string]string{"error": "shipment already exists", "code": client.CodeDuplicate},
+			want:     client.Error{StatusCode: http.StatusConflict, Message: "shipment already exists", Code: client.CodeDuplicate},
+			attempts: 1,
+		},
+		{
+			name:     "in progress",
+			status:   http.StatusConflict,
+			body:     map[string]string{"error": "in progress", "code": client.CodeRequestInProgress},
+			want:     client.Error{StatusCode: http.StatusConflict, Message: "in progress", Code: client.CodeRequestInProgress},
+			attempts: 3,
+		},
+		{
+			name:     "not found",
+			status:   http.StatusNotFound,
+			body:     map[string]string{"error": "shipment not found"},
+			want:     client.Error{StatusCode: http.StatusNotFound, Message: "shipment not found"},
+			attempts: 1,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+				attempts.Add(1)
+				writeJSON(w, test.status, test.body)
+			})
+
+			err := c.Request(context.Background(), &client.RequestInput{ShipmentUID: "shipment_1"})
+
+			var apiErr *client.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("got %v, want a *client.Error", err)
+			}
+
+			test.want.Method, test.want.Path = http.MethodPost, "/v1/request"
+			if apiErr.StatusCode != test.want.StatusCode || apiErr.Message != test.want.Message || apiErr.Code != test.want.Code ||
+				apiErr.Method != test.want.Method || apiErr.Path != test.want.Path || !slices.Equal(apiErr.Violations, test.want.Violations) {
+				t.Errorf("got %+v, want %+v", apiErr, test.want)
+			}
+			if attempts.Load() != test.attempts {
+				t.Errorf("sent %d attempts, want %d", attempts.Load(), test.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWithContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	c := client.NewClient(server.URL, &client.Config{HTTPClient: server.Client()})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.Slots(ctx, &client.SlotsInput{})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("gave up after %s", elapsed)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	from := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	for _, test := range []struct {
+		name string
+		call func(*client.Client) error
+		want string
+	}{
+		{
+			name: "list",
+			call: func(c *client.Client) error {
+				_, err := c.List(context.Background(), &client.ListInput{
+					ShipmentFilter: client.ShipmentFilter{Statuses: []string{"searching", "not_found"}, ZoneIDs: []string{"north"}},
+					PageSize:       10,
+					PageToken:      "token",
+				})
+				return err
+			},
+			want: "/v1/shipments?page_size=10&page_token=token&status=searching&status=not_found&zone_id=north",
+		},
+		{
+			name: "nearby",
+			call: func(c *client.Client) error {
+				_, err := c.Nearby(context.Background(), &client.NearbyInput{
+					Center:       client.Location{Lat: 35.7, Long: 51.4},
+					RadiusMeters: 500,
+					Status:       "searching",
+				})
+				return err
+			},
+			want: "/v1/shipments/nearby?lat=35.7&long=51.4&radius_meters=500&status=searching",
+		},
+		{
+			name: "slots",
+			call: func(c *client.Client) error {
+				_, err := c.Slots(context.Background(), &client.SlotsInput{ZoneID: "north", From: from, To: from.Add(time.Hour)})
+				return err
+			},
+			want: "/v1/slots?from=2025-01-01T09%3A00%3A00Z&to=2025-01-01T10%3A00%3A00Z&zone=north",
+		},
+		{
+			name: "get",
+			call: func(c *client.Client) error {
+				_, err := c.Get(context.Background(), "a/b")
+				return err
+			},
+			want: "/v1/shipments/a%2Fb",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var got string
+			c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+				got = req.URL.RequestURI()
+				writeJSON(w, http.StatusOK, struct{}{})
+			})
+
+			if err := test.call(c); err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("requested %s, want %s", got, test.want)
+			}
+		})
+	}
+}
+
+func TestDecodesResults(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"shipment": map[string]any{
+				"uid":    "shipment_1",
+				"status": "in_transit",
+				"destination_point": map[string]any{
+					"lat": 35.72, "long": 51.41, "type": "Point", "coordinates": []float64{51.41, 35.72},
+				},
+				"tracking": map[string]any{"courier": map[string]any{"id": "courier_1"}},
+			},
+		})
+	})
+
+	shipment, err := c.Get(context.Background(), "shipment_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shipment.UID != "shipment_1" || shipment.Status != "in_transit" ||
+		shipment.DestinationPoint != (client.Location{Lat: 35.72, Long: 51.41}) ||
+		shipment.Tracking.Courier == nil || shipment.Tracking.Courier.ID != "courier_1" {
+		t.Errorf("got %+v", shipment)
+	}
+}
+
+func TestEvents(t *testing.T) {
+	c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "event: shipment\ndata: {\"shipment_uid\":\"shipment_1\",\"status\":\"searching\"}\n\n")
+		io.WriteString(w, ": heartbeat\n\n")
+		io.WriteString(w, "event: shipment\ndata: {\"shipment_uid\":\"shipment_1\",\"status\":\"found\"}\n\n")
+	})
+
+	stream, err := c.Events(context.Background(), "shipment_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	for _, want := range []string{"searching", "found"} {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.ShipmentUID != "shipment_1" || event.Status != want {
+			t.Errorf("got %+v, want status %s", event, want)
+		}
+	}
+
+	if _, err := stream.Next(); err != io.EOF {
+		t.Errorf("got %v, want io.EOF", err)
+	}
+}
+
+func TestWatch(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+
+	c := newClient(t, func(w http.ResponseWriter, req *http.Request) {
+		conn, err := upgrader.Upgrade(w, req, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			var message map[string]any
+			if err := conn.ReadJSON(&message); err != nil {
+				return
+			}
+			conn.WriteJSON(map[string]any{
+				"type":   client.WatchSubscribed,
+				"id":     message["id"],
+				"events": []map[string]any{{"shipment_uid": "shipment_1", "zone_id": message["zone_ids"].([]any)[0], "status": "searching"}},
+			})
+		}
+	})
+
+	watcher, err := c.Watch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Subscribe("north", client.ShipmentFilter{ZoneIDs: []string{"north"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := watcher.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if message.Type != client.WatchSubscribed || message.ID != "north" || len(message.Events) != 1 || message.Events[0].ZoneID != "north" {
+		t.Errorf("got %+v", message)
+	}
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Codes of the conflicts the service answers with 409, and of the errors of idempotency keys.
+const (
+	CodeDuplicate            = "duplicate"
+	CodeNotCancellable       = "not_cancellable"
+	CodeRequestInProgress    = "request_in_progress"
+	CodeIdempotencyKeyReused = "idempotency_key_reused"
+)
+
+// Error is a response of the service other than 200, decoded from its json error body.
+// Validation errors are 422 and carry their Violations, conflicts are 409 and carry a Code.
+//
+//	var apiErr *client.Error
+//	if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+type Error struct {
+	Method     string `json:"-"`
+	Path       string `json:"-"`
+	StatusCode int    `json:"-"`
+
+	Message    string           `json:"error"`
+	Code       string           `json:"code,omitempty"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("%s %s: status code %d: %s", e.Method, e.Path, e.StatusCode, e.Message)
+	if e.Code != "" {
+		message += " (" + e.Code + ")"
+	}
+	for _, violation := range e.Violations {
+		message += fmt.Sprintf("; %s: %s", violation.Field, violation.Message)
+	}
+	return message
+}
+
+// decodeError reads the error body of resp. Bodies that are not json, e.g. from a proxy, are kept
+// as the message.
+func decodeError(method, path string, resp *http.Response) *Error {
+	apiErr := &Error{Method: method, Path: path, StatusCode: resp.StatusCode}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil || json.Unmarshal(body, apiErr) != nil || apiErr.Message == "" {
+		apiErr.Message = string(bytes.TrimSpace(body))
+	}
+	if apiErr.Message == "" {
+		apiErr.Message = http.StatusText(resp.StatusCode)
+	}
+
+	return apiErr
+}
@@ -0,0 +1,166 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// EventStream reads the server-sent events of a shipment.
+type EventStream struct {
+	body    io.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// Events streams the changes of a shipment, starting with its current state. The stream ends with
+// io.EOF when the client falls too far behind, it should then be opened again.
+func (c *Client) Events(ctx context.Context, shipmentUID string) (*EventStream, error) {
+	resp, err := c.send(ctx, http.MethodGet, "/shipments/"+url.PathEscape(shipmentUID)+"/events", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EventStream{body: resp.Body, scanner: bufio.NewScanner(resp.Body)}, nil
+}
+
+// Next blocks until the next event, skipping heartbeats.
+func (s *EventStream) Next() (*ShipmentEvent, error) {
+	var data []byte
+
+	for s.scanner.Scan() {
+		line := s.scanner.Bytes()
+
+		switch {
+		// an empty line dispatches the event read so far
+		case len(line) == 0:
+			if data == nil {
+				continue
+			}
+
+			var event ShipmentEvent
+			if err := json.Unmarshal(data, &event); err != nil {
+				return nil, err
+			}
+			return &event, nil
+
+		case bytes.HasPrefix(line, []byte("data:")):
+			if data != nil {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" "))...)
+		}
+	}
+
+	if err := s.scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *EventStream) Close() error {
+	return s.body.Close()
+}
+
+// Types of the messages of a Watcher.
+const (
+	WatchSubscribed   = "subscribed"
+	WatchUnsubscribed = "unsubscribed"
+	// WatchDropped ends a subscription falling too far behind, it should be subscribed again.
+	WatchDropped = "dropped"
+	WatchEvents  = "events"
+	WatchError   = "error"
+)
+
+// WatchMessage is sent by the service on a Watcher. Subscribed messages carry the current state of
+// the shipments of subscription ID, events messages the latest state of the shipments changing.
+type WatchMessage struct {
+	Type       string           `json:"type"`
+	ID         string           `json:"id,omitempty"`
+	Events     []ShipmentEvent  `json:"events,omitempty"`
+	Error      string           `json:"error,omitempty"`
+	Violations []FieldViolation `json:"violations,omitempty"`
+}
+
+type watchSubscription struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	ShipmentFilter
+}
+
+// Watcher watches many shipments over a websocket. Next must be called continuously, it also
+// answers the pings keeping the connection open.
+type Watcher struct {
+	conn *websocket.Conn
+	// mu serializes writes, the websocket allows a single writer.
+	mu sync.Mutex
+}
+
+// Watch opens a websocket to subscribe to shipments on. ctx only bounds the handshake.
+func (c *Client) Watch(ctx context.Context) (*Watcher, error) {
+	target := "ws" + strings.TrimPrefix(c.baseURL, "http") + basePath + "/shipments/watch"
+
+	dialer := websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		Jar:              c.config.HTTPClient.Jar,
+	}
+
+	conn, resp, err := dialer.DialContext(ctx, target, nil)
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			defer resp.Body.Close()
+			return nil, decodeError(http.MethodGet, basePath+"/shipments/watch", resp)
+		}
+		return nil, err
+	}
+
+	return &Watcher{conn: conn}, nil
+}
+
+// Subscribe subscribes to the shipments matching filter under id, to be unsubscribed with later.
+// The service answers with a subscribed or an error message of the same id.
+func (w *Watcher) Subscribe(id string, filter ShipmentFilter) error {
+	return w.write(watchSubscription{Type: "subscribe", ID: id, ShipmentFilter: filter})
+}
+
+func (w *Watcher) Unsubscribe(id string) error {
+	return w.write(watchSubscription{Type: "unsubscribe", ID: id})
+}
+
+func (w *Watcher) write(message any) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.conn.WriteJSON(message)
+}
+
+// Next blocks until the next message of the service.
+func (w *Watcher) Next() (*WatchMessage, error) {
+	var message WatchMessage
+	if err := w.conn.ReadJSON(&message); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// Close ends the subscriptions and closes the websocket.
+func (w *Watcher) Close() error {
+	err := w.conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+		time.Now().Add(time.Second),
+	)
+	if closeErr := w.conn.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
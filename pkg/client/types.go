@@ -0,0 +1,218 @@
+package client
+
+import "time"
+
+// The payloads of the delivery service api, see api/openapi.json. They are kept in sync with the
+// document by the tests of package api.
+
+// Location is a WGS84 coordinate.
+type Location struct {
+	Lat  float64 `json:"lat"`
+	Long float64 `json:"long"`
+}
+
+type RoutingInfo struct {
+	Origin      Location `json:"origin"`
+	Destination Location `json:"destination"`
+}
+
+type Address struct {
+	Street     string `json:"street"`
+	City       string `json:"city,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string `json:"country,omitempty"`
+	// Notes are delivery instructions for the courier.
+	Notes string `json:"notes,omitempty"`
+}
+
+type UserInfo struct {
+	UserUID string  `json:"user_uid"`
+	Address Address `json:"address"`
+}
+
+type ScheduledDeliveryWindow struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+}
+
+type RequestInput struct {
+	ShipmentUID             string                  `json:"shipment_uid"`
+	UserInfo                UserInfo                `json:"user_info"`
+	RoutingInfo             RoutingInfo             `json:"routing_info"`
+	ScheduledDeliveryWindow ScheduledDeliveryWindow `json:"scheduled_delivery_window"`
+	// QuoteToken books the shipment with the price of a previously issued quote.
+	QuoteToken string `json:"quote_token,omitempty"`
+}
+
+type Courier struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Phone   string `json:"phone,omitempty"`
+	Vehicle string `json:"vehicle,omitempty"`
+}
+
+type ProofOfDelivery struct {
+	DeliveredAt   time.Time `json:"delivered_at"`
+	RecipientName string    `json:"recipient_name,omitempty"`
+	// PhotoRef references the photo taken on delivery in the 3pl's storage.
+	PhotoRef string `json:"photo_ref,omitempty"`
+}
+
+// Tracking is what a 3pl reports of a delivery underway.
+type Tracking struct {
+	Courier         *Courier         `json:"courier,omitempty"`
+	CourierLocation *Location        `json:"courier_location,omitempty"`
+	PickupETA       *time.Time       `json:"pickup_eta,omitempty"`
+	DropoffETA      *time.Time       `json:"dropoff_eta,omitempty"`
+	ProofOfDelivery *ProofOfDelivery `json:"proof_of_delivery,omitempty"`
+	// FailureReason is why the courier could not hand the shipment over, e.g. recipient_unavailable.
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// WebhookInput reports a status change from a 3pl. Tracking is only read from version 2 on and
+// only carries what changed.
+type WebhookInput struct {
+	Version     int    `json:"version,omitempty"`
+	ShipmentUID string `json:"shipment_uid"`
+	Status      string `json:"status"`
+	Tracking
+}
+
+type FieldViolation struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type BatchRequestInput struct {
+	Items []RequestInput `json:"items"`
+}
+
+// Statuses of the items of a batch request.
+const (
+	BatchItemStatusCreated   = "created"
+	BatchItemStatusDuplicate = "duplicate"
+	BatchItemStatusInvalid   = "invalid"
+)
+
+type BatchRequestItemResult struct {
+	ShipmentUID string           `json:"shipment_uid"`
+	Status      string           `json:"status"`
+	Reason      string           `json:"reason,omitempty"`
+	Violations  []FieldViolation `json:"violations,omitempty"`
+}
+
+type BatchRequestResult struct {
+	Results []BatchRequestItemResult `json:"results"`
+}
+
+// SlotsInput lists the bookable windows of a zone between From and To, the zero times leave the
+// service to pick the range.
+type SlotsInput struct {
+	ZoneID string
+	From   time.Time
+	To     time.Time
+}
+
+// Slot is a bookable window. Capacity and Remaining are nil when slots are unlimited.
+type Slot struct {
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Capacity  *int      `json:"capacity,omitempty"`
+	Remaining *int      `json:"remaining,omitempty"`
+}
+
+type SlotsResult struct {
+	Slots []Slot `json:"slots"`
+}
+
+type Shipment struct {
+	UID                      string    `json:"uid"`
+	UserUID                  string    `json:"user_uid"`
+	UserAddr                 string    `json:"user_addr"`
+	UserAddress              Address   `json:"user_address"`
+	OriginPoint              Location  `json:"origin_point"`
+	DestinationPoint         Location  `json:"destination_point"`
+	ScheduledDeliveryMinTime time.Time `json:"scheduled_delivery_min_time"`
+	ScheduledDeliveryMaxTime time.Time `json:"scheduled_delivery_max_time"`
+	Status                   string    `json:"status"`
+	DistanceMeters           float64   `json:"distance_meters"`
+	ETASeconds               int       `json:"eta_seconds"`
+	ZoneID                   string    `json:"zone_id"`
+	PriceAmount              int64     `json:"price_amount"`
+	PriceCurrency            string    `json:"price_currency"`
+	Tracking                 Tracking  `json:"tracking"`
+}
+
+// ShipmentFilter matches shipments by uid, or by zone and status. Empty fields match everything.
+type ShipmentFilter struct {
+	ShipmentUIDs []string `json:"shipment_uids,omitempty"`
+	ZoneIDs      []string `json:"zone_ids,omitempty"`
+	Statuses     []string `json:"statuses,omitempty"`
+}
+
+type ListInput struct {
+	ShipmentFilter
+	// PageSize is left to the service when zero.
+	PageSize int
+	// PageToken continues a listing from the NextPageToken of its previous page.
+	PageToken string
+}
+
+type ListResult struct {
+	Shipments []Shipment `json:"shipments"`
+	// NextPageToken is empty on the last page.
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+// NearbyInput lists the shipments destined within RadiusMeters of Center, optionally in Status.
+type NearbyInput struct {
+	Center       Location
+	RadiusMeters float64
+	Status       string
+	// Limit is left to the service when zero.
+	Limit int
+}
+
+type NearbyResult struct {
+	Shipments []Shipment `json:"shipments"`
+}
+
+// ShipmentEvent is the state of a shipment at At.
+type ShipmentEvent struct {
+	ShipmentUID string `json:"shipment_uid"`
+	// ZoneID is empty for shipments outside of any zone.
+	ZoneID   string    `json:"zone_id,omitempty"`
+	Status   string    `json:"status"`
+	Tracking *Tracking `json:"tracking,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+type QuoteInput struct {
+	RoutingInfo             RoutingInfo             `json:"routing_info"`
+	ScheduledDeliveryWindow ScheduledDeliveryWindow `json:"scheduled_delivery_window"`
+}
+
+type PriceBreakdown struct {
+	BaseFare         int64   `json:"base_fare"`
+	DistanceFare     int64   `json:"distance_fare"`
+	DistanceMeters   float64 `json:"distance_meters"`
+	ZoneMultiplier   float64 `json:"zone_multiplier"`
+	PeakMultiplier   float64 `json:"peak_multiplier"`
+	DemandMultiplier float64 `json:"demand_multiplier"`
+}
+
+type Price struct {
+	Amount    int64          `json:"amount"`
+	Currency  string         `json:"currency"`
+	Breakdown PriceBreakdown `json:"breakdown"`
+}
+
+// QuoteResult is a price valid until ExpiresAt. Token is to be sent back as the QuoteToken of
+// the request.
+type QuoteResult struct {
+	Token     string    `json:"token"`
+	Price     Price     `json:"price"`
+	ExpiresAt time.Time `json:"expires_at"`
+}